@@ -1,40 +1,73 @@
 package main
 
 import (
-	"fmt"
-	"io"
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"platform/gateway/internal/accesslog"
 	"platform/gateway/internal/auth"
-	"platform/gateway/internal/limiter"
+	"platform/gateway/internal/contract"
+	"platform/gateway/internal/metrics"
+	"platform/gateway/internal/problem"
+	"platform/gateway/internal/routing"
+	"platform/shared/health"
+	"platform/shared/logging"
+	"platform/shared/tracing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-var (
-	authServiceURL     string
-	functionServiceURL string
-)
+// readinessTimeout bounds each dependency check of /readyz
+const readinessTimeout = 2 * time.Second
 
-func main() {
-	// Read service URLs from environment variables
-	authServiceURL = os.Getenv("AUTH_SERVICE_URL")
-	if authServiceURL == "" {
-		authServiceURL = "http://auth:8083" // Default Auth Service URL
-	}
+// defaultReloadInterval is how often the routes file is checked for changes
+const defaultReloadInterval = 5 * time.Second
 
-	functionServiceURL = os.Getenv("FUNCTION_SERVICE_URL")
-	if functionServiceURL == "" {
-		functionServiceURL = "http://functionservice:8080" // Default Search Service URL
+func main() {
+	if _, err := logging.Setup(os.Getenv("LOG_LEVEL")); err != nil {
+		logging.Fatal("Invalid log configuration", "error", err)
 	}
 
-	// Initialize Gin router
-	r := gin.Default()
+	shutdownTracing, err := tracing.Setup(context.Background(), "api-gateway")
+	if err != nil {
+		logging.Fatal("Error initializing tracing", "error", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
+
+	// Initialize Gin router. Requests are logged as JSON by our own middleware
+	// rather than gin's text logger.
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(accesslog.Middleware())
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(problem.NoMethod)
+
+	// Metrics are recorded for every request, including ones the limiters
+	// refuse. /metrics is registered before the limiters, auth and contract
+	// checks so scrapes are never throttled or rejected.
+	r.Use(metrics.Middleware())
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// The probes are public and unlimited for the same reason. Checks are
+	// added as the dependencies are created.
+	checker := health.NewChecker(readinessTimeout)
+	r.GET("/livez", gin.WrapF(checker.Live))
+	r.GET("/readyz", gin.WrapF(checker.Ready))
+
+	// Trace every request after /metrics and the probes so they don't fill the traces
+	r.Use(otelgin.Middleware("api-gateway"))
 
 	// Initialize Redis client for rate-limiting and concurrency control
 	redisClient := redis.NewClient(&redis.Options{
@@ -42,48 +75,50 @@ func main() {
 		Password: "",
 		DB:       0,
 	})
+	if err := redisotel.InstrumentTracing(redisClient); err != nil {
+		logging.Fatal("Error tracing Redis", "error", err)
+	}
+	// Every request needs Redis for rate limiting. The upstream services have
+	// probes of their own; checking them here would take the gateway out of
+	// rotation, and with it every other route, whenever one of them is down.
+	checker.Add("redis", func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
 
-	// Apply rate limiting middleware, handle error
-	limitMW, err := limiter.NewRateLimitMiddleware(redisClient, "token-bucket", 10, "1m")
+	// Requests gin has no route for are forwarded according to the routes
+	// file, which is reloaded when it changes or on SIGHUP. Each route's
+	// rate limit is applied here, before the contract check.
+	routesPath := os.Getenv("GATEWAY_ROUTES_PATH")
+	if routesPath == "" {
+		routesPath = "config/routes.yaml"
+	}
+	routes, err := routing.New(routesPath, redisClient)
 	if err != nil {
-		log.Fatalf("Error initializing rate limit middleware: %v", err)
+		logging.Fatal("Invalid routes", "path", routesPath, "error", err)
 	}
-	r.Use(limitMW) // Apply the middleware if no error occurred
+	watchRoutes(routes)
+	r.Use(routes.Middleware())
+	r.NoRoute(routes.Handle)
 
-	r.Use(concurrencyMiddleware(redisClient))
-
-	// Public routes (authentication)
-	public := r.Group("/auth")
-	{
-		public.POST("/register", forwardToAuthService)
-		public.POST("/login", forwardToAuthService)   // Handles OAuth2 + JWT generation
-		public.POST("/refresh", forwardToAuthService) // Refresh JWT
+	// Check traffic against the OpenAPI contract. Only strict mode refuses to start without it.
+	contractMode, err := contract.ParseMode(os.Getenv("CONTRACT_MODE"))
+	if err != nil {
+		logging.Fatal("Invalid contract configuration", "error", err)
 	}
-
-	// Protected routes (requires JWT validation)
-	protected := r.Group("/")
-	protected.Use(auth.AuthMiddleware()) // Apply JWT validation middleware
-	{
-		protected.GET("/health", func(c *gin.Context) {
-			c.String(http.StatusOK, "API Gateway is healthy\n")
-		})
-
-		// Forward protected routes to the Search Service
-		protected.Any("/functions", forwardToFunctionService)
-		protected.Any("/functions/*rest", forwardToFunctionService)
-
-		protected.Any("/jobs", forwardToFunctionService)
-		protected.Any("/jobs/*rest", forwardToFunctionService)
-
-		// Admin routes with additional role-based access control
-		admin := protected.Group("/admin")
-		admin.Use(auth.RequireRoles("admin"))
-		{
-			admin.GET("/dashboard", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"dashboard": "admin metrics"})
-			})
+	specPath := os.Getenv("OPENAPI_SPEC_PATH")
+	if specPath == "" {
+		specPath = "../../api-specs/openapi.yaml"
+	}
+	validator, err := contract.Load(specPath, "gateway", contractMode)
+	if err != nil {
+		if contractMode == contract.ModeStrict {
+			logging.Fatal("Failed to load API contract", "error", err)
 		}
+		slog.Warn("API contract not loaded, requests are not checked", "error", err)
 	}
+	r.Use(validator.Middleware())
+
+	registerRoutes(r)
 
 	// Set the port for the API Gateway to run on
 	addr := ":8082"
@@ -91,87 +126,58 @@ func main() {
 		addr = ":" + port
 	}
 
-	log.Printf("Starting gateway on %s", addr)
+	slog.Info("Starting gateway", "addr", addr)
 	if err := r.Run(addr); err != nil {
-		log.Fatal("Failed to start gateway:", err)
-	}
-}
-
-// Middleware to handle concurrency control using Redis
-func concurrencyMiddleware(rdb *redis.Client) gin.HandlerFunc {
-	// Initialize rate limiting middleware and check for error
-	limitMW, err := limiter.NewRateLimitMiddleware(rdb, "token-bucket", 10, "1m")
-	if err != nil {
-		log.Fatalf("Error initializing rate limit middleware: %v", err) // Log and terminate if there is an error
+		logging.Fatal("Failed to start gateway", "error", err)
 	}
-	return limitMW // Return the middleware if no error
 }
 
-// Forward incoming requests to the Authentication Service
-func forwardToAuthService(c *gin.Context) {
-	path := strings.TrimPrefix(c.Request.URL.Path, "/auth")
-	targetURL := fmt.Sprintf("%s%s", authServiceURL, path)
-	log.Printf("Forwarding to Auth Service => %s", targetURL)
-
-	req, err := http.NewRequest(c.Request.Method, targetURL, c.Request.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
-		return
+// watchRoutes reloads the routes when their file changes, and on SIGHUP
+func watchRoutes(routes *routing.Router) {
+	interval := defaultReloadInterval
+	if value := os.Getenv("GATEWAY_ROUTES_RELOAD_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			logging.Fatal("Invalid routes reload interval", "error", err)
+		}
+		interval = parsed
 	}
-
-	for k, v := range c.Request.Header {
-		req.Header[k] = v
+	if interval > 0 {
+		go routes.Watch(context.Background(), interval)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth service unreachable"})
-		return
-	}
-	defer resp.Body.Close()
-
-	c.Status(resp.StatusCode)
-	for k, v := range resp.Header {
-		c.Writer.Header()[k] = v
-	}
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	c.Writer.Write(bodyBytes)
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			if err := routes.Reload(); err != nil {
+				slog.Error("Routes not reloaded, keeping the previous routes", "error", err)
+			}
+		}
+	}()
 }
 
-// Forward incoming requests to the Search Service (functions, jobs)
-func forwardToFunctionService(c *gin.Context) {
-	finalURL := functionServiceURL + c.Request.URL.Path
-
-	if c.Request.URL.RawQuery != "" {
-		finalURL += "?" + c.Request.URL.RawQuery
-	}
-
-	log.Printf("Forwarding to Function Service => %s", finalURL)
-
-	req, err := http.NewRequest(c.Request.Method, finalURL, c.Request.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
-		return
-	}
-
-	for k, v := range c.Request.Header {
-		req.Header[k] = v
-	}
-
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "function service unreachable"})
-		return
-	}
-	defer resp.Body.Close()
+// registerRoutes adds the endpoints the gateway serves itself; everything
+// else is forwarded by the routing table. Each one must also be described in
+// api-specs/openapi.yaml, which routes_test.go checks.
+func registerRoutes(r *gin.Engine) {
+	// Public like the probes, which it predates; /livez and /readyz are
+	// what orchestrators should use
+	r.GET("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "API Gateway is healthy\n")
+	})
 
-	c.Status(resp.StatusCode)
-	for k, v := range resp.Header {
-		c.Writer.Header()[k] = v
+	// Protected routes (requires JWT validation)
+	protected := r.Group("/")
+	protected.Use(auth.AuthMiddleware()) // Apply JWT validation middleware
+	{
+		// Admin routes with additional role-based access control
+		admin := protected.Group("/admin")
+		admin.Use(auth.RequireRoles("admin"))
+		{
+			admin.GET("/dashboard", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"dashboard": "admin metrics"})
+			})
+		}
 	}
-
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	c.Writer.Write(bodyBytes)
 }
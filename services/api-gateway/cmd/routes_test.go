@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"platform/gateway/internal/contract"
+
+	"github.com/gin-gonic/gin"
+)
+
+const specPath = "../../../api-specs/openapi.yaml"
+
+// TestRoutesMatchSpec fails when a registered route is missing from
+// api-specs/openapi.yaml or the spec describes a route that is not registered
+func TestRoutesMatchSpec(t *testing.T) {
+	doc, err := contract.LoadSpec(specPath, "gateway")
+	if err != nil {
+		t.Fatalf("error loading spec: %v", err)
+	}
+
+	specified := map[string]bool{}
+	for template, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			specified[method+" "+template] = true
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerRoutes(r)
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		// OpenAPI has no CONNECT operation, so routes registered with Any
+		// are described by their other methods
+		if route.Method == http.MethodConnect {
+			continue
+		}
+		registered[route.Method+" "+contract.PathTemplate(route.Path)] = true
+	}
+
+	if missing := difference(registered, specified); len(missing) > 0 {
+		t.Errorf("registered routes missing from the spec:\n  %s", strings.Join(missing, "\n  "))
+	}
+	if stale := difference(specified, registered); len(stale) > 0 {
+		t.Errorf("spec paths that are not registered:\n  %s", strings.Join(stale, "\n  "))
+	}
+}
+
+// difference returns the keys of a that are not in b, sorted
+func difference(a, b map[string]bool) []string {
+	var out []string
+	for key := range a {
+		if !b[key] {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,38 @@
+// Package accesslog logs every request the gateway handles. The gateway
+// assigns each request an ID and passes it on to the services behind it, so
+// the lines each service logs while handling the request can be found from
+// one ID.
+package accesslog
+
+import (
+	"time"
+
+	"platform/gateway/internal/metrics"
+	"platform/shared/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware gives every request an ID, reusing the client's when there is
+// one, and logs the request once it is handled. The ID is set on the request
+// headers so it is forwarded to the services behind the gateway.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := logging.NewRequestID(c.GetHeader(logging.RequestIDHeader))
+		c.Request.Header.Set(logging.RequestIDHeader, id)
+		c.Header(logging.RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+		start := time.Now()
+		c.Next()
+
+		route := metrics.Route(c)
+		logging.FromContext(c.Request.Context()).Info("Request handled",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", route,
+			"status", c.Writer.Status(),
+			"client_ip", c.ClientIP(),
+			"duration_ms", time.Since(start).Milliseconds())
+	}
+}
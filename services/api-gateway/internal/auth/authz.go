@@ -4,35 +4,38 @@ import (
 	"github.com/gin-gonic/gin"
 	"golang.org/x/exp/slices"
 	"net/http"
+	"platform/gateway/internal/problem"
 )
 
 func RequireRoles(requiredRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		rolesVal, exists := c.Get(CtxRolesKey)
-		if !exists {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing roles in token"})
-			return
-		}
-		roles, ok := rolesVal.([]string)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid roles format"})
-			return
+		if Authorize(c, requiredRoles...) {
+			c.Next()
 		}
+	}
+}
 
-		// check if user has at least one of the required roles
-		authorized := false
-		for _, rr := range requiredRoles {
-			if slices.Contains(roles, rr) {
-				authorized = true
-				break
-			}
-		}
+// Authorize checks that the user authenticated by Authenticate has at least
+// one of requiredRoles, aborting with 403 Forbidden when they don't
+func Authorize(c *gin.Context, requiredRoles ...string) bool {
+	rolesVal, exists := c.Get(CtxRolesKey)
+	if !exists {
+		problem.Abort(c, http.StatusForbidden, "missing_roles", "missing roles in token")
+		return false
+	}
+	roles, ok := rolesVal.([]string)
+	if !ok {
+		problem.Abort(c, http.StatusForbidden, "invalid_roles", "invalid roles format")
+		return false
+	}
 
-		if !authorized {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden, insufficient roles"})
-			return
+	// check if user has at least one of the required roles
+	for _, rr := range requiredRoles {
+		if slices.Contains(roles, rr) {
+			return true
 		}
-
-		c.Next()
 	}
+
+	problem.Abort(c, http.StatusForbidden, "insufficient_roles", "forbidden, insufficient roles")
+	return false
 }
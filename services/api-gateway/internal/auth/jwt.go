@@ -7,6 +7,9 @@ import (
 	"os"
 	"strings"
 
+	"platform/gateway/internal/problem"
+	"platform/shared/logging"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -16,32 +19,55 @@ var hmacSecret = []byte(os.Getenv("JWT_SECRET"))
 const CtxUserKey = "usedID"
 const CtxRolesKey = "roles"
 
+// UserIDHeader tells upstreams which user the gateway authenticated. It is
+// only ever set from a verified token; values sent by clients are dropped.
+const UserIDHeader = "X-User-ID"
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
-			return
+		if Authenticate(c) {
+			c.Next()
 		}
+	}
+}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header"})
-			return
-		}
+// Authenticate checks the bearer token of the request and stores its user
+// and roles in c. It aborts with 401 Unauthorized when the token is missing
+// or invalid.
+func Authenticate(c *gin.Context) bool {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		problem.Abort(c, http.StatusUnauthorized, "missing_token", "missing Authorization header")
+		return false
+	}
 
-		tokenString := parts[1]
-		claims, err := parseToken(tokenString)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			return
-		}
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		problem.Abort(c, http.StatusUnauthorized, "invalid_token", "invalid Authorization header")
+		return false
+	}
 
-		c.Set(CtxUserKey, claims.UserID)
-		c.Set(CtxRolesKey, claims.Roles)
+	tokenString := parts[1]
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Warn("Rejected token", "error", err)
+		problem.Abort(c, http.StatusUnauthorized, "invalid_token", "invalid or expired token")
+		return false
+	}
 
-		c.Next()
+	userID := claims.UserID
+	if userID == "" {
+		userID = claims.Subject
 	}
+	c.Set(CtxUserKey, userID)
+	c.Set(CtxRolesKey, claims.Roles)
+	return true
+}
+
+// UserID returns the user authenticated by Authenticate, if any
+func UserID(c *gin.Context) (string, bool) {
+	userID := c.GetString(CtxUserKey)
+	return userID, userID != ""
 }
 
 func parseToken(tokenString string) (*CustomClaims, error) {
@@ -0,0 +1,267 @@
+// Package contract checks gateway traffic against the OpenAPI spec in
+// api-specs/openapi.yaml, which is the authoritative description of the API
+package contract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"platform/gateway/internal/problem"
+	"platform/shared/logging"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceExtension names the service that serves a path item
+const ServiceExtension = "x-service"
+
+// ProxyExtension marks operations whose responses come from an upstream
+// service and are passed through unchecked
+const ProxyExtension = "x-proxy"
+
+// Responses larger than this are passed through without being checked
+const maxCheckedResponse = 1 << 20
+
+// Mode controls what happens when traffic does not match the contract
+type Mode string
+
+const (
+	ModeOff    Mode = "off"    // Nothing is checked
+	ModeLog    Mode = "log"    // Mismatches are logged
+	ModeStrict Mode = "strict" // Non-conforming requests are rejected, response mismatches are logged
+)
+
+// ParseMode converts a configured mode, treating an empty value as log
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return ModeLog, nil
+	case ModeOff, ModeLog, ModeStrict:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown contract mode %q", s)
+	}
+}
+
+// LoadSpec reads and validates the spec at path, keeping only the paths
+// whose x-service extension is service
+func LoadSpec(path, service string) (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	paths := openapi3.NewPaths()
+	for template, item := range doc.Paths.Map() {
+		if item.Extensions[ServiceExtension] == service {
+			paths.Set(template, item)
+		}
+	}
+	doc.Paths = paths
+	return doc, nil
+}
+
+// PathTemplate converts a gin route path to its OpenAPI form, so
+// /jobs/:id becomes /jobs/{id} and /jobs/*rest becomes /jobs/{rest}
+func PathTemplate(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Validator checks requests and responses of the gateway against the spec
+type Validator struct {
+	doc  *openapi3.T
+	mode Mode
+}
+
+// Load creates a validator for the paths of service in the spec at path
+func Load(path, service string, mode Mode) (*Validator, error) {
+	doc, err := LoadSpec(path, service)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{doc: doc, mode: mode}, nil
+}
+
+// Route returns the operation registered for method on a path template,
+// or nil when the spec does not describe it
+func (v *Validator) Route(method, template string) *routers.Route {
+	item := v.doc.Paths.Value(template)
+	if item == nil {
+		return nil
+	}
+	operation := item.GetOperation(method)
+	if operation == nil {
+		return nil
+	}
+	return &routers.Route{
+		Spec:      v.doc,
+		Path:      template,
+		PathItem:  item,
+		Method:    method,
+		Operation: operation,
+	}
+}
+
+// Middleware checks every routed request against the spec. A nil
+// validator checks nothing.
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v == nil || v.mode == ModeOff || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		template := PathTemplate(c.FullPath())
+		route := v.Route(c.Request.Method, template)
+		if route == nil {
+			logging.FromContext(c.Request.Context()).Warn("No contract for route", "method", c.Request.Method, "route", template)
+			c.Next()
+			return
+		}
+
+		params := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			// Wildcards keep their leading slash in gin
+			params[param.Key] = strings.TrimPrefix(param.Value, "/")
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: params,
+			Route:      route,
+			Options:    options(),
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			logging.FromContext(c.Request.Context()).Warn("Request does not match the contract", "method", c.Request.Method, "route", template, "error", err)
+			if v.mode == ModeStrict {
+				problem.AbortInvalid(c, "contract_violation", "The request does not match the API contract", FieldErrors(err))
+				return
+			}
+		}
+
+		// Proxied and upgraded responses are not ours to check
+		if route.Operation.Extensions[ProxyExtension] == true || isUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+		c.Writer = rec.ResponseWriter
+		if rec.overflow {
+			return
+		}
+
+		status := rec.Status()
+		err := openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 status,
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options:                input.Options,
+		})
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Warn("Response does not match the contract", "status", status, "method", c.Request.Method, "route", template, "error", err)
+		}
+	}
+}
+
+// FieldErrors flattens a validation error into per-field errors. Fields are
+// named by parameter name or by their dotted path in the request body.
+func FieldErrors(err error) []problem.FieldError {
+	return collectFieldErrors(err, "")
+}
+
+func collectFieldErrors(err error, field string) []problem.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var fields []problem.FieldError
+		for _, inner := range e {
+			fields = append(fields, collectFieldErrors(inner, field)...)
+		}
+		return fields
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		} else if e.RequestBody != nil && field == "" {
+			field = "body"
+		}
+		switch e.Err.(type) {
+		case openapi3.MultiError, *openapi3.SchemaError:
+			return collectFieldErrors(e.Err, field)
+		}
+		return []problem.FieldError{{Field: field, Code: "invalid", Message: e.Error()}}
+	case *openapi3.SchemaError:
+		if pointer := e.JSONPointer(); len(pointer) > 0 {
+			field = strings.Join(pointer, ".")
+		}
+		code := e.SchemaField
+		if code == "" {
+			code = "invalid"
+		}
+		return []problem.FieldError{{Field: field, Code: code, Message: e.Reason}}
+	default:
+		return []problem.FieldError{{Field: field, Code: "invalid", Message: err.Error()}}
+	}
+}
+
+// options checks schemas only. Authentication is left to the auth
+// middleware, and requests are never rewritten with defaults.
+func options() *openapi3filter.Options {
+	return &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+		MultiError:            true,
+		SkipSettingDefaults:   true,
+	}
+}
+
+func isUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// responseRecorder passes a response through while keeping a copy to check
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.record(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *responseRecorder) WriteString(s string) (int, error) {
+	rec.record([]byte(s))
+	return rec.ResponseWriter.WriteString(s)
+}
+
+func (rec *responseRecorder) record(b []byte) {
+	if rec.overflow {
+		return
+	}
+	if rec.body.Len()+len(b) > maxCheckedResponse {
+		rec.overflow = true
+		rec.body.Reset()
+		return
+	}
+	rec.body.Write(b)
+}
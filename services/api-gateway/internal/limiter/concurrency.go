@@ -6,6 +6,9 @@ import (
 	"net/http"
 	"time"
 
+	"platform/gateway/internal/metrics"
+	"platform/gateway/internal/problem"
+
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
@@ -36,7 +39,8 @@ func (c *ConcurrencyMiddleware) handle(ctx *gin.Context) {
 	}
 
 	if !allowed {
-		ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many concurrent requests"})
+		metrics.RateLimited("too_many_concurrent_requests")
+		problem.Abort(ctx, http.StatusTooManyRequests, "too_many_concurrent_requests", "too many concurrent requests")
 		return
 	}
 
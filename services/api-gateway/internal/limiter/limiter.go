@@ -1,18 +1,21 @@
 package limiter
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"platform/gateway/internal/metrics"
+	"platform/gateway/internal/problem"
+
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
 type RateLimitMiddleware struct {
 	redisClient *redis.Client
+	scope       string
 	algorithm   string
 	limit       int
 	window      time.Duration
@@ -24,18 +27,36 @@ func NewRateLimitMiddleware(rdb *redis.Client, algo string, limit int, windowStr
 		return nil, fmt.Errorf("invalid window duration: %w", err)
 	}
 
-	mw := &RateLimitMiddleware{
+	return NewRateLimiter(rdb, "", algo, limit, dur).handle, nil
+}
+
+// NewRateLimiter creates a limiter whose counters are kept apart from those
+// of limiters with a different scope, so each policy limits clients
+// separately
+func NewRateLimiter(rdb *redis.Client, scope, algo string, limit int, window time.Duration) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
 		redisClient: rdb,
+		scope:       scope,
 		algorithm:   strings.ToLower(algo),
 		limit:       limit,
-		window:      dur,
+		window:      window,
 	}
-
-	return mw.handle, nil
 }
 
 func (rl *RateLimitMiddleware) handle(c *gin.Context) {
+	if rl.Allow(c) {
+		c.Next()
+	}
+}
+
+// Allow counts the request against the client's limit and aborts it with
+// 429 Too Many Requests once the limit is reached. Requests are let through
+// when Redis cannot be reached.
+func (rl *RateLimitMiddleware) Allow(c *gin.Context) bool {
 	userKey := c.ClientIP()
+	if rl.scope != "" {
+		userKey = rl.scope + ":" + userKey
+	}
 
 	var allowed bool
 	var err error
@@ -50,20 +71,20 @@ func (rl *RateLimitMiddleware) handle(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.Next()
-		return
+		return true
 	}
 
 	if !allowed {
-		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
-		return
+		metrics.RateLimited("rate_limited")
+		problem.Abort(c, http.StatusTooManyRequests, "rate_limited", "rate limit exceeded")
+		return false
 	}
 
-	c.Next()
+	return true
 }
 
 func (rl *RateLimitMiddleware) tokenBucketCheck(c *gin.Context, userKey string) (bool, error) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	tbKey := fmt.Sprintf("tokenbucket:%s", userKey)
 	windowKey := fmt.Sprintf("%s:window", tbKey)
@@ -103,7 +124,7 @@ func (rl *RateLimitMiddleware) tokenBucketCheck(c *gin.Context, userKey string)
 }
 
 func (rl *RateLimitMiddleware) slidingWindowCheck(c *gin.Context, userKey string) (bool, error) {
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	swKey := fmt.Sprintf("sliding:%s", userKey)
 	now := time.Now().Unix()
 
@@ -0,0 +1,91 @@
+// Package metrics exposes the gateway's Prometheus metrics. Requests are
+// labelled with the route template rather than the raw path so IDs in URLs
+// don't create a series per resource.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_http_requests_total",
+		Help: "HTTP requests handled by the gateway, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	duration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_rate_limit_rejections_total",
+		Help: "Requests refused by the gateway's limiters, by reason.",
+	}, []string{"reason"})
+
+	routeReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_route_reloads_total",
+		Help: "Attempts to reload the routing table, by result (ok or invalid).",
+	}, []string{"result"})
+)
+
+// routeKey is the gin context key of the route label of requests routed by
+// the routing table rather than by gin
+const routeKey = "metrics.route"
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the rate, errors and duration of every request
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := Route(c)
+		requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		duration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// SetRoute names the route of a request gin did not route itself
+func SetRoute(c *gin.Context, route string) {
+	c.Set(routeKey, route)
+}
+
+// Route returns the label of the route that handled the request: the gin
+// route template, the name given with SetRoute, or "unmatched"
+func Route(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	if route := c.GetString(routeKey); route != "" {
+		return route
+	}
+	return "unmatched"
+}
+
+// RouteReload counts an attempt to reload the routing table
+func RouteReload(err error) {
+	result := "ok"
+	if err != nil {
+		result = "invalid"
+	}
+	routeReloads.WithLabelValues(result).Inc()
+}
+
+// RateLimited counts a request refused by a limiter. reason is the problem
+// code sent to the client.
+func RateLimited(reason string) {
+	rateLimited.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,75 @@
+// Package problem writes gateway errors as RFC 7807 application/problem+json
+// responses, matching the error format of the services behind the gateway.
+package problem
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of every error response
+const ContentType = "application/problem+json"
+
+// typeBase prefixes the code to form the problem type URI
+const typeBase = "/problems/"
+
+// Problem is an RFC 7807 problem detail with a stable Code extension
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why one field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// New creates a problem for status with a stable code
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   typeBase + strings.ReplaceAll(code, "_", "-"),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Abort writes a problem response and stops the handler chain
+func Abort(c *gin.Context, status int, code, detail string) {
+	abort(c, New(status, code, detail))
+}
+
+// AbortInvalid rejects a request with a 400 listing the offending fields
+func AbortInvalid(c *gin.Context, code, detail string, fields []FieldError) {
+	p := New(http.StatusBadRequest, code, detail)
+	p.Errors = fields
+	abort(c, p)
+}
+
+func abort(c *gin.Context, p *Problem) {
+	p.Instance = c.Request.URL.Path
+	// gin only sets application/json when no content type is present
+	c.Header("Content-Type", ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// NoRoute answers requests that match no gateway route
+func NoRoute(c *gin.Context) {
+	Abort(c, http.StatusNotFound, "route_not_found", "No route matches "+c.Request.URL.Path)
+}
+
+// NoMethod answers requests whose path exists under a different method
+func NoMethod(c *gin.Context) {
+	Abort(c, http.StatusMethodNotAllowed, "method_not_allowed", c.Request.Method+" is not allowed on "+c.Request.URL.Path)
+}
@@ -0,0 +1,210 @@
+// Package routing forwards requests to the services behind the gateway
+// according to a routing table read from a YAML (or JSON) file. The table
+// can be reloaded while the gateway runs; a file that fails validation is
+// rejected as a whole and the routes in use are kept.
+package routing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTimeout bounds the wait for the response headers of routes that
+// set no timeout
+const DefaultTimeout = 15 * time.Second
+
+// Config is the routing table as written in the file
+type Config struct {
+	Defaults   Defaults                   `yaml:"defaults"`
+	RateLimits map[string]RateLimitPolicy `yaml:"rateLimits"`
+	Routes     []Route                    `yaml:"routes"`
+}
+
+// Defaults apply to routes that do not set their own values
+type Defaults struct {
+	Timeout   time.Duration `yaml:"timeout"`
+	RateLimit string        `yaml:"rateLimit"` // Also applies to the gateway's own routes
+}
+
+// RateLimitPolicy limits how many requests a client may make in a window
+type RateLimitPolicy struct {
+	Algorithm string        `yaml:"algorithm"` // token-bucket or sliding-window
+	Limit     int           `yaml:"limit"`
+	Window    time.Duration `yaml:"window"`
+}
+
+// Route forwards requests whose path starts with Prefix to Upstream
+type Route struct {
+	Name        string        `yaml:"name"`
+	Prefix      string        `yaml:"prefix"`
+	Methods     []string      `yaml:"methods"` // Empty allows every method
+	Upstream    string        `yaml:"upstream"`
+	StripPrefix bool          `yaml:"stripPrefix"` // Forward the path without the prefix
+	Rewrite     string        `yaml:"rewrite"`     // Forward the path with the prefix replaced by this
+	Auth        bool          `yaml:"auth"`        // Require a valid bearer token
+	Roles       []string      `yaml:"roles"`       // Require one of these roles
+	RateLimit   string        `yaml:"rateLimit"`   // Name of a policy in rateLimits
+	Timeout     time.Duration `yaml:"timeout"`     // Wait for the response headers
+}
+
+var algorithms = map[string]bool{"token-bucket": true, "sliding-window": true}
+
+var methods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true,
+	http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+	http.MethodOptions: true,
+}
+
+// envReference matches ${NAME} and ${NAME:-default}
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// LoadConfig reads and validates the routing table at path
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading routes: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a routing table. References to
+// environment variables, written ${NAME} or ${NAME:-default}, are replaced
+// first so upstream addresses can differ between deployments.
+func ParseConfig(data []byte) (*Config, error) {
+	data = envReference.ReplaceAllFunc(data, func(ref []byte) []byte {
+		groups := envReference.FindSubmatch(ref)
+		if value, ok := os.LookupEnv(string(groups[1])); ok && value != "" {
+			return []byte(value)
+		}
+		return groups[2]
+	})
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var cfg Config
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("error parsing routes: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate reports every problem with the table at once, so a broken file
+// can be fixed in one go
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	if cfg.Defaults.Timeout < 0 {
+		errs = append(errs, errors.New("defaults: timeout must not be negative"))
+	}
+	if name := cfg.Defaults.RateLimit; name != "" {
+		if _, ok := cfg.RateLimits[name]; !ok {
+			errs = append(errs, fmt.Errorf("defaults: unknown rate limit %q", name))
+		}
+	}
+
+	for name, policy := range cfg.RateLimits {
+		if !algorithms[policy.Algorithm] {
+			errs = append(errs, fmt.Errorf("rate limit %q: unknown algorithm %q", name, policy.Algorithm))
+		}
+		if policy.Limit <= 0 {
+			errs = append(errs, fmt.Errorf("rate limit %q: limit must be positive", name))
+		}
+		if policy.Window <= 0 {
+			errs = append(errs, fmt.Errorf("rate limit %q: window must be positive", name))
+		}
+	}
+
+	if len(cfg.Routes) == 0 {
+		errs = append(errs, errors.New("no routes"))
+	}
+
+	names := map[string]bool{}
+	// Methods claimed by each prefix; "" stands for every method
+	claimed := map[string]map[string]bool{}
+	for i, route := range cfg.Routes {
+		label := fmt.Sprintf("route %d", i)
+		if route.Name != "" {
+			label = fmt.Sprintf("route %q", route.Name)
+		}
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf(label+": "+format, args...))
+		}
+
+		switch {
+		case route.Name == "":
+			fail("name is required")
+		case names[route.Name]:
+			fail("duplicate name")
+		}
+		names[route.Name] = true
+
+		if !validPath(route.Prefix) {
+			fail("prefix %q must start with / and not end with one", route.Prefix)
+		}
+		if route.Rewrite != "" && !validPath(route.Rewrite) {
+			fail("rewrite %q must start with / and not end with one", route.Rewrite)
+		}
+		if route.StripPrefix && route.Rewrite != "" {
+			fail("stripPrefix and rewrite cannot both be set")
+		}
+
+		if upstream, err := url.Parse(route.Upstream); err != nil || upstream.Host == "" ||
+			(upstream.Scheme != "http" && upstream.Scheme != "https") {
+			fail("upstream %q must be an absolute http or https URL", route.Upstream)
+		} else if upstream.RawQuery != "" || upstream.Fragment != "" {
+			fail("upstream %q must not have a query or fragment", route.Upstream)
+		}
+
+		if len(route.Roles) > 0 && !route.Auth {
+			fail("roles require auth")
+		}
+		if route.RateLimit != "" {
+			if _, ok := cfg.RateLimits[route.RateLimit]; !ok {
+				fail("unknown rate limit %q", route.RateLimit)
+			}
+		}
+		if route.Timeout < 0 {
+			fail("timeout must not be negative")
+		}
+
+		if claimed[route.Prefix] == nil {
+			claimed[route.Prefix] = map[string]bool{}
+		}
+		routeMethods := route.Methods
+		if len(routeMethods) == 0 {
+			routeMethods = []string{""}
+		}
+		for _, method := range routeMethods {
+			if method != "" && !methods[method] {
+				fail("unknown method %q", method)
+				continue
+			}
+			if claimed[route.Prefix][method] || claimed[route.Prefix][""] || (method == "" && len(claimed[route.Prefix]) > 0) {
+				fail("overlaps another route on %s", route.Prefix)
+				continue
+			}
+			claimed[route.Prefix][method] = true
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validPath reports whether p is "/" or an absolute path without a
+// trailing slash
+func validPath(p string) bool {
+	return p == "/" || (strings.HasPrefix(p, "/") && !strings.HasSuffix(p, "/"))
+}
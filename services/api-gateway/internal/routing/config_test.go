@@ -0,0 +1,156 @@
+package routing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShippedRoutesAreValid(t *testing.T) {
+	if _, err := LoadConfig("../../config/routes.yaml"); err != nil {
+		t.Fatalf("config/routes.yaml is invalid: %v", err)
+	}
+}
+
+func TestParseConfigExpandsEnvironment(t *testing.T) {
+	t.Setenv("TEST_UPSTREAM", "http://set.internal:9000")
+
+	cfg, err := ParseConfig([]byte(`
+routes:
+  - name: set
+    prefix: /set
+    upstream: ${TEST_UPSTREAM:-http://fallback.internal}
+  - name: unset
+    prefix: /unset
+    upstream: ${TEST_UNSET_UPSTREAM:-http://fallback.internal}
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+
+	if got := cfg.Routes[0].Upstream; got != "http://set.internal:9000" {
+		t.Errorf("set upstream = %q, want the environment value", got)
+	}
+	if got := cfg.Routes[1].Upstream; got != "http://fallback.internal" {
+		t.Errorf("unset upstream = %q, want the default", got)
+	}
+}
+
+func TestParseConfigRejectsInvalidRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name: "unknown field",
+			config: `
+routes:
+  - name: a
+    prefix: /a
+    upstream: http://a.internal
+    stripprefix: true`,
+			want: "field stripprefix not found",
+		},
+		{
+			name: "trailing slash",
+			config: `
+routes:
+  - name: a
+    prefix: /a/
+    upstream: http://a.internal`,
+			want: `prefix "/a/"`,
+		},
+		{
+			name: "relative upstream",
+			config: `
+routes:
+  - name: a
+    prefix: /a
+    upstream: a.internal`,
+			want: "absolute http or https URL",
+		},
+		{
+			name: "strip and rewrite",
+			config: `
+routes:
+  - name: a
+    prefix: /a
+    upstream: http://a.internal
+    stripPrefix: true
+    rewrite: /b`,
+			want: "cannot both be set",
+		},
+		{
+			name: "roles without auth",
+			config: `
+routes:
+  - name: a
+    prefix: /a
+    upstream: http://a.internal
+    roles: [admin]`,
+			want: "roles require auth",
+		},
+		{
+			name: "unknown rate limit",
+			config: `
+routes:
+  - name: a
+    prefix: /a
+    upstream: http://a.internal
+    rateLimit: missing`,
+			want: `unknown rate limit "missing"`,
+		},
+		{
+			name: "invalid policy",
+			config: `
+rateLimits:
+  bad:
+    algorithm: leaky-bucket
+    limit: 0
+    window: 1m
+routes:
+  - name: a
+    prefix: /a
+    upstream: http://a.internal`,
+			want: `unknown algorithm "leaky-bucket"`,
+		},
+		{
+			name: "overlapping methods",
+			config: `
+routes:
+  - name: a
+    prefix: /a
+    methods: [GET, POST]
+    upstream: http://a.internal
+  - name: b
+    prefix: /a
+    methods: [POST]
+    upstream: http://b.internal`,
+			want: "overlaps another route on /a",
+		},
+		{
+			name: "duplicate name",
+			config: `
+routes:
+  - name: a
+    prefix: /a
+    upstream: http://a.internal
+  - name: a
+    prefix: /b
+    upstream: http://b.internal`,
+			want: "duplicate name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tt.config))
+			if err == nil {
+				t.Fatal("ParseConfig succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
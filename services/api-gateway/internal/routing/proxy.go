@@ -0,0 +1,111 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+
+	"platform/gateway/internal/auth"
+	"platform/gateway/internal/problem"
+	"platform/shared/logging"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// upstreamTransport is shared by every route so connections to each
+// upstream are pooled and reused. It records a client span for each
+// forwarded request and passes the trace context on in its headers.
+var upstreamTransport = otelhttp.NewTransport(&http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          200,
+	MaxIdleConnsPerHost:   50,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ExpectContinueTimeout: time.Second,
+})
+
+// proxyErrorLog receives errors ReverseProxy hits after the response has
+// started, such as an upstream closing a stream midway. It is created on
+// first use so it writes through the logger set up in main.
+var proxyErrorLog = sync.OnceValue(func() *log.Logger {
+	return slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn)
+})
+
+// errUpstreamTimeout cancels a forwarded request whose upstream has not
+// started responding within the route's timeout
+var errUpstreamTimeout = errors.New("upstream did not respond in time")
+
+// forward proxies the request to the upstream of r. Bodies are streamed in
+// both directions, and event streams are flushed as they arrive. WebSocket
+// and other upgrades are passed through once the upstream accepts them.
+//
+// The route's timeout only bounds the wait for the response headers, so
+// long-lived streams and upgraded connections stay open for as long as
+// either side keeps them. Everything stops when the client disconnects.
+func forward(c *gin.Context, r *route) {
+	ctx, cancel := context.WithCancelCause(c.Request.Context())
+	defer cancel(nil)
+	timer := time.AfterFunc(r.timeout, func() { cancel(errUpstreamTimeout) })
+	defer timer.Stop()
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Scheme = r.upstream.Scheme
+			pr.Out.URL.Host = r.upstream.Host
+			pr.Out.URL.Path = r.upstreamPath(pr.In.URL.Path)
+			pr.Out.URL.RawPath = ""
+			pr.Out.Host = ""
+			// The gateway faces clients directly, so forwarding headers they
+			// sent are dropped rather than trusted, and set from the connection
+			pr.SetXForwarded()
+			// Upstreams trust the user header, so it only carries a user
+			// whose token the gateway verified
+			pr.Out.Header.Del(auth.UserIDHeader)
+			if userID, ok := auth.UserID(c); ok {
+				pr.Out.Header.Set(auth.UserIDHeader, userID)
+			}
+			logging.FromContext(pr.In.Context()).Debug("Forwarding request", "route", r.Name, "url", pr.Out.URL.String())
+		},
+		Transport: upstreamTransport,
+		ModifyResponse: func(*http.Response) error {
+			timer.Stop()
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			proxyError(c, r, err, context.Cause(ctx))
+		},
+		ErrorLog: proxyErrorLog(),
+	}
+
+	proxy.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
+
+// proxyError answers a request the upstream could not serve
+func proxyError(c *gin.Context, r *route, err, cause error) {
+	logger := logging.FromContext(c.Request.Context())
+
+	switch {
+	case errors.Is(cause, errUpstreamTimeout):
+		logger.Warn("Upstream timed out", "route", r.Name, "timeout", r.timeout)
+		problem.Abort(c, http.StatusGatewayTimeout, "upstream_timeout", r.Name+" did not respond in time")
+	case c.Request.Context().Err() != nil:
+		// The client went away; there is nobody to answer
+		logger.Debug("Client disconnected before the upstream responded", "route", r.Name)
+		c.Abort()
+	default:
+		logger.Warn("Upstream unavailable", "route", r.Name, "error", err)
+		problem.Abort(c, http.StatusBadGateway, "upstream_unavailable", r.Name+" unreachable")
+	}
+}
@@ -0,0 +1,309 @@
+package routing
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// newGateway serves routes through a gateway that forwards every path to
+// upstream
+func newGateway(t *testing.T, upstream, routes string) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	if routes == "" {
+		routes = `
+routes:
+  - name: upstream
+    prefix: /
+    upstream: ` + upstream + `
+`
+	}
+	writeRoutes(t, path, routes)
+	router, err := New(path, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	engine := gin.New()
+	engine.Use(router.Middleware())
+	engine.NoRoute(router.Handle)
+
+	gateway := httptest.NewServer(engine)
+	t.Cleanup(gateway.Close)
+	return gateway
+}
+
+func TestForwardHeaders(t *testing.T) {
+	var got http.Header
+	var host string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, host = r.Header.Clone(), r.Host
+		w.Header().Set("Keep-Alive", "timeout=5")
+		w.Header().Set("X-Upstream", "yes")
+	}))
+	defer upstream.Close()
+	gateway := newGateway(t, upstream.URL, "")
+
+	req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/rides", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+
+	if xff := got.Get("X-Forwarded-For"); xff != "127.0.0.1" {
+		t.Errorf("X-Forwarded-For = %q, want only the connecting address", xff)
+	}
+	if proto := got.Get("X-Forwarded-Proto"); proto != "http" {
+		t.Errorf("X-Forwarded-Proto = %q, want http", proto)
+	}
+	if fwdHost := got.Get("X-Forwarded-Host"); fwdHost != strings.TrimPrefix(gateway.URL, "http://") {
+		t.Errorf("X-Forwarded-Host = %q, want the gateway's host", fwdHost)
+	}
+	if host != strings.TrimPrefix(upstream.URL, "http://") {
+		t.Errorf("Host = %q, want the upstream's host", host)
+	}
+	if got.Get("Proxy-Authorization") != "" {
+		t.Error("hop-by-hop Proxy-Authorization was forwarded")
+	}
+	if got.Get("Authorization") != "Bearer token" {
+		t.Error("Authorization was not forwarded")
+	}
+	if resp.Header.Get("Keep-Alive") != "" {
+		t.Error("hop-by-hop Keep-Alive was passed back to the client")
+	}
+	if resp.Header.Get("X-Upstream") != "yes" {
+		t.Error("upstream response header was not passed back")
+	}
+}
+
+func TestForwardReplacesUserIDHeader(t *testing.T) {
+	var got []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Values("X-User-ID")
+	}))
+	defer upstream.Close()
+	gateway := newGateway(t, upstream.URL, `
+routes:
+  - name: search
+    prefix: /search
+    upstream: `+upstream.URL+`
+    auth: true
+  - name: public
+    prefix: /public
+    upstream: `+upstream.URL+`
+`)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "verified-user",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+
+	tests := []struct {
+		path, token string
+		want        []string
+	}{
+		{"/search", token, []string{"verified-user"}},
+		// Routes without auth never vouch for a user
+		{"/public", token, nil},
+		{"/public", "", nil},
+	}
+	for _, tt := range tests {
+		got = nil
+		req, _ := http.NewRequest(http.MethodGet, gateway.URL+tt.path, nil)
+		req.Header.Add("X-User-ID", "spoofed-admin")
+		req.Header.Add("X-User-ID", "another")
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status = %d", tt.path, resp.StatusCode)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: upstream saw X-User-ID %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestForwardStreamsEvents(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		// Hold the stream open until the client has seen the first event
+		<-release
+		fmt.Fprint(w, "data: second\n\n")
+	}))
+	defer upstream.Close()
+	defer close(release)
+	gateway := newGateway(t, upstream.URL, "")
+
+	resp, err := http.Get(gateway.URL + "/events")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	line := make(chan string, 1)
+	go func() {
+		s, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		line <- s
+	}()
+	select {
+	case got := <-line:
+		if got != "data: first\n" {
+			t.Errorf("first line = %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("first event was not flushed while the stream was open")
+	}
+}
+
+func TestForwardUpgradesWebSocket(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+		// Echo one line back
+		msg, _ := rw.ReadString('\n')
+		rw.WriteString("echo: " + msg)
+		rw.Flush()
+	}))
+	defer upstream.Close()
+	gateway := newGateway(t, upstream.URL, "")
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(gateway.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprint(conn, "GET /chat HTTP/1.1\r\nHost: gateway\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	fmt.Fprint(conn, "hello\n")
+	got, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading echo: %v", err)
+	}
+	if got != "echo: hello\n" {
+		t.Errorf("echo = %q", got)
+	}
+}
+
+func TestForwardTimesOutWaitingForHeaders(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer upstream.Close()
+	defer close(release)
+	gateway := newGateway(t, upstream.URL, `
+routes:
+  - name: slow
+    prefix: /
+    upstream: `+upstream.URL+`
+    timeout: 50ms
+`)
+
+	resp, err := http.Get(gateway.URL + "/slow")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusGatewayTimeout)
+	}
+}
+
+func TestForwardCancelsWhenClientDisconnects(t *testing.T) {
+	canceled := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer upstream.Close()
+	gateway := newGateway(t, upstream.URL, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, gateway.URL+"/slow", nil)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream request was not canceled when the client went away")
+	}
+}
+
+func TestForwardReportsUnreachableUpstream(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	gateway := newGateway(t, "http://"+addr, "")
+
+	resp, err := http.Get(gateway.URL + "/rides")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadGateway)
+	}
+}
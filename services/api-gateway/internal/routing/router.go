@@ -0,0 +1,147 @@
+package routing
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"platform/gateway/internal/auth"
+	"platform/gateway/internal/metrics"
+	"platform/gateway/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// routeKey is the gin context key of the route matched by Middleware
+const routeKey = "routing.route"
+
+// Router routes requests with the table read from a file
+type Router struct {
+	path  string
+	redis *redis.Client
+	table atomic.Pointer[table]
+
+	// mu serializes reloads; modTime and size identify the file last loaded
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// New loads the table at path. Rate limits are counted in rdb.
+func New(path string, rdb *redis.Client) (*Router, error) {
+	r := &Router{path: path, redis: rdb}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the file again and switches to its routes if they are valid.
+// Requests already matched finish on the routes they matched.
+func (r *Router) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reload()
+}
+
+// reload does the work of Reload; callers must hold mu
+func (r *Router) reload() error {
+	info, err := os.Stat(r.path)
+	if err == nil {
+		r.modTime, r.size = info.ModTime(), info.Size()
+	}
+
+	cfg, err := LoadConfig(r.path)
+	if r.table.Load() != nil {
+		metrics.RouteReload(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.table.Store(newTable(cfg, r.redis))
+	slog.Info("Routes loaded", "path", r.path, "routes", len(cfg.Routes))
+	return nil
+}
+
+// Watch reloads the table whenever the file changes, checking every
+// interval until ctx is done. Invalid files are logged and skipped.
+func (r *Router) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			if info, err := os.Stat(r.path); err == nil && (!info.ModTime().Equal(r.modTime) || info.Size() != r.size) {
+				if err := r.reload(); err != nil {
+					slog.Error("Routes not reloaded, keeping the previous routes", "path", r.path, "error", err)
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Middleware matches requests gin has no route for against the table and
+// applies the rate limit of the route. The gateway's own routes take
+// precedence over the table and are limited by the default policy.
+func (r *Router) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := r.table.Load()
+
+		if c.FullPath() != "" {
+			if t.defaultLimit != nil && !t.defaultLimit.Allow(c) {
+				return
+			}
+			c.Next()
+			return
+		}
+
+		matched, pathMatched := t.match(c.Request.Method, c.Request.URL.Path)
+		if matched == nil {
+			if pathMatched {
+				problem.NoMethod(c)
+				return
+			}
+			// Left to the NoRoute or NoMethod handler
+			c.Next()
+			return
+		}
+
+		c.Set(routeKey, matched)
+		metrics.SetRoute(c, matched.Name)
+		if matched.limit != nil && !matched.limit.Allow(c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// Handle forwards a request matched by Middleware after checking its
+// credentials, and answers 404 Not Found when nothing matched. It is meant
+// to be gin's NoRoute handler.
+func (r *Router) Handle(c *gin.Context) {
+	value, ok := c.Get(routeKey)
+	if !ok {
+		problem.NoRoute(c)
+		return
+	}
+	matched := value.(*route)
+
+	if matched.Auth && !auth.Authenticate(c) {
+		return
+	}
+	if len(matched.Roles) > 0 && !auth.Authorize(c, matched.Roles...) {
+		return
+	}
+
+	forward(c, matched)
+}
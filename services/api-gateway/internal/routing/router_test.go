@@ -0,0 +1,177 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMatchPrefersLongestPrefix(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+routes:
+  - name: api
+    prefix: /api
+    upstream: http://api.internal
+  - name: admin
+    prefix: /api/admin
+    methods: [GET]
+    upstream: http://admin.internal
+  - name: search
+    prefix: /search
+    methods: [POST]
+    upstream: http://search.internal
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	tbl := newTable(cfg, nil)
+
+	tests := []struct {
+		method, path string
+		want         string
+		pathMatched  bool
+	}{
+		{http.MethodGet, "/api/admin/users", "admin", true},
+		{http.MethodPost, "/api/admin/users", "api", true}, // admin only takes GET
+		{http.MethodGet, "/api", "api", true},
+		{http.MethodGet, "/apis", "", false},
+		{http.MethodGet, "/search", "", true},
+		{http.MethodPost, "/search", "search", true},
+	}
+	for _, tt := range tests {
+		got, pathMatched := tbl.match(tt.method, tt.path)
+		name := ""
+		if got != nil {
+			name = got.Name
+		}
+		if name != tt.want || pathMatched != tt.pathMatched {
+			t.Errorf("match(%s %s) = %q, %v; want %q, %v", tt.method, tt.path, name, pathMatched, tt.want, tt.pathMatched)
+		}
+	}
+}
+
+func TestUpstreamPath(t *testing.T) {
+	tests := []struct {
+		route Route
+		path  string
+		want  string
+	}{
+		{Route{Prefix: "/api", Upstream: "http://u"}, "/api/rides", "/api/rides"},
+		{Route{Prefix: "/search", Upstream: "http://u", StripPrefix: true}, "/search", "/"},
+		{Route{Prefix: "/search", Upstream: "http://u", StripPrefix: true}, "/search/x", "/x"},
+		{Route{Prefix: "/auth", Upstream: "http://u", Rewrite: "/api/v1/auth"}, "/auth/refresh", "/api/v1/auth/refresh"},
+		{Route{Prefix: "/auth", Upstream: "http://u/base/", StripPrefix: true}, "/auth/refresh", "/base/refresh"},
+	}
+	for _, tt := range tests {
+		tbl := newTable(&Config{Routes: []Route{tt.route}}, nil)
+		if got := tbl.routes[0].upstreamPath(tt.path); got != tt.want {
+			t.Errorf("upstreamPath(%q) with %+v = %q, want %q", tt.path, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestRouterForwardsAndReloads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream-Path", r.URL.RequestURI())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	writeRoutes(t, path, `
+routes:
+  - name: rides
+    prefix: /rides
+    upstream: `+upstream.URL+`
+    rewrite: /api/rides
+`)
+	router, err := New(path, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	engine := gin.New()
+	engine.Use(router.Middleware())
+	engine.NoRoute(router.Handle)
+
+	rec := serve(engine, http.MethodGet, "/rides/42?seats=2")
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("status = %d, want the upstream's %d", rec.Code, http.StatusTeapot)
+	}
+	if got := rec.Header().Get("X-Upstream-Path"); got != "/api/rides/42?seats=2" {
+		t.Errorf("upstream saw %q, want the rewritten path with its query", got)
+	}
+
+	// An invalid file is rejected and the old routes stay in use
+	writeRoutes(t, path, "routes: []\n")
+	if err := router.Reload(); err == nil {
+		t.Fatal("Reload accepted a table without routes")
+	}
+	if rec := serve(engine, http.MethodGet, "/rides/42"); rec.Code != http.StatusTeapot {
+		t.Errorf("status after invalid reload = %d, want the old route to still work", rec.Code)
+	}
+
+	writeRoutes(t, path, `
+routes:
+  - name: trips
+    prefix: /trips
+    upstream: `+upstream.URL+`
+`)
+	if err := router.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if rec := serve(engine, http.MethodGet, "/rides/42"); rec.Code != http.StatusNotFound {
+		t.Errorf("status of removed route = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := serve(engine, http.MethodGet, "/trips"); rec.Code != http.StatusTeapot {
+		t.Errorf("status of added route = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}
+
+func TestRouterRequiresAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	writeRoutes(t, path, `
+routes:
+  - name: search
+    prefix: /search
+    methods: [POST]
+    upstream: http://search.internal
+    auth: true
+`)
+	router, err := New(path, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	engine := gin.New()
+	engine.Use(router.Middleware())
+	engine.NoRoute(router.Handle)
+
+	if rec := serve(engine, http.MethodPost, "/search"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := serve(engine, http.MethodGet, "/search"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status of GET = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func writeRoutes(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing routes: %v", err)
+	}
+}
+
+func serve(engine *gin.Engine, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
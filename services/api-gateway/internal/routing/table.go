@@ -0,0 +1,113 @@
+package routing
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"platform/gateway/internal/limiter"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// table is a validated routing table ready to match requests. Tables are
+// never modified; a reload builds a new one.
+type table struct {
+	routes       []*route // Longest prefix first
+	defaultLimit *limiter.RateLimitMiddleware
+}
+
+// route is a Route with its upstream parsed and its policies resolved
+type route struct {
+	Route
+	upstream *url.URL
+	methods  map[string]bool // nil allows every method
+	limit    *limiter.RateLimitMiddleware
+	timeout  time.Duration
+}
+
+// newTable builds the table of a validated config. Limiters are scoped by
+// policy name, so clients keep their counts across reloads.
+func newTable(cfg *Config, rdb *redis.Client) *table {
+	limits := make(map[string]*limiter.RateLimitMiddleware, len(cfg.RateLimits))
+	for name, policy := range cfg.RateLimits {
+		limits[name] = limiter.NewRateLimiter(rdb, "policy:"+name, policy.Algorithm, policy.Limit, policy.Window)
+	}
+
+	t := &table{defaultLimit: limits[cfg.Defaults.RateLimit]}
+	for _, r := range cfg.Routes {
+		upstream, _ := url.Parse(r.Upstream)
+		compiled := &route{
+			Route:    r,
+			upstream: upstream,
+			limit:    t.defaultLimit,
+			timeout:  DefaultTimeout,
+		}
+		if len(r.Methods) > 0 {
+			compiled.methods = make(map[string]bool, len(r.Methods))
+			for _, method := range r.Methods {
+				compiled.methods[method] = true
+			}
+		}
+		if r.RateLimit != "" {
+			compiled.limit = limits[r.RateLimit]
+		}
+		if cfg.Defaults.Timeout > 0 {
+			compiled.timeout = cfg.Defaults.Timeout
+		}
+		if r.Timeout > 0 {
+			compiled.timeout = r.Timeout
+		}
+		t.routes = append(t.routes, compiled)
+	}
+
+	sort.SliceStable(t.routes, func(i, j int) bool {
+		return len(t.routes[i].Prefix) > len(t.routes[j].Prefix)
+	})
+	return t
+}
+
+// match returns the route with the longest prefix of path that allows
+// method. When no route allows method, pathMatched tells whether some route
+// would have taken the path under another method.
+func (t *table) match(method, path string) (r *route, pathMatched bool) {
+	for _, candidate := range t.routes {
+		if !hasPathPrefix(path, candidate.Prefix) {
+			continue
+		}
+		if candidate.methods == nil || candidate.methods[method] {
+			return candidate, true
+		}
+		pathMatched = true
+	}
+	return nil, pathMatched
+}
+
+// hasPathPrefix matches whole segments, so /api matches /api and /api/rides
+// but not /apis
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "/" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// upstreamPath returns the path to request from the upstream for path,
+// after stripping or rewriting the prefix
+func (r *route) upstreamPath(path string) string {
+	if r.StripPrefix || r.Rewrite != "" {
+		rest := path
+		if r.Prefix != "/" {
+			rest = strings.TrimPrefix(path, r.Prefix)
+		}
+		path = strings.TrimSuffix(r.Rewrite, "/") + rest
+		if path == "" {
+			path = "/"
+		}
+	}
+	if base := strings.TrimSuffix(r.upstream.Path, "/"); base != "" {
+		path = base + path
+	}
+	return path
+}
@@ -3,25 +3,58 @@ package main
 import (
 	"auth-service/internal/config"
 	"auth-service/internal/db"
+	"auth-service/internal/metrics"
 	"auth-service/internal/service"
 	"auth-service/internal/transport"
-	"fmt"
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"platform/shared/health"
+	"platform/shared/logging"
+	"platform/shared/tracing"
+	"time"
 )
 
+// readinessTimeout bounds each dependency check of /readyz
+const readinessTimeout = 2 * time.Second
+
 func main() {
 	config.LoadConfig()
-	fmt.Println("✅ Config Loaded Successfully")
-	fmt.Println("🟢 Running on port:", config.AppConfig.Port)
+	if _, err := logging.Setup(config.AppConfig.LogLevel); err != nil {
+		logging.Fatal("Invalid log level", "error", err)
+	}
+	slog.Info("Config loaded", "port", config.AppConfig.Port)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), "auth-service")
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
 
 	db.ConnectToDB()
+	metrics.WatchDB("auth", db.DB)
 
 	authService := &service.AuthService{DB: db.DB}
 	router := transport.SetupRoutes(authService)
 
-	err := http.ListenAndServe(":"+config.AppConfig.Port, router)
+	// Readiness only depends on the user database
+	checker := health.NewChecker(readinessTimeout)
+	checker.Add("postgres", db.DB.PingContext)
+
+	// /metrics and the probes are served beside the API rather than by its
+	// router, which only carries the routes in the OpenAPI spec
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("GET /livez", checker.Live)
+	mux.HandleFunc("GET /readyz", checker.Ready)
+	mux.Handle("/", router)
+
+	err = http.ListenAndServe(":"+config.AppConfig.Port, mux)
 	if err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+		logging.Fatal("Failed to start server", "error", err)
 	}
 }
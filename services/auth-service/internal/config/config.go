@@ -17,6 +17,7 @@ type Config struct {
 	GoogleClientID     string
 	GoogleClientSecret string
 	GoogleRedirectURI  string
+	LogLevel           string
 }
 
 var AppConfig Config
@@ -38,5 +39,6 @@ func LoadConfig() {
 		GoogleClientID:     viper.GetString("GOOGLE_CLIENT_ID"),
 		GoogleClientSecret: viper.GetString("GOOGLE_CLIENT_SECRET"),
 		GoogleRedirectURI:  viper.GetString("GOOGLE_REDIRECT_URI"),
+		LogLevel:           viper.GetString("LOG_LEVEL"),
 	}
 }
@@ -2,12 +2,15 @@ package db
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
 	"time"
 
 	"auth-service/internal/config"
+	"platform/shared/logging"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 var DB *sql.DB
@@ -15,11 +18,12 @@ var DB *sql.DB
 func ConnectToDB() {
 	var err error
 
-	log.Println("🔐 Using DB URL:", config.AppConfig.DBUrl)
+	slog.Info("Connecting to database", "url", config.AppConfig.DBUrl)
 
-	DB, err = sql.Open("pgx", config.AppConfig.DBUrl)
+	// Queries are traced as child spans of the request that made them
+	DB, err = otelsql.Open("pgx", config.AppConfig.DBUrl, otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
 	if err != nil {
-		log.Fatalf("❌ Failed to parse DB URL: %v", err)
+		logging.Fatal("Failed to parse DB URL", "error", err)
 	}
 
 	DB.SetMaxOpenConns(10)
@@ -32,15 +36,15 @@ func ConnectToDB() {
 		if err == nil {
 			break
 		}
-		log.Println("🔁 Waiting for DB to be ready...")
+		slog.Info("Waiting for DB to be ready")
 		time.Sleep(1 * time.Second)
 	}
 
 	if err != nil {
-		log.Fatalf("❌ Failed to connect to DB after retries: %v", err)
+		logging.Fatal("Failed to connect to DB after retries", "error", err)
 	}
 
-	log.Println("✅ Connected to PostgreSQL database")
+	slog.Info("Connected to PostgreSQL database")
 }
 
 //Initializes the DB connection (e.g., PostgreSQL)
@@ -22,7 +22,7 @@ type User struct {
 }
 
 // CreateUser inserts a new user into the database
-func CreateUser(db *sql.DB, user *User) error {
+func CreateUser(ctx context.Context, db *sql.DB, user *User) error {
 	user.ID = uuid.New()
 	user.CreatedAt = time.Now()
 
@@ -30,7 +30,7 @@ func CreateUser(db *sql.DB, user *User) error {
         INSERT INTO users (id, name, email, gender, batch, phone_number, role, created_at)
         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     `
-	_, err := db.ExecContext(context.Background(), query,
+	_, err := db.ExecContext(ctx, query,
 		user.ID, user.Name, user.Email, user.Gender,
 		user.Batch, user.PhoneNumber, user.Role, user.CreatedAt,
 	)
@@ -39,9 +39,9 @@ func CreateUser(db *sql.DB, user *User) error {
 }
 
 // GetUserByEmail fetches a user from the database by email
-func GetUserByEmail(db *sql.DB, email string) (*User, error) {
+func GetUserByEmail(ctx context.Context, db *sql.DB, email string) (*User, error) {
 	query := `SELECT id, name, email, gender, batch, phone_number, role, created_at FROM users WHERE email = $1`
-	row := db.QueryRowContext(context.Background(), query, email)
+	row := db.QueryRowContext(ctx, query, email)
 
 	var user User
 	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Gender, &user.Batch, &user.PhoneNumber, &user.Role, &user.CreatedAt)
@@ -3,6 +3,7 @@ package oauth
 import (
 	"auth-service/internal/config"
 	"auth-service/internal/service"
+	"platform/shared/apperror"
 
 	//"context"
 	"encoding/json"
@@ -12,6 +13,9 @@ import (
 	"strings"
 )
 
+// errGoogleUnavailable hides transport details of a failed call to Google
+var errGoogleUnavailable = apperror.Unavailable("oauth_provider_unavailable", "could not reach the Google OAuth provider")
+
 type GoogleUserResponse struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
@@ -37,16 +41,21 @@ func ExchangeCodeForToken(code string) (string, error) {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", errGoogleUnavailable
 	}
 	defer resp.Body.Close()
 
+	// Google answers 400 invalid_grant for expired or reused codes
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return "", apperror.Unauthorized("invalid_oauth_code", "authorization code was rejected")
+	}
+
 	var res struct {
 		AccessToken string `json:"access_token"`
 	}
 	err = json.NewDecoder(resp.Body).Decode(&res)
-	if err != nil {
-		return "", err
+	if err != nil || res.AccessToken == "" {
+		return "", errGoogleUnavailable
 	}
 
 	return res.AccessToken, nil
@@ -59,13 +68,16 @@ func GetGoogleUser(accessToken string) (*service.GoogleUser, error) {
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, errGoogleUnavailable
 	}
 	defer resp.Body.Close()
 
 	var gRes GoogleUserResponse
+	if resp.StatusCode != http.StatusOK {
+		return nil, errGoogleUnavailable
+	}
 	if err := json.NewDecoder(resp.Body).Decode(&gRes); err != nil {
-		return nil, err
+		return nil, errGoogleUnavailable
 	}
 
 	return &service.GoogleUser{
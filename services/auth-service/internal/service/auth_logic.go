@@ -4,6 +4,7 @@ import (
 	"auth-service/internal/config"
 	"auth-service/internal/db"
 	"auth-service/internal/token"
+	"context"
 	"database/sql"
 	"errors"
 	"net/http"
@@ -24,9 +25,9 @@ type AuthService struct {
 }
 
 // LoginOrRegister handles login or registration via Google OAuth
-func (a *AuthService) LoginOrRegister(googleUser *GoogleUser, w http.ResponseWriter) (*db.User, string, error) {
+func (a *AuthService) LoginOrRegister(ctx context.Context, googleUser *GoogleUser, w http.ResponseWriter) (*db.User, string, error) {
 	// Step 1: Check if user already exists
-	existingUser, err := db.GetUserByEmail(a.DB, googleUser.Email)
+	existingUser, err := db.GetUserByEmail(ctx, a.DB, googleUser.Email)
 	if err != nil {
 		return nil, "", err
 	}
@@ -42,7 +43,7 @@ func (a *AuthService) LoginOrRegister(googleUser *GoogleUser, w http.ResponseWri
 			Role:        "user",
 		}
 
-		err := db.CreateUser(a.DB, newUser)
+		err := db.CreateUser(ctx, a.DB, newUser)
 		if err != nil {
 			return nil, "", err
 		}
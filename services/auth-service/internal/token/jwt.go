@@ -3,14 +3,16 @@ package token
 import (
 	"auth-service/internal/config"
 	"auth-service/internal/db"
+	"platform/shared/apperror"
 	"time"
 
-	"errors"
-
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// ErrInvalidToken is returned for any token that fails to parse or verify
+var ErrInvalidToken = apperror.Unauthorized("invalid_token", "invalid or expired token")
+
 // Claims defines the JWT payload structure
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
@@ -58,12 +60,12 @@ func VerifyToken(tokenStr string) (*Claims, error) {
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, ErrInvalidToken
 	}
 
 	return claims, nil
@@ -5,9 +5,10 @@ import (
 	"auth-service/internal/service"
 	"auth-service/internal/token"
 	"encoding/json"
-
-	//"log"
 	"net/http"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+	"platform/shared/problem"
 )
 
 // AuthHandler wraps the AuthService
@@ -19,25 +20,28 @@ type AuthHandler struct {
 func (h *AuthHandler) GoogleCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	code := r.URL.Query().Get("code")
 	if code == "" {
-		http.Error(w, "Missing code", http.StatusBadRequest)
+		problem.InvalidParam(w, r, "code", "Missing code")
 		return
 	}
 
 	accessToken, err := oauth.ExchangeCodeForToken(code)
 	if err != nil {
-		http.Error(w, "Token exchange failed", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error exchanging OAuth code", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
 	googleUser, err := oauth.GetGoogleUser(accessToken)
 	if err != nil {
-		http.Error(w, "Failed to get user info", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error fetching Google user", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
-	user, jwtToken, err := h.Service.LoginOrRegister(googleUser, w)
+	user, jwtToken, err := h.Service.LoginOrRegister(r.Context(), googleUser, w)
 	if err != nil {
-		http.Error(w, "Login failed", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error logging in", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -51,20 +55,21 @@ func (h *AuthHandler) GoogleCallbackHandler(w http.ResponseWriter, r *http.Reque
 func (h *AuthHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("refresh_token")
 	if err != nil || cookie.Value == "" {
-		http.Error(w, "Missing refresh token", http.StatusUnauthorized)
+		problem.Error(w, r, apperror.Unauthorized("missing_refresh_token", "Missing refresh token"))
 		return
 	}
 
 	claims, err := token.VerifyToken(cookie.Value)
 	if err != nil {
-		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		problem.Error(w, r, err)
 		return
 	}
 
 	// Generate new access token
 	accessToken, err := token.GenerateAccessToken(token.UserFromClaims(*claims))
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error generating access token", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -75,7 +80,7 @@ func (h *AuthHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request
 func (h *AuthHandler) ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	claims := GetUserFromContext(r)
 	if claims == nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 
@@ -4,6 +4,7 @@ import (
 	"auth-service/internal/token"
 	"context"
 	"net/http"
+	"platform/shared/problem"
 	"strings"
 )
 
@@ -16,14 +17,14 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 		claims, err := token.VerifyToken(tokenStr)
 		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			problem.Error(w, r, err)
 			return
 		}
 
@@ -1,8 +1,12 @@
 package transport
 
 import (
+	"auth-service/internal/metrics"
 	"auth-service/internal/service"
+	"auth-service/internal/tracing"
 	"net/http"
+	"platform/shared/logging"
+	"platform/shared/problem"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -12,8 +16,16 @@ func SetupRoutes(authService *service.AuthService) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
+	r.Use(tracing.Middleware)
+	r.Use(metrics.Middleware)
+	r.Use(logging.Middleware)
 	r.Use(middleware.Recoverer)
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.New(http.StatusNotFound, "route_not_found", "No route matches "+r.URL.Path).Write(w, r)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		problem.New(http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+r.URL.Path).Write(w, r)
+	})
 
 	// Routes
 	handler := &AuthHandler{Service: authService}
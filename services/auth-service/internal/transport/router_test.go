@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"gopkg.in/yaml.v3"
+)
+
+const specPath = "../../../../api-specs/openapi.yaml"
+
+var specMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// specRoutes returns "METHOD path" for every operation the spec assigns to service
+func specRoutes(t *testing.T, service string) map[string]bool {
+	t.Helper()
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		t.Fatalf("error reading spec: %v", err)
+	}
+	var spec struct {
+		Paths map[string]map[string]any `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("error parsing spec: %v", err)
+	}
+
+	routes := map[string]bool{}
+	for path, item := range spec.Paths {
+		if item["x-service"] != service {
+			continue
+		}
+		for _, method := range specMethods {
+			if _, ok := item[method]; ok {
+				routes[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+	return routes
+}
+
+// TestRoutesMatchSpec fails when a registered route is missing from
+// api-specs/openapi.yaml or the spec describes a route that is not registered
+func TestRoutesMatchSpec(t *testing.T) {
+	specified := specRoutes(t, "auth")
+
+	registered := map[string]bool{}
+	err := chi.Walk(SetupRoutes(nil).(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error walking routes: %v", err)
+	}
+
+	if missing := difference(registered, specified); len(missing) > 0 {
+		t.Errorf("registered routes missing from the spec:\n  %s", strings.Join(missing, "\n  "))
+	}
+	if stale := difference(specified, registered); len(stale) > 0 {
+		t.Errorf("spec paths that are not registered:\n  %s", strings.Join(stale, "\n  "))
+	}
+}
+
+// difference returns the keys of a that are not in b, sorted
+func difference(a, b map[string]bool) []string {
+	var out []string
+	for key := range a {
+		if !b[key] {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -1,29 +1,85 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"geo-distance-service/internal/config"
+	"geo-distance-service/internal/metrics"
 	"geo-distance-service/internal/transport"
 	pb "geo-distance-service/proto"
+	"platform/shared/grpclogging"
+	"platform/shared/logging"
+	"platform/shared/tracing"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
+	if _, err := logging.Setup(os.Getenv("LOG_LEVEL")); err != nil {
+		logging.Fatal("Invalid log level", "error", err)
+	}
+
 	cfg := config.Load()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), "geo-distance-service")
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
+
 	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		logging.Fatal("Failed to listen", "error", err)
 	}
 
-	server := grpc.NewServer()
+	// Calls continue the trace context and request ID the client sent in
+	// their metadata
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpclogging.UnaryServerInterceptor, metrics.UnaryServerInterceptor),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	)
 	pb.RegisterGeoDistanceServiceServer(server, transport.NewGeoServer(cfg))
 
-	log.Printf("GeoDistanceService listening on port %s with radius %.2fkm", cfg.GRPCPort, cfg.RadiusKM)
+	// The service has no dependencies, so it is ready as soon as it serves.
+	// Probes and clients can ask about the whole server ("") or the service
+	// by name.
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.GeoDistanceService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	// On SIGTERM, report NOT_SERVING so clients stop sending new calls, then
+	// let calls in progress finish
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+		slog.Info("Shutting down")
+		healthServer.Shutdown()
+		server.GracefulStop()
+	}()
+
+	go func() {
+		slog.Info("Serving metrics", "port", cfg.MetricsPort)
+		if err := metrics.Serve(cfg.MetricsPort); err != nil {
+			logging.Fatal("Failed to serve metrics", "error", err)
+		}
+	}()
+
+	slog.Info("GeoDistanceService listening", "port", cfg.GRPCPort, "radius_km", cfg.RadiusKM)
 	if err := server.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		logging.Fatal("Failed to serve", "error", err)
 	}
 }
@@ -1,27 +1,29 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 )
 
 type Config struct {
-	GRPCPort string
-	RadiusKM float64
+	GRPCPort    string
+	MetricsPort string
+	RadiusKM    float64
 }
 
 func Load() *Config {
 	return &Config{
-		GRPCPort: getEnv("GRPC_PORT", "50051"),
-		RadiusKM: getEnvAsFloat("RADIUS_KM", 5.0),
+		GRPCPort:    getEnv("GRPC_PORT", "50051"),
+		MetricsPort: getEnv("METRICS_PORT", "9090"),
+		RadiusKM:    getEnvAsFloat("RADIUS_KM", 5.0),
 	}
 }
 
 func getEnv(key, defaultVal string) string {
 	val := os.Getenv(key)
 	if val == "" {
-		log.Printf("Env %s not found. Using default: %s", key, defaultVal)
+		slog.Info("Env not found, using default", "key", key, "default", defaultVal)
 		return defaultVal
 	}
 	return val
@@ -30,12 +32,12 @@ func getEnv(key, defaultVal string) string {
 func getEnvAsFloat(key string, defaultVal float64) float64 {
 	valStr := os.Getenv(key)
 	if valStr == "" {
-		log.Printf("Env %s not found. Using default: %f", key, defaultVal)
+		slog.Info("Env not found, using default", "key", key, "default", defaultVal)
 		return defaultVal
 	}
 	val, err := strconv.ParseFloat(valStr, 64)
 	if err != nil {
-		log.Printf("Invalid float for %s: %v. Using default: %f", key, err, defaultVal)
+		slog.Warn("Invalid float, using default", "key", key, "error", err, "default", defaultVal)
 		return defaultVal
 	}
 	return val
@@ -0,0 +1,45 @@
+// Package metrics exposes the geo-distance service's Prometheus metrics.
+// The gRPC server has no HTTP listener of its own, so they are served on a
+// separate metrics port.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	handled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "geo_grpc_server_handled_total",
+		Help: "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	duration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "geo_grpc_server_handling_seconds",
+		Help:    "Time taken to handle gRPC calls, by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// UnaryServerInterceptor records the rate, errors and duration of every call
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	handled.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	duration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// Serve serves /metrics on port until the listener fails
+func Serve(port string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return http.ListenAndServe(":"+port, mux)
+}
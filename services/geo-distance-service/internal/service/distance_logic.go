@@ -1,9 +1,11 @@
 package service
 
 import (
+	"fmt"
 	"geo-distance-service/internal/config"
 	"geo-distance-service/proto"
 	"math"
+	"platform/shared/apperror"
 	"strings"
 )
 
@@ -23,7 +25,11 @@ func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
 	return EarthRadiusKm * c
 }
 
-func FilterRidesByProximity(cfg *config.Config, input *proto.FilterRequest) []*proto.Ride {
+func FilterRidesByProximity(cfg *config.Config, input *proto.FilterRequest) ([]*proto.Ride, error) {
+	if err := validateFilterRequest(input); err != nil {
+		return nil, err
+	}
+
 	var filtered []*proto.Ride
 	point := input.Point
 	matchType := strings.ToLower(input.MatchType.String())
@@ -40,5 +46,32 @@ func FilterRidesByProximity(cfg *config.Config, input *proto.FilterRequest) []*p
 			filtered = append(filtered, ride)
 		}
 	}
-	return filtered
+	return filtered, nil
+}
+
+func validateFilterRequest(input *proto.FilterRequest) error {
+	if input.Point == nil {
+		return apperror.Invalid("point", "required", "point is required")
+	}
+
+	var fields []apperror.FieldError
+	checkPoint := func(field string, p *proto.Point) {
+		if p == nil {
+			fields = append(fields, apperror.FieldError{Field: field, Code: "required", Message: field + " is required"})
+			return
+		}
+		if p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+			fields = append(fields, apperror.FieldError{Field: field, Code: "out_of_range", Message: "coordinates are out of range"})
+		}
+	}
+	checkPoint("point", input.Point)
+	for i, ride := range input.Rides {
+		checkPoint(fmt.Sprintf("rides[%d].start_point", i), ride.StartPoint)
+		checkPoint(fmt.Sprintf("rides[%d].end_point", i), ride.EndPoint)
+	}
+
+	if len(fields) > 0 {
+		return apperror.Validation("invalid_coordinates", "request contains invalid coordinates", fields...)
+	}
+	return nil
 }
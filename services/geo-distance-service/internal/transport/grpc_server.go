@@ -2,9 +2,17 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"geo-distance-service/internal/config"
 	"geo-distance-service/internal/service"
 	pb "geo-distance-service/proto"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
 )
 
 type GeoServer struct {
@@ -17,6 +25,58 @@ func NewGeoServer(cfg *config.Config) *GeoServer {
 }
 
 func (s *GeoServer) FilterRidesByProximity(ctx context.Context, req *pb.FilterRequest) (*pb.FilterResponse, error) {
-	filtered := service.FilterRidesByProximity(s.Config, req)
+	filtered, err := service.FilterRidesByProximity(s.Config, req)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
 	return &pb.FilterResponse{Rides: filtered}, nil
 }
+
+// toStatus maps a domain error to a gRPC status. The stable error code is
+// sent as ErrorInfo.Reason and field problems as a BadRequest detail.
+func toStatus(ctx context.Context, err error) error {
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		logging.FromContext(ctx).Error("Internal error", "error", err)
+		return status.Error(codes.Internal, "an unexpected error occurred")
+	}
+
+	st := status.New(codeOf(appErr.Kind), appErr.Message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: appErr.Code, Domain: "geo-distance-service"}}
+	if len(appErr.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, f := range appErr.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	withDetails, detailErr := st.WithDetails(details...)
+	if detailErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+func codeOf(kind apperror.Kind) codes.Code {
+	switch kind {
+	case apperror.KindValidation:
+		return codes.InvalidArgument
+	case apperror.KindUnauthorized:
+		return codes.Unauthenticated
+	case apperror.KindForbidden:
+		return codes.PermissionDenied
+	case apperror.KindNotFound:
+		return codes.NotFound
+	case apperror.KindConflict:
+		return codes.FailedPrecondition
+	case apperror.KindUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
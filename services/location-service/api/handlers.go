@@ -2,13 +2,15 @@ package api
 
 import (
     "encoding/json"
-    "log"
     "net/http"
     "strconv"
     "time"
+    "platform/shared/logging"
     "location-service/models"
     "location-service/queue"
     "location-service/service"
+    "platform/shared/apperror"
+    "platform/shared/problem"
 )
 
 // Handler holds dependencies for HTTP handlers
@@ -28,46 +30,45 @@ func (h *Handler) GetLatestLocation(w http.ResponseWriter, r *http.Request) {
     rideIDStr := r.URL.Path[len("/location/"):]
     rideID, err := strconv.Atoi(rideIDStr)
     if err != nil {
-        http.Error(w, "Invalid rideID", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "rideID", "Invalid rideID")
         return
     }
 
     // Call service to get the latest location
     update, err := h.svc.GetLatestLocation(rideID)
     if err != nil {
-        log.Printf("Failed to get latest location for ride %d: %v", rideID, err)
-        http.Error(w, "Failed to get location", http.StatusInternalServerError)
+        logging.FromContext(r.Context()).Error("Failed to get latest location", "rideID", rideID, "error", err)
+        problem.Error(w, r, err)
         return
     }
     if update == nil {
-        http.Error(w, "Location not found", http.StatusNotFound)
+        problem.Error(w, r, apperror.NotFound("location_not_found", "Location not found"))
         return
     }
 
     // Respond with JSON
     w.Header().Set("Content-Type", "application/json")
     if err := json.NewEncoder(w).Encode(update); err != nil {
-        log.Printf("Failed to encode response: %v", err)
-        http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+        logging.FromContext(r.Context()).Error("Failed to encode response", "error", err)
     }
 }
 
 // PostLocation handles POST /location requests
 func (h *Handler) PostLocation(w http.ResponseWriter, r *http.Request) {
     if r.Method != http.MethodPost {
-        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        problem.New(http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed").Write(w, r)
         return
     }
 
     var update models.LocationUpdate
     if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        problem.InvalidBody(w, r)
         return
     }
 
     // Validate required fields
-    if update.RideID == 0 || update.Latitude == 0 || update.Longitude == 0 {
-        http.Error(w, "Missing required fields", http.StatusBadRequest)
+    if fields := missingLocationFields(update); len(fields) > 0 {
+        problem.Error(w, r, apperror.Validation("missing_fields", "Missing required fields", fields...))
         return
     }
 
@@ -80,16 +81,31 @@ func (h *Handler) PostLocation(w http.ResponseWriter, r *http.Request) {
     }
 
     // Log after setting defaults
-    log.Printf("Received and processed update: %+v", update)
+    logging.FromContext(r.Context()).Debug("Received location update", "rideID", update.RideID, "latitude", update.Latitude, "longitude", update.Longitude)
 
     // Publish to queue
     if err := h.queue.Publish(update); err != nil {
-        log.Printf("Failed to publish location update: %v", err)
-        http.Error(w, "Failed to publish location update", http.StatusInternalServerError)
+        logging.FromContext(r.Context()).Error("Failed to publish location update", "error", err)
+        problem.Error(w, r, err)
         return
     }
 
     w.WriteHeader(http.StatusCreated)
     w.Header().Set("Content-Type", "application/json")
     json.NewEncoder(w).Encode(map[string]string{"message": "Location update published"})
-}
\ No newline at end of file
+}
+
+// missingLocationFields lists the required fields left at their zero value
+func missingLocationFields(update models.LocationUpdate) []apperror.FieldError {
+    var fields []apperror.FieldError
+    if update.RideID == 0 {
+        fields = append(fields, apperror.FieldError{Field: "rideID", Code: "required", Message: "rideID is required"})
+    }
+    if update.Latitude == 0 {
+        fields = append(fields, apperror.FieldError{Field: "latitude", Code: "required", Message: "latitude is required"})
+    }
+    if update.Longitude == 0 {
+        fields = append(fields, apperror.FieldError{Field: "longitude", Code: "required", Message: "longitude is required"})
+    }
+    return fields
+}
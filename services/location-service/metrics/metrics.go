@@ -0,0 +1,84 @@
+// Package metrics exposes the location service's Prometheus metrics.
+// Requests are labelled with the matched pattern rather than the raw path so
+// ride IDs in URLs don't create a series per ride.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "location_http_requests_total",
+		Help: "HTTP requests handled, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	duration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "location_http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	consumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "location_queue_messages_consumed_total",
+		Help: "Location updates taken off the queue, by result (ok or invalid).",
+	}, []string{"result"})
+
+	consumeLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "location_queue_consume_lag_seconds",
+		Help:    "Time between a location update being published and consumed.",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	})
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the rate, errors and duration of every request mux
+// handles, including ones that match no pattern
+func Middleware(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		mux.ServeHTTP(rec, r)
+
+		// ServeMux sets the matched pattern on the request it is given
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		requests.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Consumed counts a message taken off the queue. published is when it was
+// sent, and is zero when the publisher did not set it.
+func Consumed(published time.Time, valid bool) {
+	result := "ok"
+	if !valid {
+		result = "invalid"
+	}
+	consumed.WithLabelValues(result).Inc()
+	if !published.IsZero() {
+		consumeLag.Observe(time.Since(published).Seconds())
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -1,8 +1,11 @@
 package queue
 
 import (
+    "context"
     "encoding/json"
-    "log"
+    "log/slog"
+    "time"
+    "location-service/metrics"
     "location-service/models"
 
     "github.com/streadway/amqp"
@@ -14,7 +17,14 @@ type LocationQueue interface {
     Publish(update models.LocationUpdate) error
 }
 
+// Pinger is implemented by queues that can report whether their connection
+// to the broker is usable
+type Pinger interface {
+    Ping(ctx context.Context) error
+}
+
 type locationQueue struct {
+    conn    *amqp.Connection
     channel *amqp.Channel
     queue   amqp.Queue
 }
@@ -32,7 +42,16 @@ func NewLocationQueue(rabbitMQURI string) (LocationQueue, error) {
     if err != nil {
         return nil, err
     }
-    return &locationQueue{channel: ch, queue: q}, nil
+    return &locationQueue{conn: conn, channel: ch, queue: q}, nil
+}
+
+// Ping fails once the connection has closed. The queue does not reconnect,
+// so the instance stays unready until it is restarted.
+func (q *locationQueue) Ping(ctx context.Context) error {
+    if q.conn.IsClosed() {
+        return amqp.ErrClosed
+    }
+    return nil
 }
 
 func (q *locationQueue) Consume(handler func(models.LocationUpdate)) error {
@@ -44,9 +63,11 @@ func (q *locationQueue) Consume(handler func(models.LocationUpdate)) error {
         for msg := range msgs {
             var update models.LocationUpdate
             if err := json.Unmarshal(msg.Body, &update); err != nil {
-                log.Printf("Failed to unmarshal location update: %v", err)
+                slog.Error("Failed to unmarshal location update", "error", err)
+                metrics.Consumed(msg.Timestamp, false)
                 continue
             }
+            metrics.Consumed(msg.Timestamp, true)
             handler(update)
         }
     }()
@@ -60,6 +81,7 @@ func (q *locationQueue) Publish(update models.LocationUpdate) error {
     }
     return q.channel.Publish("", q.queue.Name, false, false, amqp.Publishing{
         ContentType: "application/json",
+        Timestamp:   time.Now(),
         Body:        body,
     })
 }
\ No newline at end of file
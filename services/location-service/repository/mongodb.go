@@ -2,7 +2,7 @@ package repository
 
 import (
     "context"
-    "log"
+    "platform/shared/logging"
     "location-service/models"
 
     "go.mongodb.org/mongo-driver/bson"
@@ -28,7 +28,7 @@ func NewLocationRepository(client *mongo.Client) LocationRepository {
 func (r *locationRepository) Save(ctx context.Context, update models.LocationUpdate) error {
     _, err := r.collection.InsertOne(ctx, update)
     if err != nil {
-        log.Printf("Failed to save location update: %v", err)
+        logging.FromContext(ctx).Error("Failed to save location update", "rideID", update.RideID, "error", err)
         return err
     }
     return nil
@@ -39,7 +39,7 @@ func (r *locationRepository) GetLatest(ctx context.Context, rideID int) (*models
     opts := options.FindOne().SetSort(bson.M{"timestamp": -1})
     err := r.collection.FindOne(ctx, bson.M{"ride_id": rideID}, opts).Decode(&update)
     if err != nil {
-        log.Printf("Failed to get latest location for ride %d: %v", rideID, err)
+        logging.FromContext(ctx).Error("Failed to get latest location", "rideID", rideID, "error", err)
         return nil, err
     }
     return &update, nil
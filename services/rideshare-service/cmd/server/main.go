@@ -4,108 +4,285 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"time"
 
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/contract"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/handlers"
-	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/blob"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/chat"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/config"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/fare"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/geocode"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/idempotency"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/impact"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/metrics"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/notification"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/outbox"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/presence"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
-	"github.com/gorilla/mux"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/migrations"
+	"github.com/redis/go-redis/v9"
+	"platform/shared/health"
+	"platform/shared/logging"
 )
 
 func main() {
     // Load configuration
     cfg, err := config.LoadConfig(".")
     if err != nil {
-        log.Fatalf("Failed to load configuration: %v", err)
+        logging.Fatal("Failed to load configuration", "error", err)
+    }
+
+    // Log JSON lines at the configured level
+    if _, err := logging.Setup(cfg.Server.LogLevel); err != nil {
+        logging.Fatal("Invalid log configuration", "error", err)
     }
 
     // Initialize database connection
     dbManager, err := db.NewDBManager(&cfg.Database)
     if err != nil {
-        log.Fatalf("Failed to initialize database: %v", err)
+        logging.Fatal("Failed to initialize database", "error", err)
     }
     defer dbManager.Close()
+    dbManager.WatchPools()
+
+    // Bring a database created from an older schema up to date
+    if err := db.Migrate(context.Background(), dbManager.GetPrimary(), migrations.Files); err != nil {
+        logging.Fatal("Failed to migrate database", "error", err)
+    }
+
+    // The service is ready once it can reach the databases and whichever of
+    // Redis and RabbitMQ are configured
+    checker := health.NewChecker(time.Duration(cfg.Server.ReadinessTimeout) * time.Second)
+    for name, pool := range dbManager.Pools() {
+        checker.Add("postgres-"+name, pool.PingContext)
+    }
+
+    // Initialize presence tracking and idempotency keys, falling back to memory when Redis is not configured
+    presenceTTL := time.Duration(cfg.Presence.TTL) * time.Second
+    idempotencyTTL := time.Duration(cfg.Idempotency.TTL) * time.Second
+    var presenceStore presence.Store
+    var idempotencyStore idempotency.Store
+    if cfg.Redis.Address != "" {
+        redisClient := redis.NewClient(&redis.Options{
+            Addr:     cfg.Redis.Address,
+            Password: cfg.Redis.Password,
+            DB:       cfg.Redis.DB,
+        })
+        defer redisClient.Close()
+        checker.Add("redis", func(ctx context.Context) error {
+            return redisClient.Ping(ctx).Err()
+        })
+        presenceStore = presence.NewRedisStore(redisClient, presenceTTL,
+            time.Duration(cfg.Presence.LastSeenDays)*24*time.Hour)
+        idempotencyStore = idempotency.NewRedisStore(redisClient, idempotencyTTL)
+    } else {
+        slog.Warn("Redis not configured, tracking presence and idempotency keys in memory")
+        presenceStore = presence.NewMemoryStore(presenceTTL)
+        idempotencyStore = idempotency.NewMemoryStore(idempotencyTTL)
+    }
+
+    // Initialize notification channels. Email is only enabled when an SMTP relay is configured.
+    notifyCfg := cfg.Notifications
+    channels := []notification.Channel{
+        notification.NewInboxChannel(dbManager),
+        notification.NewWebhookChannel(notifyCfg.Webhook.Secret, time.Duration(notifyCfg.Webhook.Timeout)*time.Second),
+    }
+    if notifyCfg.SMTP.Host != "" {
+        channels = append(channels, notification.NewEmailChannel(notification.SMTPConfig{
+            Host:     notifyCfg.SMTP.Host,
+            Port:     notifyCfg.SMTP.Port,
+            Username: notifyCfg.SMTP.Username,
+            Password: notifyCfg.SMTP.Password,
+            From:     notifyCfg.SMTP.From,
+            Timeout:  time.Duration(notifyCfg.SMTP.Timeout) * time.Second,
+        }))
+    } else {
+        slog.Warn("SMTP not configured, email notifications disabled")
+    }
+    backoff := notification.DefaultBackoff
+    backoff.MaxAttempts = notifyCfg.MaxAttempts
+    backoff.InitialDelay = time.Duration(notifyCfg.InitialBackoffMs) * time.Millisecond
+    backoff.MaxDelay = time.Duration(notifyCfg.MaxBackoffMs) * time.Millisecond
+    dispatcher := notification.NewDispatcher(backoff, channels...)
+
+    // Domain events go through the outbox; the relay hands them to RabbitMQ,
+    // when configured, and then to local subscribers. An event stays in the
+    // outbox until every subscriber has handled it.
+    eventBus := events.NewBus()
+    var eventPublisher events.Publisher = eventBus
+    if cfg.RabbitMQ.URI != "" {
+        amqpPublisher := outbox.NewAMQPPublisher(cfg.RabbitMQ.URI, cfg.RabbitMQ.Exchange)
+        defer amqpPublisher.Close()
+        checker.Add("rabbitmq", amqpPublisher.Ping)
+        eventPublisher = events.Fanout(amqpPublisher, eventBus)
+    } else {
+        slog.Warn("RabbitMQ not configured, domain events are only delivered in-process")
+    }
+    relay := outbox.NewRelay(dbManager, eventPublisher, cfg.Outbox.BatchSize)
+
+    // Addresses are geocoded against a local gazetteer
+    gazetteer, err := geocode.LoadGazetteer(cfg.Geocoding.GazetteerPath)
+    if err != nil {
+        logging.Fatal("Failed to load gazetteer", "error", err)
+    }
+
+    // Rides are priced at cost
+    fares := fare.Model{
+        FuelPricePerLiter: cfg.Fares.FuelPricePerLiter,
+        LitersPer100Km:    cfg.Fares.LitersPer100Km,
+        WearCostPerKm:     cfg.Fares.WearCostPerKm,
+        RoadFactor:        cfg.Fares.RoadFactor,
+        Currency:          cfg.Fares.Currency,
+    }
+
+    // Verification documents are kept on disk, or in memory when no directory is configured
+    var documentStore blob.Store
+    if cfg.Documents.StorageDir != "" {
+        documentStore, err = blob.NewDirStore(cfg.Documents.StorageDir)
+        if err != nil {
+            logging.Fatal("Failed to initialize document storage", "error", err)
+        }
+    } else {
+        slog.Warn("Document storage not configured, keeping uploads in memory")
+        documentStore = blob.NewMemoryStore()
+    }
+    maxUpload := int64(cfg.Documents.MaxUploadMB) << 20
 
     // Initialize services
-    rideService := service.NewRideService(dbManager)
+    blockService := service.NewBlockService(dbManager)
+    reportService := service.NewReportService(dbManager)
+    communityService := service.NewCommunityService(dbManager)
+    impactService := service.NewImpactService(dbManager, impact.Model{
+        Fares: fares,
+        Baseline: impact.Vehicle{
+            FuelType:   cfg.Impact.BaselineFuelType,
+            Efficiency: cfg.Impact.BaselineEfficiency,
+        },
+        GridKgPerKWh: cfg.Impact.GridKgCO2PerKWh,
+    })
+    documentService := service.NewDocumentService(dbManager, documentStore, maxUpload, cfg.Documents.RequiredForHosting)
+    rideService := service.NewRideService(dbManager, blockService, communityService, impactService, documentService, gazetteer, fares)
+    notificationService := service.NewNotificationService(dbManager, dispatcher, notifyCfg.RideLinkBase)
+    eventBus.Subscribe(notificationService.HandleEvent)
+    savedSearchService := service.NewSavedSearchService(dbManager, rideService, blockService, communityService)
+    eventBus.Subscribe(savedSearchService.HandleEvent)
     userService := service.NewUserService(dbManager)
     vehicleService := service.NewVehicleService(dbManager)
+    chatService := service.NewChatService(dbManager, chat.NewHub())
+    presenceService := service.NewPresenceService(presenceStore, dbManager)
+    auditService := service.NewAuditService(dbManager)
+    calendarService := service.NewCalendarService(dbManager, cfg.Calendar.FeedBase, notifyCfg.RideLinkBase,
+        fares.Currency, time.Duration(cfg.Calendar.RefreshMinutes)*time.Minute)
 
     // Initialize handlers
-    rideHandler := handlers.NewRideHandler(rideService, vehicleService)
+    rideHandler := handlers.NewRideHandler(rideService, vehicleService, presenceService)
     userHandler := handlers.NewUserHandler(userService)
     vehicleHandler := handlers.NewVehicleHandler(vehicleService)
+    blockHandler := handlers.NewBlockHandler(blockService)
+    reportHandler := handlers.NewReportHandler(reportService)
+    chatHandler := handlers.NewChatHandler(chatService, presenceService, cfg.Server.AllowedOrigins)
+    presenceHandler := handlers.NewPresenceHandler(presenceService)
+    notificationHandler := handlers.NewNotificationHandler(notificationService)
+    auditHandler := handlers.NewAuditHandler(auditService)
+    placeHandler := handlers.NewPlaceHandler(gazetteer)
+    communityHandler := handlers.NewCommunityHandler(communityService)
+    fareHandler := handlers.NewFareHandler(fares, vehicleService)
+    impactHandler := handlers.NewImpactHandler(impactService)
+    savedSearchHandler := handlers.NewSavedSearchHandler(savedSearchService)
+    calendarHandler := handlers.NewCalendarHandler(calendarService)
+    documentHandler := handlers.NewDocumentHandler(documentService, maxUpload)
     
-    // Set up router
-    r := mux.NewRouter()
-    
-    // Add health check endpoint
-    r.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
-        // Check database connection
-        dbStatus := "ok"
-        
-        // Try executing a simple query to test connectivity
-        primaryDB := dbManager.GetPrimary()
-        if err := primaryDB.Ping(); err != nil {
-            dbStatus = "error: " + err.Error()
+    // Load the OpenAPI contract. Only strict mode refuses to start without it.
+    contractMode, err := contract.ParseMode(cfg.Contract.Mode)
+    if err != nil {
+        logging.Fatal("Invalid contract configuration", "error", err)
+    }
+    validator, err := contract.Load(cfg.Contract.SpecPath, "rideshare", contractMode)
+    if err != nil {
+        if contractMode == contract.ModeStrict {
+            logging.Fatal("Failed to load API contract", "error", err)
         }
-        
-        w.Header().Set("Content-Type", "application/json")
-        json.NewEncoder(w).Encode(map[string]string{
-            "status": "ok",
-            "database": dbStatus,
-            "version": "1.0.0",
-        })
-    }).Methods("GET")    
-    
-    // Public routes (no authentication required)
-    public := r.PathPrefix("/api").Subrouter()
-
-    // IMPORTANT: Register most specific routes first!
-    public.HandleFunc("/rides/nearby", rideHandler.FindNearbyRides).Methods("GET")
-    public.HandleFunc("/rides/{id}", rideHandler.GetRide).Methods("GET")
-    public.HandleFunc("/users/{id}/vehicles", vehicleHandler.GetUserVehicles).Methods("GET")
-    public.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
-    public.HandleFunc("/users/register", userHandler.RegisterUser).Methods("POST")
-    public.HandleFunc("/users/login", userHandler.LoginUser).Methods("POST")
-
-    // Middleware to inject user service into request context
-    serviceMiddleware := func(next http.Handler) http.Handler {
-        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-            // Add user service to request context
-            ctx := context.WithValue(r.Context(), "userService", userService)
-            next.ServeHTTP(w, r.WithContext(ctx))
-        })
+        slog.Warn("API contract not loaded, requests are not checked", "error", err)
     }
 
-    // Protected routes with authentication
-    protected := r.PathPrefix("/api").Subrouter()
-    protected.Use(serviceMiddleware)  // Add service middleware first
-    protected.Use(middleware.AuthMiddleware)  // Then auth middleware
-    protected.HandleFunc("/vehicles", vehicleHandler.CreateVehicle).Methods("POST")
-    protected.HandleFunc("/vehicles", vehicleHandler.GetUserVehiclesForAuthUser).Methods("GET")
-    protected.HandleFunc("/rides", rideHandler.CreateRide).Methods("POST")
-    protected.HandleFunc("/rides/{id}", rideHandler.CancelRide).Methods("DELETE")
+    // Set up router
+    r := newRouter(routeHandlers{
+        health: func(w http.ResponseWriter, r *http.Request) {
+            // Check database connection
+            dbStatus := "ok"
+
+            // Try executing a simple query to test connectivity
+            primaryDB := dbManager.GetPrimary()
+            if err := primaryDB.Ping(); err != nil {
+                dbStatus = "error: " + err.Error()
+            }
+
+            w.Header().Set("Content-Type", "application/json")
+            json.NewEncoder(w).Encode(map[string]string{
+                "status": "ok",
+                "database": dbStatus,
+                "version": "1.0.0",
+            })
+        },
+        userService:  userService,
+        ride:         rideHandler,
+        user:         userHandler,
+        vehicle:      vehicleHandler,
+        block:        blockHandler,
+        report:       reportHandler,
+        chat:         chatHandler,
+        presence:     presenceHandler,
+        notification: notificationHandler,
+        audit:        auditHandler,
+        place:        placeHandler,
+        community:    communityHandler,
+        fare:         fareHandler,
+        impact:       impactHandler,
+        savedSearch:  savedSearchHandler,
+        calendar:     calendarHandler,
+        document:     documentHandler,
+    }, validator, idempotencyStore)
+
+    // /metrics and the probes are served beside the API rather than by its
+    // router, which only carries the routes in the OpenAPI spec
+    mux := http.NewServeMux()
+    mux.Handle("/metrics", metrics.Handler())
+    mux.HandleFunc("GET /livez", checker.Live)
+    mux.HandleFunc("GET /readyz", checker.Ready)
+    mux.Handle("/", metrics.Middleware(r))
 
     // Create HTTP server
     srv := &http.Server{
         Addr:         ":" + cfg.Server.Port,
-        Handler:      r,
+        Handler:      mux,
         ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
         WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
     }
 
+    // Send "starting soon" and document expiry reminders, relay outbox events
+    // and send queued email and webhook notifications in the background
+    backgroundCtx, stopBackground := context.WithCancel(context.Background())
+    go rideService.RunStartingSoonReminders(backgroundCtx,
+        time.Duration(notifyCfg.ReminderLeadMinutes)*time.Minute,
+        time.Duration(notifyCfg.ReminderInterval)*time.Second)
+    go documentService.RunExpiryReminders(backgroundCtx, cfg.Documents.ExpiryReminderDays,
+        time.Duration(cfg.Documents.ReminderInterval)*time.Second)
+    go relay.Run(backgroundCtx, time.Duration(cfg.Outbox.PollInterval)*time.Millisecond)
+    go notificationService.RunDeliveries(backgroundCtx, notifyCfg.DeliveryBatchSize,
+        time.Duration(notifyCfg.DeliveryInterval)*time.Second)
+
     // Start server in a goroutine
     go func() {
-        log.Printf("Server starting on port %s", cfg.Server.Port)
+        slog.Info("Server starting", "port", cfg.Server.Port)
         if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-            log.Fatalf("Failed to start server: %v", err)
+            logging.Fatal("Failed to start server", "error", err)
         }
     }()
 
@@ -114,13 +291,15 @@ func main() {
     signal.Notify(c, os.Interrupt)
     <-c
 
+    stopBackground()
+
     // Create a deadline to wait for
     ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
     defer cancel()
 
     // Doesn't block if no connections, but will otherwise wait until the timeout
     if err := srv.Shutdown(ctx); err != nil {
-        log.Fatalf("Server shutdown failed: %v", err)
+        logging.Fatal("Server shutdown failed", "error", err)
     }
-    log.Println("Server gracefully stopped")
+    slog.Info("Server gracefully stopped")
 }
\ No newline at end of file
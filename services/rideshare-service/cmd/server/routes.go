@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/contract"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/handlers"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/idempotency"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/gorilla/mux"
+	"platform/shared/problem"
+)
+
+// routeHandlers holds everything the router dispatches to
+type routeHandlers struct {
+    health       http.HandlerFunc
+    userService  *service.UserService
+    ride         *handlers.RideHandler
+    user         *handlers.UserHandler
+    vehicle      *handlers.VehicleHandler
+    block        *handlers.BlockHandler
+    report       *handlers.ReportHandler
+    chat         *handlers.ChatHandler
+    presence     *handlers.PresenceHandler
+    notification *handlers.NotificationHandler
+    audit        *handlers.AuditHandler
+    place        *handlers.PlaceHandler
+    community    *handlers.CommunityHandler
+    fare         *handlers.FareHandler
+    impact       *handlers.ImpactHandler
+    savedSearch  *handlers.SavedSearchHandler
+    calendar     *handlers.CalendarHandler
+    document     *handlers.DocumentHandler
+}
+
+// newRouter registers every route of the API. Each one must also be described
+// in api-specs/openapi.yaml, which routes_test.go checks.
+func newRouter(h routeHandlers, validator *contract.Validator, idempotencyStore idempotency.Store) *mux.Router {
+    r := mux.NewRouter()
+    r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        problem.New(http.StatusNotFound, "route_not_found", "No route matches "+r.URL.Path).Write(w, r)
+    })
+    r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        problem.New(http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+r.URL.Path).Write(w, r)
+    })
+
+    // Tag every request with an ID and record who sent it for the audit log
+    r.Use(middleware.RequestIDMiddleware)
+    r.Use(middleware.AuditMetadataMiddleware)
+
+    // Check traffic against the OpenAPI contract
+    r.Use(validator.Middleware)
+
+    // Replay recorded responses to retried POST, PUT, PATCH and DELETE
+    // requests. Keys are scoped to the caller, so it runs after authentication.
+    idempotent := middleware.IdempotencyMiddleware(idempotencyStore)
+
+    // Add health check endpoint
+    r.HandleFunc("/api/health", h.health).Methods("GET")
+
+    // Middleware to inject user service into request context
+    serviceMiddleware := func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            // Add user service to request context
+            ctx := context.WithValue(r.Context(), "userService", h.userService)
+            next.ServeHTTP(w, r.WithContext(ctx))
+        })
+    }
+
+    // Public routes (no authentication required)
+    public := r.PathPrefix("/api").Subrouter()
+    public.Use(serviceMiddleware)
+    public.Use(idempotent)
+
+    // IMPORTANT: Register most specific routes first!
+    // Nearby search is public, but signed-in callers don't see rides from blocked users
+    public.Handle("/rides/nearby", middleware.OptionalAuthMiddleware(http.HandlerFunc(h.ride.FindNearbyRides))).Methods("GET")
+    public.HandleFunc("/rides/{id}", h.ride.GetRide).Methods("GET")
+    public.HandleFunc("/users/{id}/vehicles", h.vehicle.GetUserVehicles).Methods("GET")
+    public.HandleFunc("/users/{id}", h.user.GetUser).Methods("GET")
+    public.HandleFunc("/users/register", h.user.RegisterUser).Methods("POST")
+    public.HandleFunc("/users/login", h.user.LoginUser).Methods("POST")
+    public.HandleFunc("/places/geocode", h.place.Geocode).Methods("GET")
+    public.HandleFunc("/places/reverse", h.place.Reverse).Methods("GET")
+    public.HandleFunc("/places/autocomplete", h.place.Autocomplete).Methods("GET")
+    public.HandleFunc("/fares/estimate", h.fare.Estimate).Methods("GET")
+    // Calendar feeds are authorized by the secret token in their URL
+    public.HandleFunc("/calendar/{token}.ics", h.calendar.Feed).Methods("GET")
+
+    // Protected routes with authentication
+    protected := r.PathPrefix("/api").Subrouter()
+    protected.Use(serviceMiddleware)  // Add service middleware first
+    protected.Use(middleware.AuthMiddleware)  // Then auth middleware
+    protected.Use(idempotent)
+    protected.HandleFunc("/vehicles", h.vehicle.CreateVehicle).Methods("POST")
+    protected.HandleFunc("/vehicles", h.vehicle.GetUserVehiclesForAuthUser).Methods("GET")
+    protected.HandleFunc("/vehicles/{id}/documents", h.document.UploadVehicleDocument).Methods("POST")
+    protected.HandleFunc("/rides", h.ride.CreateRide).Methods("POST")
+    protected.HandleFunc("/rides/{id}", h.ride.UpdateRide).Methods("PATCH")
+    protected.HandleFunc("/rides/{id}", h.ride.CancelRide).Methods("DELETE")
+    protected.HandleFunc("/rides/{id}/complete", h.ride.CompleteRide).Methods("POST")
+    protected.HandleFunc("/rides/{id}/requests", h.ride.RequestToJoin).Methods("POST")
+    protected.HandleFunc("/rides/{id}/requests", h.ride.ListRideRequests).Methods("GET")
+    protected.HandleFunc("/rides/{id}/requests/{requestId}", h.ride.DecideRideRequest).Methods("PUT")
+    protected.HandleFunc("/rides/{id}/calendar.ics", h.calendar.DownloadRide).Methods("GET")
+    protected.HandleFunc("/rides/{id}/chat/messages", h.chat.ListMessages).Methods("GET")
+    protected.HandleFunc("/rides/{id}/chat/messages", h.chat.SendMessage).Methods("POST")
+    protected.HandleFunc("/rides/{id}/chat/read", h.chat.ListReadReceipts).Methods("GET")
+    protected.HandleFunc("/rides/{id}/chat/read", h.chat.MarkRead).Methods("POST")
+    protected.HandleFunc("/users/me", h.user.UpdateProfile).Methods("PATCH")
+    protected.HandleFunc("/users/me/community", h.community.GetProfile).Methods("GET")
+    protected.HandleFunc("/users/me/community", h.community.UpdateProfile).Methods("PUT")
+    protected.HandleFunc("/users/me/impact", h.impact.GetOwnImpact).Methods("GET")
+    protected.HandleFunc("/users/me/documents", h.document.UploadDriverDocument).Methods("POST")
+    protected.HandleFunc("/users/me/documents", h.document.ListOwnDocuments).Methods("GET")
+    protected.HandleFunc("/documents/{id}/file", h.document.DownloadOwnDocument).Methods("GET")
+    protected.HandleFunc("/users/me/calendar", h.calendar.GetFeed).Methods("GET")
+    protected.HandleFunc("/users/me/calendar/rotate", h.calendar.RotateFeed).Methods("POST")
+    protected.HandleFunc("/impact/campuses/{university}", h.impact.GetCampusImpact).Methods("GET")
+    protected.HandleFunc("/impact/campuses/{university}/leaderboard", h.impact.GetLeaderboard).Methods("GET")
+    protected.HandleFunc("/saved-searches", h.savedSearch.CreateSavedSearch).Methods("POST")
+    protected.HandleFunc("/saved-searches", h.savedSearch.ListSavedSearches).Methods("GET")
+    protected.HandleFunc("/saved-searches/{id}", h.savedSearch.GetSavedSearch).Methods("GET")
+    protected.HandleFunc("/saved-searches/{id}", h.savedSearch.UpdateSavedSearch).Methods("PUT")
+    protected.HandleFunc("/saved-searches/{id}", h.savedSearch.DeleteSavedSearch).Methods("DELETE")
+    protected.HandleFunc("/users/{id}/block", h.block.BlockUser).Methods("POST")
+    protected.HandleFunc("/users/{id}/block", h.block.UnblockUser).Methods("DELETE")
+    protected.HandleFunc("/blocks", h.block.ListBlockedUsers).Methods("GET")
+    protected.HandleFunc("/reports", h.report.CreateReport).Methods("POST")
+    protected.HandleFunc("/notifications", h.notification.ListNotifications).Methods("GET")
+    protected.HandleFunc("/notifications/unread-count", h.notification.UnreadCount).Methods("GET")
+    protected.HandleFunc("/notifications/read", h.notification.MarkAllRead).Methods("POST")
+    protected.HandleFunc("/notifications/preferences", h.notification.GetPreferences).Methods("GET")
+    protected.HandleFunc("/notifications/preferences", h.notification.UpdatePreferences).Methods("PUT")
+    protected.HandleFunc("/notifications/{id}/read", h.notification.MarkRead).Methods("POST")
+    protected.HandleFunc("/presence", h.presence.Lookup).Methods("GET")
+    protected.HandleFunc("/presence/heartbeat", h.presence.Heartbeat).Methods("POST")
+    protected.HandleFunc("/presence/heartbeat", h.presence.Disconnect).Methods("DELETE")
+    protected.HandleFunc("/audit", h.audit.ListOwnHistory).Methods("GET")
+
+    // Admin routes
+    admin := protected.PathPrefix("/admin").Subrouter()
+    admin.Use(middleware.AdminMiddleware)
+    admin.HandleFunc("/reports", h.report.ListReports).Methods("GET")
+    admin.HandleFunc("/reports/{id}", h.report.ReviewReport).Methods("PUT")
+    admin.HandleFunc("/audit", h.audit.ListHistory).Methods("GET")
+    admin.HandleFunc("/users/{id}/community/verify", h.community.VerifyProfile).Methods("POST")
+    admin.HandleFunc("/documents", h.document.ListDocuments).Methods("GET")
+    admin.HandleFunc("/documents/{id}", h.document.ReviewDocument).Methods("PUT")
+    admin.HandleFunc("/documents/{id}/file", h.document.DownloadDocument).Methods("GET")
+
+    // Chat WebSocket, which may carry its token in the query string
+    realtime := r.PathPrefix("/api").Subrouter()
+    realtime.Use(serviceMiddleware)
+    realtime.Use(middleware.QueryTokenMiddleware)
+    realtime.Use(middleware.AuthMiddleware)
+    realtime.HandleFunc("/rides/{id}/chat/ws", h.chat.Connect).Methods("GET")
+
+    return r
+}
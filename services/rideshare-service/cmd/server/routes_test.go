@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/contract"
+	"github.com/gorilla/mux"
+)
+
+const specPath = "../../../../api-specs/openapi.yaml"
+
+// TestRoutesMatchSpec fails when a registered route is missing from
+// api-specs/openapi.yaml or the spec describes a route that is not registered
+func TestRoutesMatchSpec(t *testing.T) {
+	doc, err := contract.LoadSpec(specPath, "rideshare")
+	if err != nil {
+		t.Fatalf("error loading spec: %v", err)
+	}
+
+	specified := map[string]bool{}
+	for template, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			specified[method+" "+template] = true
+		}
+	}
+
+	registered := map[string]bool{}
+	router := newRouter(routeHandlers{}, nil, nil)
+	err = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		// Subrouter prefixes have no methods of their own
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			registered[method+" "+template] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error walking routes: %v", err)
+	}
+
+	if missing := difference(registered, specified); len(missing) > 0 {
+		t.Errorf("registered routes missing from the spec:\n  %s", strings.Join(missing, "\n  "))
+	}
+	if stale := difference(specified, registered); len(stale) > 0 {
+		t.Errorf("spec paths that are not registered:\n  %s", strings.Join(stale, "\n  "))
+	}
+}
+
+// difference returns the keys of a that are not in b, sorted
+func difference(a, b map[string]bool) []string {
+	var out []string
+	for key := range a {
+		if !b[key] {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
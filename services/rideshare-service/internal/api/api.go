@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/handlers"
@@ -44,7 +44,7 @@ func NewServer(db *sql.DB, config ServerConfig) *Server {
 
 // registerRoutes sets up all API endpoints
 func (s *Server) registerRoutes() {
-    log.Println("Registering API routes...")
+    slog.Debug("Registering API routes")
     
     // Register the health endpoint
     s.RegisterHealthHandler()
@@ -80,7 +80,7 @@ func (s *Server) RegisterUserRoutes() {
     s.router.HandleFunc("/api/users/{id}", userHandler.GetUser).Methods("GET")
     
     // Debug logging for routes
-    log.Println("Registered user routes")
+    slog.Debug("Registered user routes")
 }
 
 // RegisterVehicleRoutes sets up all vehicle-related endpoints
@@ -114,17 +114,18 @@ func (s *Server) RegisterVehicleRoutes() {
     s.router.HandleFunc("/api/users/{id}/vehicles", vehicleHandler.GetUserVehicles).Methods("GET")
     
     // Log registered routes for debugging
-    log.Println("Registered vehicle routes:")
-    log.Println("- POST /api/vehicles")
-    log.Println("- GET /api/vehicles")
-    log.Println("- DELETE /api/vehicles/{id}")
-    log.Println("- GET /api/users/{id}/vehicles")
+    slog.Debug("Registered vehicle routes", "routes", []string{
+        "POST /api/vehicles",
+        "GET /api/vehicles",
+        "DELETE /api/vehicles/{id}",
+        "GET /api/users/{id}/vehicles",
+    })
 }
 
 // Start begins listening for HTTP requests
 func (s *Server) Start() error {
     addr := fmt.Sprintf(":%s", s.config.Port)
-    log.Printf("API server listening on %s", addr)
+    slog.Info("API server listening", "addr", addr)
     
     return http.ListenAndServe(addr, s.router)
 }
\ No newline at end of file
@@ -0,0 +1,265 @@
+// Package contract checks HTTP traffic against the OpenAPI spec in
+// api-specs/openapi.yaml, which is the authoritative description of the API
+package contract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gorilla/mux"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+// ServiceExtension names the service that serves a path item
+const ServiceExtension = "x-service"
+
+// Responses larger than this are passed through without being checked
+const maxCheckedResponse = 1 << 20
+
+// Calendar feeds are checked as plain text, and uploaded documents as files
+func init() {
+	openapi3filter.RegisterBodyDecoder("text/calendar", textBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/pdf", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("image/jpeg", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("image/png", openapi3filter.FileBodyDecoder)
+}
+
+// Mode controls what happens when traffic does not match the contract
+type Mode string
+
+const (
+	ModeOff    Mode = "off"    // Nothing is checked
+	ModeLog    Mode = "log"    // Mismatches are logged
+	ModeStrict Mode = "strict" // Non-conforming requests are rejected, response mismatches are logged
+)
+
+// ParseMode converts a configured mode, treating an empty value as log
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return ModeLog, nil
+	case ModeOff, ModeLog, ModeStrict:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown contract mode %q", s)
+	}
+}
+
+// LoadSpec reads and validates the spec at path, keeping only the paths
+// whose x-service extension is service
+func LoadSpec(path, service string) (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	paths := openapi3.NewPaths()
+	for template, item := range doc.Paths.Map() {
+		if item.Extensions[ServiceExtension] == service {
+			paths.Set(template, item)
+		}
+	}
+	doc.Paths = paths
+	return doc, nil
+}
+
+// Validator checks requests and responses of one service against the spec
+type Validator struct {
+	doc  *openapi3.T
+	mode Mode
+}
+
+// Load creates a validator for the paths of service in the spec at path
+func Load(path, service string, mode Mode) (*Validator, error) {
+	doc, err := LoadSpec(path, service)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{doc: doc, mode: mode}, nil
+}
+
+// Route returns the operation registered for method on a path template,
+// or nil when the spec does not describe it
+func (v *Validator) Route(method, template string) *routers.Route {
+	item := v.doc.Paths.Value(template)
+	if item == nil {
+		return nil
+	}
+	operation := item.GetOperation(method)
+	if operation == nil {
+		return nil
+	}
+	return &routers.Route{
+		Spec:      v.doc,
+		Path:      template,
+		PathItem:  item,
+		Method:    method,
+		Operation: operation,
+	}
+}
+
+// Middleware checks every request routed by mux against the spec. It must be
+// installed with Router.Use so the matched route template is available.
+// A nil validator checks nothing.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	if v == nil || v.mode == ModeOff {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := mux.CurrentRoute(r)
+		if current == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := current.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := v.Route(r.Method, template)
+		if route == nil {
+			logging.FromContext(r.Context()).Warn("No contract for route", "method", r.Method, "route", template)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: mux.Vars(r),
+			Route:      route,
+			Options:    options(),
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			logging.FromContext(r.Context()).Warn("Request does not match the contract", "method", r.Method, "route", template, "error", err)
+			if v.mode == ModeStrict {
+				problem.Error(w, r, apperror.Validation("contract_violation",
+					"The request does not match the API contract", FieldErrors(err)...))
+				return
+			}
+		}
+
+		// Upgraded connections have no response to check
+		if isUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.overflow {
+			return
+		}
+
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.status,
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options:                input.Options,
+		})
+		if err != nil {
+			logging.FromContext(r.Context()).Warn("Response does not match the contract", "status", rec.status, "method", r.Method, "route", template, "error", err)
+		}
+	})
+}
+
+// FieldErrors flattens a validation error into per-field errors. Fields are
+// named by parameter name or by their dotted path in the request body.
+func FieldErrors(err error) []apperror.FieldError {
+	return collectFieldErrors(err, "")
+}
+
+func collectFieldErrors(err error, field string) []apperror.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var fields []apperror.FieldError
+		for _, inner := range e {
+			fields = append(fields, collectFieldErrors(inner, field)...)
+		}
+		return fields
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		} else if e.RequestBody != nil && field == "" {
+			field = "body"
+		}
+		switch e.Err.(type) {
+		case openapi3.MultiError, *openapi3.SchemaError:
+			return collectFieldErrors(e.Err, field)
+		}
+		return []apperror.FieldError{{Field: field, Code: "invalid", Message: e.Error()}}
+	case *openapi3.SchemaError:
+		if pointer := e.JSONPointer(); len(pointer) > 0 {
+			field = strings.Join(pointer, ".")
+		}
+		code := e.SchemaField
+		if code == "" {
+			code = "invalid"
+		}
+		return []apperror.FieldError{{Field: field, Code: code, Message: e.Reason}}
+	default:
+		return []apperror.FieldError{{Field: field, Code: "invalid", Message: err.Error()}}
+	}
+}
+
+// options checks schemas only. Authentication is left to the auth
+// middleware, and requests are never rewritten with defaults.
+func options() *openapi3filter.Options {
+	return &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+		MultiError:            true,
+		SkipSettingDefaults:   true,
+	}
+}
+
+func textBodyDecoder(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (interface{}, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, &openapi3filter.ParseError{Kind: openapi3filter.KindInvalidFormat, Cause: err}
+	}
+	return string(data), nil
+}
+
+func isUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// responseRecorder passes a response through while keeping a copy to check
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if !rec.overflow {
+		if rec.body.Len()+len(b) > maxCheckedResponse {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,182 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"platform/shared/problem"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /api/rides/{id}/requests:
+    x-service: rideshare
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+          format: uuid
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [pickupLatitude]
+              properties:
+                pickupLatitude:
+                  type: number
+                  minimum: -90
+                  maximum: 90
+      responses:
+        '201':
+          description: created
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [requestId]
+                properties:
+                  requestId:
+                    type: string
+  /search:
+    x-service: search
+    post:
+      responses:
+        '200':
+          description: ok
+`
+
+func newTestRouter(t *testing.T, mode Mode, handler http.HandlerFunc) *mux.Router {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(path, []byte(testSpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v, err := Load(path, "rideshare", mode)
+	if err != nil {
+		t.Fatalf("error loading spec: %v", err)
+	}
+
+	r := mux.NewRouter()
+	r.Use(v.Middleware)
+	r.HandleFunc("/api/rides/{id}/requests", handler).Methods("POST")
+	return r
+}
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func created(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`{"requestId":"r1"}`))
+}
+
+func TestLoadSpecKeepsOnlyTheService(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	os.WriteFile(path, []byte(testSpec), 0o600)
+
+	doc, err := LoadSpec(path, "rideshare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Paths.Len() != 1 || doc.Paths.Value("/search") != nil {
+		t.Errorf("expected only the rideshare path, got %v", doc.Paths.InMatchingOrder())
+	}
+}
+
+func TestStrictModeRejectsNonConformingRequests(t *testing.T) {
+	captureLog(t)
+	called := false
+	r := newTestRouter(t, ModeStrict, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		created(w, r)
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/rides/4f9d4c1e-54c2-4c55-9ad2-29a4d0e1b0b7/requests",
+		strings.NewReader(`{"pickupLatitude": 200}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(rec, req)
+
+	if called {
+		t.Error("handler should not run for a non-conforming request")
+	}
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != problem.ContentType {
+		t.Fatalf("expected a 400 problem, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body problem.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != "contract_violation" || len(body.Errors) != 1 || body.Errors[0].Field != "pickupLatitude" {
+		t.Errorf("unexpected body %+v", body)
+	}
+}
+
+func TestLogModeLetsRequestsThrough(t *testing.T) {
+	logs := captureLog(t)
+	r := newTestRouter(t, ModeLog, func(w http.ResponseWriter, r *http.Request) {
+		// The body must still be readable after validation
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("error decoding body: %v", err)
+		}
+		created(w, r)
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/rides/not-a-uuid/requests", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rec.Code)
+	}
+	if !strings.Contains(logs.String(), "does not match the contract") {
+		t.Errorf("expected the violation to be logged, got %q", logs.String())
+	}
+}
+
+func TestResponseMismatchIsLogged(t *testing.T) {
+	logs := captureLog(t)
+	r := newTestRouter(t, ModeStrict, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"r1"}`))
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/rides/4f9d4c1e-54c2-4c55-9ad2-29a4d0e1b0b7/requests",
+		strings.NewReader(`{"pickupLatitude": 30}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != `{"id":"r1"}` {
+		t.Errorf("response should pass through unchanged, got %d %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(logs.String(), "Response does not match the contract status=201") {
+		t.Errorf("expected the response mismatch to be logged, got %q", logs.String())
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/audit"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListOwnHistory returns the change history of the caller's own entities
+func (h *AuditHandler) ListOwnHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	filter, limit, offset, ok := parseAuditQuery(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.auditService.ListOwnHistory(r.Context(), userID, filter, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching audit history", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// ListHistory returns the whole audit log for admins, optionally narrowed to
+// one actor or owner
+func (h *AuditHandler) ListHistory(w http.ResponseWriter, r *http.Request) {
+	filter, limit, offset, ok := parseAuditQuery(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	if actorStr := query.Get("actorId"); actorStr != "" {
+		actorID, err := uuid.Parse(actorStr)
+		if err != nil {
+			problem.InvalidParam(w, r, "actorId", "Invalid actor ID")
+			return
+		}
+		filter.ActorID = actorID
+	}
+	if ownerStr := query.Get("ownerId"); ownerStr != "" {
+		ownerID, err := uuid.Parse(ownerStr)
+		if err != nil {
+			problem.InvalidParam(w, r, "ownerId", "Invalid owner ID")
+			return
+		}
+		filter.OwnerID = ownerID
+	}
+
+	entries, err := h.auditService.ListHistory(r.Context(), filter, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching audit log", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// parseAuditQuery reads the entity filter and page shared by both audit
+// endpoints, writing a problem response when a parameter is invalid
+func parseAuditQuery(w http.ResponseWriter, r *http.Request) (models.AuditFilter, int, int, bool) {
+	query := r.URL.Query()
+	var filter models.AuditFilter
+
+	switch entityType := query.Get("entityType"); entityType {
+	case "", audit.EntityRide, audit.EntityRideRequest, audit.EntityVehicle, audit.EntityUser, audit.EntityCommunityProfile, audit.EntityDocument:
+		filter.EntityType = entityType
+	default:
+		problem.InvalidParam(w, r, "entityType", "Invalid entity type")
+		return filter, 0, 0, false
+	}
+
+	if entityStr := query.Get("entityId"); entityStr != "" {
+		entityID, err := uuid.Parse(entityStr)
+		if err != nil {
+			problem.InvalidParam(w, r, "entityId", "Invalid entity ID")
+			return filter, 0, 0, false
+		}
+		filter.EntityID = entityID.String()
+	}
+
+	limit := 50
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > 200 {
+			problem.InvalidParam(w, r, "limit", "Invalid limit")
+			return filter, 0, 0, false
+		}
+		limit = parsed
+	}
+
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			problem.InvalidParam(w, r, "offset", "Invalid offset")
+			return filter, 0, 0, false
+		}
+		offset = parsed
+	}
+
+	return filter, limit, offset, true
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+type BlockHandler struct {
+	blockService *service.BlockService
+}
+
+func NewBlockHandler(blockService *service.BlockService) *BlockHandler {
+	return &BlockHandler{blockService: blockService}
+}
+
+// BlockUser handles blocking another user
+func (h *BlockHandler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	blockedID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid user ID")
+		return
+	}
+
+	// The body is optional, it only carries a private reason
+	var req models.BlockUserRequest
+	if r.ContentLength > 0 {
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+	}
+
+	block, err := h.blockService.BlockUser(r.Context(), userID, blockedID, req.Reason)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error blocking user", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(block)
+}
+
+// UnblockUser handles removing a block
+func (h *BlockHandler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	blockedID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid user ID")
+		return
+	}
+
+	if err := h.blockService.UnblockUser(r.Context(), userID, blockedID); err != nil {
+		logging.FromContext(r.Context()).Error("Error unblocking user", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListBlockedUsers returns the users blocked by the authenticated user
+func (h *BlockHandler) ListBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	blocks, err := h.blockService.ListBlockedUsers(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching blocked users", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blocks)
+}
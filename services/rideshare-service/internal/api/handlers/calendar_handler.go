@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/ical"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+type CalendarHandler struct {
+	calendarService *service.CalendarService
+}
+
+func NewCalendarHandler(calendarService *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService}
+}
+
+// GetFeed returns the URL of the authenticated user's calendar feed
+func (h *CalendarHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	feed, err := h.calendarService.GetFeed(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching calendar feed", "userID", userID, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feed)
+}
+
+// RotateFeed gives the authenticated user a new feed URL, for when the old
+// one has been shared by mistake
+func (h *CalendarHandler) RotateFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	feed, err := h.calendarService.RotateFeed(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error rotating calendar feed", "userID", userID, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	// The URL carries the feed secret
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(feed)
+}
+
+// Feed serves a calendar feed. Calendar apps cannot send credentials, so the
+// token in the URL is what authorizes the request.
+func (h *CalendarHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	cal, err := h.calendarService.Feed(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error building calendar feed", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	writeCalendar(w, r, cal)
+}
+
+// DownloadRide returns a ride the authenticated user takes part in as an
+// .ics file
+func (h *CalendarHandler) DownloadRide(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	rideID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid ride ID")
+		return
+	}
+
+	cal, err := h.calendarService.RideCalendar(r.Context(), userID, rideID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error building calendar of ride", "rideID", rideID, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="ride-`+rideID.String()+`.ics"`)
+	writeCalendar(w, r, cal)
+}
+
+func writeCalendar(w http.ResponseWriter, r *http.Request, cal *ical.Calendar) {
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if err := ical.Write(w, *cal); err != nil {
+		logging.FromContext(r.Context()).Error("Error writing calendar", "error", err)
+	}
+}
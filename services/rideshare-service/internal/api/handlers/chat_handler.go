@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/chat"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+const (
+	// Time allowed to write a frame to the client
+	wsWriteWait = 10 * time.Second
+	// Time allowed between pongs before the connection is considered dead
+	wsPongWait = 60 * time.Second
+	// Pings are sent a little more often than wsPongWait
+	wsPingPeriod = (wsPongWait * 9) / 10
+	// Largest frame accepted from a client
+	wsMaxFrameSize = 8192
+)
+
+var errUnknownFrame = apperror.Invalid("type", "unknown_frame", "unknown frame type")
+
+type ChatHandler struct {
+	chatService     *service.ChatService
+	presenceService *service.PresenceService
+	upgrader        websocket.Upgrader
+}
+
+// NewChatHandler creates a ChatHandler whose sockets may be opened from
+// pages on allowedOrigins, as well as from the service's own origin
+func NewChatHandler(chatService *service.ChatService, presenceService *service.PresenceService, allowedOrigins []string) *ChatHandler {
+	return &ChatHandler{
+		chatService:     chatService,
+		presenceService: presenceService,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(allowedOrigins),
+		},
+	}
+}
+
+// checkOrigin accepts handshakes without an Origin, which browsers always
+// send and mobile clients do not, and those from the allowed origins or
+// the request's own host. Without it any website could open a socket with
+// a token taken from the query string.
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if allowed[strings.ToLower(origin)] {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// chatFrame is a message sent by a WebSocket client
+type chatFrame struct {
+	Type      string    `json:"type"`
+	Body      string    `json:"body,omitempty"`
+	MessageID uuid.UUID `json:"messageId,omitempty"`
+}
+
+// chatErrorFrame is sent to a WebSocket client when one of its frames fails.
+// Code matches the code of the equivalent HTTP problem response.
+type chatErrorFrame struct {
+	Type  string `json:"type"`
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// ListMessages handles fetching a page of messages; also used for HTTP polling
+func (h *ChatHandler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	userID, rideID, ok := chatRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			problem.InvalidParam(w, r, "limit", "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	page, err := h.chatService.ListMessages(r.Context(), rideID, userID, query.Get("before"), query.Get("after"), limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error getting messages", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// SendMessage handles posting a message over HTTP
+func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	userID, rideID, ok := chatRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SendChatMessageRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	message, err := h.chatService.SendMessage(r.Context(), rideID, userID, req.Body)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error sending message", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(message)
+}
+
+// MarkRead handles updating the caller's read receipt
+func (h *ChatHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, rideID, ok := chatRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MarkChatReadRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	receipt, err := h.chatService.MarkRead(r.Context(), rideID, userID, req.MessageID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error marking messages as read", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipt)
+}
+
+// ListReadReceipts returns the read position of every participant
+func (h *ChatHandler) ListReadReceipts(w http.ResponseWriter, r *http.Request) {
+	userID, rideID, ok := chatRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	receipts, err := h.chatService.ListReadReceipts(r.Context(), rideID, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error getting read receipts", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipts)
+}
+
+// Connect upgrades the request to a WebSocket that streams new messages and
+// read receipts. Clients may also send "message" and "read" frames over it.
+func (h *ChatHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	userID, rideID, ok := chatRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Check access before upgrading so failures get a normal HTTP status
+	if _, err := h.chatService.CheckAccess(r.Context(), rideID, userID); err != nil {
+		logging.FromContext(r.Context()).Error("Error opening chat", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		logging.FromContext(r.Context()).Error("Error upgrading chat connection", "error", err)
+		return
+	}
+
+	sub := h.chatService.Hub().Subscribe(rideID)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// An open chat connection counts as being online
+	h.touchPresence(ctx, userID)
+
+	// Only writePump may write to the connection, so replies go through it
+	replies := make(chan chatErrorFrame, 8)
+
+	go h.writePump(ctx, conn, sub, replies, cancel)
+	h.readPump(ctx, conn, rideID, userID, replies)
+
+	cancel()
+	sub.Close()
+}
+
+// touchPresence records a heartbeat; failures are logged and otherwise ignored
+func (h *ChatHandler) touchPresence(ctx context.Context, userID uuid.UUID) {
+	if err := h.presenceService.Heartbeat(ctx, userID); err != nil {
+		logging.FromContext(ctx).Error("Error recording heartbeat", "userID", userID, "error", err)
+	}
+}
+
+// readPump handles frames sent by the client until the connection closes
+func (h *ChatHandler) readPump(ctx context.Context, conn *websocket.Conn, rideID, userID uuid.UUID, replies chan<- chatErrorFrame) {
+	conn.SetReadLimit(wsMaxFrameSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		h.touchPresence(ctx, userID)
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var frame chatFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logging.FromContext(ctx).Warn("Chat connection closed unexpectedly", "rideID", rideID, "error", err)
+			}
+			return
+		}
+
+		var err error
+		switch frame.Type {
+		case chat.EventMessage:
+			_, err = h.chatService.SendMessage(ctx, rideID, userID, frame.Body)
+		case chat.EventRead:
+			_, err = h.chatService.MarkRead(ctx, rideID, userID, frame.MessageID)
+		default:
+			err = errUnknownFrame
+		}
+
+		// Successful frames are echoed back through the hub
+		if err != nil {
+			select {
+			case replies <- newChatErrorFrame(err):
+			default:
+			}
+		}
+	}
+}
+
+// writePump pushes hub events, error replies and keepalive pings to the client
+func (h *ChatHandler) writePump(ctx context.Context, conn *websocket.Conn, sub *chat.Subscription, replies <-chan chatErrorFrame, cancel context.CancelFunc) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		cancel()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				if ctx.Err() == nil {
+					// Dropped by the hub for falling behind
+					conn.WriteMessage(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"))
+				}
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case reply := <-replies:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(reply); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// chatRequestIDs extracts the authenticated user and the ride from the request
+func chatRequestIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	rideID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid ride ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, rideID, true
+}
+
+func newChatErrorFrame(err error) chatErrorFrame {
+	p := problem.FromError(err)
+	return chatErrorFrame{Type: "error", Code: p.Code, Error: p.Detail}
+}
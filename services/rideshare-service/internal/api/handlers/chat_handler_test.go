@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	check := checkOrigin([]string{"https://app.example.com/"})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true}, // Native clients send no Origin
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"http://rideshare.local", true}, // The service's own origin
+		{"https://evil.example.com", false},
+		{"http://app.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://rideshare.local/api/rides/x/chat/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := check(r); got != tt.want {
+			t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+type CommunityHandler struct {
+	communityService *service.CommunityService
+}
+
+func NewCommunityHandler(communityService *service.CommunityService) *CommunityHandler {
+	return &CommunityHandler{communityService: communityService}
+}
+
+// GetProfile returns the authenticated user's community profile
+func (h *CommunityHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	profile, err := h.communityService.GetProfile(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching community profile", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// UpdateProfile replaces the authenticated user's community attributes and
+// matching preferences
+func (h *CommunityHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	var req models.UpdateCommunityProfileRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	profile, err := h.communityService.UpdateProfile(r.Context(), userID, &req)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error updating community profile", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// VerifyProfile lets an admin confirm a user's community attributes
+func (h *CommunityHandler) VerifyProfile(w http.ResponseWriter, r *http.Request) {
+	adminID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	userID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid user ID")
+		return
+	}
+
+	profile, err := h.communityService.VerifyProfile(r.Context(), adminID, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error verifying community profile", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+// multipartOverhead allows for the form fields and part headers around an
+// uploaded file
+const multipartOverhead = 64 << 10
+
+type DocumentHandler struct {
+	documentService *service.DocumentService
+	maxUpload       int64
+}
+
+// NewDocumentHandler creates a DocumentHandler that refuses request bodies
+// much larger than maxUpload bytes
+func NewDocumentHandler(documentService *service.DocumentService, maxUpload int64) *DocumentHandler {
+	return &DocumentHandler{documentService: documentService, maxUpload: maxUpload}
+}
+
+// UploadDriverDocument uploads the authenticated user's driver's license
+func (h *DocumentHandler) UploadDriverDocument(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, nil)
+}
+
+// UploadVehicleDocument uploads a registration or insurance document for one
+// of the authenticated user's vehicles
+func (h *DocumentHandler) UploadVehicleDocument(w http.ResponseWriter, r *http.Request) {
+	vehicleID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid vehicle ID")
+		return
+	}
+	h.upload(w, r, &vehicleID)
+}
+
+// upload reads a multipart form with type, expiresOn and file fields
+func (h *DocumentHandler) upload(w http.ResponseWriter, r *http.Request, vehicleID *uuid.UUID) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUpload+multipartOverhead)
+	if err := r.ParseMultipartForm(h.maxUpload); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Error(w, r, apperror.Invalid("file", "too_large", "file cannot exceed "+strconv.FormatInt(h.maxUpload, 10)+" bytes"))
+			return
+		}
+		problem.InvalidParam(w, r, "file", "Request must be a multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		problem.Error(w, r, apperror.Invalid("file", "required", "file is required"))
+		return
+	}
+	defer file.Close()
+
+	doc, err := h.documentService.UploadDocument(r.Context(), userID, vehicleID, &service.DocumentUpload{
+		Type:      r.FormValue("type"),
+		ExpiresOn: r.FormValue("expiresOn"),
+		FileName:  header.Filename,
+		File:      file,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error uploading document", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(doc)
+}
+
+// ListOwnDocuments returns the authenticated user's documents
+func (h *DocumentHandler) ListOwnDocuments(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	docs, err := h.documentService.ListOwnDocuments(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing documents", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(docs)
+}
+
+// DownloadOwnDocument returns the file of one of the authenticated user's documents
+func (h *DocumentHandler) DownloadOwnDocument(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+	h.download(w, r, &userID)
+}
+
+// DownloadDocument returns the file of any document, for admins reviewing it
+func (h *DocumentHandler) DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	h.download(w, r, nil)
+}
+
+func (h *DocumentHandler) download(w http.ResponseWriter, r *http.Request, ownerID *uuid.UUID) {
+	documentID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid document ID")
+		return
+	}
+
+	doc, file, err := h.documentService.OpenDocument(r.Context(), ownerID, documentID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error opening document", "documentID", documentID, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	// Uploads are served as downloads so browsers never render them in our origin
+	w.Header().Set("Content-Type", doc.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": doc.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := io.Copy(w, file); err != nil {
+		logging.FromContext(r.Context()).Error("Error sending document", "documentID", documentID, "error", err)
+	}
+}
+
+// ListDocuments returns the admin review queue
+func (h *DocumentHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := query.Get("status")
+
+	limit := 50
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > 200 {
+			problem.InvalidParam(w, r, "limit", "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			problem.InvalidParam(w, r, "offset", "Invalid offset")
+			return
+		}
+		offset = parsed
+	}
+
+	docs, err := h.documentService.ListDocuments(r.Context(), status, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching documents", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(docs)
+}
+
+// ReviewDocument records an admin decision on a document
+func (h *DocumentHandler) ReviewDocument(w http.ResponseWriter, r *http.Request) {
+	adminID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	documentID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid document ID")
+		return
+	}
+
+	var req models.ReviewDocumentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	doc, err := h.documentService.ReviewDocument(r.Context(), adminID, documentID, &req)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reviewing document", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/fare"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+// Seats priced when neither seats nor a vehicle is given
+const defaultFareSeats = 4
+
+type FareHandler struct {
+	fares          fare.Model
+	vehicleService *service.VehicleService
+}
+
+func NewFareHandler(fares fare.Model, vehicleService *service.VehicleService) *FareHandler {
+	return &FareHandler{fares: fares, vehicleService: vehicleService}
+}
+
+// Estimate prices a route so hosts can see the suggested and maximum price
+// per seat before offering a ride
+func (h *FareHandler) Estimate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	originLat, ok := coordinateParam(w, r, "originLat", 90)
+	if !ok {
+		return
+	}
+	originLon, ok := coordinateParam(w, r, "originLon", 180)
+	if !ok {
+		return
+	}
+	destLat, ok := coordinateParam(w, r, "destLat", 90)
+	if !ok {
+		return
+	}
+	destLon, ok := coordinateParam(w, r, "destLon", 180)
+	if !ok {
+		return
+	}
+
+	seats := defaultFareSeats
+	if seatsStr := query.Get("seats"); seatsStr != "" {
+		var err error
+		seats, err = strconv.Atoi(seatsStr)
+		if err != nil || seats < 1 || seats > 15 {
+			problem.InvalidParam(w, r, "seats", "Invalid number of seats")
+			return
+		}
+	} else if vehicleIDStr := query.Get("vehicleId"); vehicleIDStr != "" {
+		vehicleID, err := uuid.Parse(vehicleIDStr)
+		if err != nil {
+			problem.InvalidParam(w, r, "vehicleId", "Invalid vehicle ID")
+			return
+		}
+		vehicle, err := h.vehicleService.GetVehicleByID(r.Context(), vehicleID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error getting vehicle for fare estimate", "vehicleID", vehicleID, "error", err)
+			problem.Error(w, r, err)
+			return
+		}
+		seats = vehicle.Capacity
+	}
+
+	estimate := h.fares.Estimate(h.fares.RoadKm(originLat, originLon, destLat, destLon), seats)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(estimate)
+}
+
+func coordinateParam(w http.ResponseWriter, r *http.Request, name string, limit float64) (float64, bool) {
+	value, err := strconv.ParseFloat(r.URL.Query().Get(name), 64)
+	if err != nil || value < -limit || value > limit {
+		problem.InvalidParam(w, r, name, "Invalid "+name)
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/impact"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/gorilla/mux"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+type ImpactHandler struct {
+	impactService *service.ImpactService
+}
+
+func NewImpactHandler(impactService *service.ImpactService) *ImpactHandler {
+	return &ImpactHandler{impactService: impactService}
+}
+
+// GetOwnImpact returns the authenticated user's impact as a passenger and as
+// a host
+func (h *ImpactHandler) GetOwnImpact(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+	window, ok := impactWindow(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.impactService.UserImpact(r.Context(), userID, window)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching impact of user", "userID", userID, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetCampusImpact returns the impact of a university's verified members
+func (h *ImpactHandler) GetCampusImpact(w http.ResponseWriter, r *http.Request) {
+	university := mux.Vars(r)["university"]
+	window, ok := impactWindow(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.impactService.CampusImpact(r.Context(), university, window)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching impact of campus", "university", university, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetLeaderboard ranks a university's verified members by the emissions they
+// helped avoid
+func (h *ImpactHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	university := mux.Vars(r)["university"]
+	window, ok := impactWindow(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > 100 {
+			problem.InvalidParam(w, r, "limit", "Invalid limit")
+			return
+		}
+	}
+
+	board, err := h.impactService.Leaderboard(r.Context(), university, window, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching leaderboard of campus", "university", university, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+func impactWindow(w http.ResponseWriter, r *http.Request) (impact.Window, bool) {
+	query := r.URL.Query()
+	window, err := impact.ParseWindow(query.Get("period"), query.Get("interval"), time.Now())
+	if err != nil {
+		problem.Error(w, r, err)
+		return impact.Window{}, false
+	}
+	return window, true
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/geocode"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+// Largest radius a reverse geocoding request may search, in meters
+const maxReverseRadius = 50000
+
+type PlaceHandler struct {
+	geocoder geocode.Geocoder
+}
+
+func NewPlaceHandler(geocoder geocode.Geocoder) *PlaceHandler {
+	return &PlaceHandler{geocoder: geocoder}
+}
+
+// Geocode returns the places best matching an address or place name
+func (h *PlaceHandler) Geocode(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		problem.InvalidParam(w, r, "q", "Query is required")
+		return
+	}
+	limit, ok := placeLimit(w, r)
+	if !ok {
+		return
+	}
+
+	places, err := h.geocoder.Forward(r.Context(), query, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error geocoding", "query", query, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(places)
+}
+
+// Reverse returns the places nearest to a point
+func (h *PlaceHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lat, err := strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		problem.InvalidParam(w, r, "lat", "Invalid latitude")
+		return
+	}
+	lon, err := strconv.ParseFloat(query.Get("lon"), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		problem.InvalidParam(w, r, "lon", "Invalid longitude")
+		return
+	}
+
+	radius := 500.0
+	if radiusStr := query.Get("radius"); radiusStr != "" {
+		radius, err = strconv.ParseFloat(radiusStr, 64)
+		if err != nil || radius <= 0 || radius > maxReverseRadius {
+			problem.InvalidParam(w, r, "radius", "Invalid radius")
+			return
+		}
+	}
+	limit, ok := placeLimit(w, r)
+	if !ok {
+		return
+	}
+
+	matches, err := h.geocoder.Reverse(r.Context(), lat, lon, radius, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reverse geocoding", "lat", lat, "lon", lon, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(matches)
+}
+
+// Autocomplete suggests places as the user types
+func (h *PlaceHandler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("q")
+	if prefix == "" {
+		problem.InvalidParam(w, r, "q", "Query is required")
+		return
+	}
+	limit, ok := placeLimit(w, r)
+	if !ok {
+		return
+	}
+
+	places, err := h.geocoder.Autocomplete(r.Context(), prefix, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error autocompleting", "prefix", prefix, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(places)
+}
+
+func placeLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return geocode.DefaultLimit, true
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > 50 {
+		problem.InvalidParam(w, r, "limit", "Invalid limit")
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+type PresenceHandler struct {
+	presenceService *service.PresenceService
+}
+
+func NewPresenceHandler(presenceService *service.PresenceService) *PresenceHandler {
+	return &PresenceHandler{presenceService: presenceService}
+}
+
+// Heartbeat marks the caller as online. Clients without an open chat
+// WebSocket should call this more often than the presence TTL.
+func (h *PresenceHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	if err := h.presenceService.Heartbeat(r.Context(), userID); err != nil {
+		logging.FromContext(r.Context()).Error("Error recording heartbeat", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Disconnect marks the caller as offline, e.g. when the app is backgrounded
+func (h *PresenceHandler) Disconnect(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	if err := h.presenceService.Disconnect(r.Context(), userID); err != nil {
+		logging.FromContext(r.Context()).Error("Error clearing presence", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Lookup returns the presence of a comma-separated list of users the caller
+// shares a ride with
+func (h *PresenceHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	callerID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	raw := r.URL.Query().Get("userIds")
+	if raw == "" {
+		problem.InvalidParam(w, r, "userIds", "userIds is required")
+		return
+	}
+
+	var userIDs []uuid.UUID
+	for _, part := range strings.Split(raw, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			problem.InvalidParam(w, r, "userIds", "Invalid user ID: "+part)
+			return
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	statuses, err := h.presenceService.Lookup(r.Context(), callerID, userIDs)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error looking up presence", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
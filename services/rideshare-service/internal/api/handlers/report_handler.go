@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+func NewReportHandler(reportService *service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// CreateReport handles filing an abuse report
+func (h *ReportHandler) CreateReport(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	var req models.CreateReportRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	report, err := h.reportService.CreateReport(r.Context(), userID, &req)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error creating report", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// ListReports returns the admin review queue
+func (h *ReportHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := query.Get("status")
+
+	limit := 50
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > 200 {
+			problem.InvalidParam(w, r, "limit", "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			problem.InvalidParam(w, r, "offset", "Invalid offset")
+			return
+		}
+		offset = parsed
+	}
+
+	reports, err := h.reportService.ListReports(r.Context(), status, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching reports", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// ReviewReport records an admin decision on a report
+func (h *ReportHandler) ReviewReport(w http.ResponseWriter, r *http.Request) {
+	adminID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	reportID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid report ID")
+		return
+	}
+
+	var req models.ReviewReportRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	report, err := h.reportService.ReviewReport(r.Context(), adminID, reportID, &req)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reviewing report", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
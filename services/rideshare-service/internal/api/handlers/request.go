@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/validation"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+// decodeRequest decodes the JSON body into dst and validates it against its
+// struct tags. On failure it writes the problem response and returns false.
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		logging.FromContext(r.Context()).Error("Error decoding request body", "error", err)
+		problem.InvalidBody(w, r)
+		return false
+	}
+	return validRequest(w, r, dst)
+}
+
+// validRequest validates an already decoded request
+func validRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := validation.Struct(req); err != nil {
+		problem.Error(w, r, err)
+		return false
+	}
+	return true
+}
@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,19 +10,25 @@ import (
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/timezone"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+	"platform/shared/problem"
 )
 
 type RideHandler struct {
-    rideService    *service.RideService
-    vehicleService *service.VehicleService
+    rideService     *service.RideService
+    vehicleService  *service.VehicleService
+    presenceService *service.PresenceService
 }
 
-func NewRideHandler(rideService *service.RideService, vehicleService *service.VehicleService) *RideHandler {
+func NewRideHandler(rideService *service.RideService, vehicleService *service.VehicleService, presenceService *service.PresenceService) *RideHandler {
     return &RideHandler{
         rideService: rideService,
         vehicleService: vehicleService,
+        presenceService: presenceService,
     }
 }
 
@@ -31,38 +37,39 @@ func (h *RideHandler) CreateRide(w http.ResponseWriter, r *http.Request) {
     // Get user ID from context (set by auth middleware)
     userID, err := middleware.GetUserIDFromContext(r.Context())
     if err != nil {
-        log.Printf("Error getting user ID from context: %v", err)
-        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+        problem.Unauthorized(w, r)
         return
     }
 
     // Parse request body
     var req models.CreateRideRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        log.Printf("Error decoding request body: %v", err)
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
+    if !decodeRequest(w, r, &req) {
         return
     }
     
     // Validate vehicle ownership
     vehicle, err := h.vehicleService.GetVehicleByID(r.Context(), req.VehicleID)
     if err != nil {
-        log.Printf("Error fetching vehicle: %v", err)
-        http.Error(w, "Failed to validate vehicle", http.StatusBadRequest)
+        logging.FromContext(r.Context()).Error("Error fetching vehicle", "error", err)
+        if errors.Is(err, models.ErrVehicleNotFound) {
+            err = apperror.Invalid("vehicleId", "vehicle_not_found", "vehicle not found")
+        }
+        problem.Error(w, r, err)
         return
     }
     
     if vehicle.UserID != userID {
-        log.Printf("Vehicle %s does not belong to user %s", req.VehicleID, userID)
-        http.Error(w, "Vehicle does not belong to authenticated user", http.StatusForbidden)
+        logging.FromContext(r.Context()).Warn("Vehicle does not belong to user", "vehicleID", req.VehicleID, "userID", userID)
+        problem.Error(w, r, apperror.Forbidden("vehicle_not_owned", "vehicle does not belong to authenticated user"))
         return
     }
 
     // Create ride using service
     ride, err := h.rideService.CreateRide(r.Context(), userID, &req)
     if err != nil {
-        log.Printf("Error creating ride: %v", err)
-        http.Error(w, "Failed to create ride: "+err.Error(), http.StatusInternalServerError)
+        logging.FromContext(r.Context()).Error("Error creating ride", "error", err)
+        problem.Error(w, r, err)
         return
     }
 
@@ -82,21 +89,21 @@ func (h *RideHandler) GetRide(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     rideIDStr, ok := vars["id"]
     if !ok {
-        http.Error(w, "Missing ride ID", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "id", "Missing ride ID")
         return
     }
 
     rideID, err := uuid.Parse(rideIDStr)
     if err != nil {
-        http.Error(w, "Invalid ride ID format", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "id", "Invalid ride ID format")
         return
     }
 
     // Get ride using service
     ride, err := h.rideService.GetRide(r.Context(), rideID)
     if err != nil {
-        log.Printf("Error fetching ride: %v", err)
-        http.Error(w, "Failed to get ride: "+err.Error(), http.StatusNotFound)
+        logging.FromContext(r.Context()).Error("Error fetching ride", "error", err)
+        problem.Error(w, r, err)
         return
     }
 
@@ -119,32 +126,32 @@ func (h *RideHandler) FindNearbyRides(w http.ResponseWriter, r *http.Request) {
     
     // Validate required parameters
     if latStr == "" || lonStr == "" || destLatStr == "" || destLonStr == "" {
-        http.Error(w, "Missing required location parameters", http.StatusBadRequest)
+        problem.Error(w, r, apperror.Validation("missing_parameters", "lat, lon, destLat and destLon are required"))
         return
     }
     
     // Parse location parameters
     lat, err := strconv.ParseFloat(latStr, 64)
     if err != nil {
-        http.Error(w, "Invalid latitude format", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "lat", "Invalid latitude format")
         return
     }
     
     lon, err := strconv.ParseFloat(lonStr, 64)
     if err != nil {
-        http.Error(w, "Invalid longitude format", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "lon", "Invalid longitude format")
         return
     }
     
     destLat, err := strconv.ParseFloat(destLatStr, 64)
     if err != nil {
-        http.Error(w, "Invalid destination latitude format", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "destLat", "Invalid destination latitude format")
         return
     }
     
     destLon, err := strconv.ParseFloat(destLonStr, 64)
     if err != nil {
-        http.Error(w, "Invalid destination longitude format", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "destLon", "Invalid destination longitude format")
         return
     }
     
@@ -153,7 +160,7 @@ func (h *RideHandler) FindNearbyRides(w http.ResponseWriter, r *http.Request) {
     if radiusStr != "" {
         radius, err = strconv.ParseFloat(radiusStr, 64)
         if err != nil {
-            http.Error(w, "Invalid radius format", http.StatusBadRequest)
+            problem.InvalidParam(w, r, "radius", "Invalid radius format")
             return
         }
         
@@ -168,17 +175,31 @@ func (h *RideHandler) FindNearbyRides(w http.ResponseWriter, r *http.Request) {
     if departureAfterStr != "" {
         departureAfter, err = time.Parse(time.RFC3339, departureAfterStr)
         if err != nil {
-            http.Error(w, "Invalid departure time format", http.StatusBadRequest)
+            problem.InvalidParam(w, r, "departureAfter", "Invalid departure time format")
             return
         }
     }
     
+    // A day or part of day is read on the caller's local calendar
+    var window *models.DepartureWindow
+    if day, partOfDay := query.Get("day"), query.Get("partOfDay"); day != "" || partOfDay != "" {
+        zone := query.Get("timeZone")
+        if zone != "" && !timezone.Valid(zone) {
+            problem.InvalidParam(w, r, "timeZone", "Unknown time zone")
+            return
+        }
+        window = &models.DepartureWindow{Day: day, PartOfDay: partOfDay, TimeZone: zone}
+    }
+
+    // Anonymous callers get uuid.Nil, which disables block filtering
+    viewerID, _ := middleware.GetUserIDFromContext(r.Context())
+
     // Find nearby rides using service
     rides, err := h.rideService.FindNearbyRides(
-        r.Context(), lat, lon, destLat, destLon, radius, departureAfter)
+        r.Context(), viewerID, lat, lon, destLat, destLon, radius, departureAfter, window)
     if err != nil {
-        log.Printf("Error finding nearby rides: %v", err)
-        http.Error(w, "Failed to find nearby rides", http.StatusInternalServerError)
+        logging.FromContext(r.Context()).Error("Error finding nearby rides", "error", err)
+        problem.Error(w, r, err)
         return
     }
     
@@ -192,8 +213,8 @@ func (h *RideHandler) CancelRide(w http.ResponseWriter, r *http.Request) {
     // Get user ID from context
     userID, err := middleware.GetUserIDFromContext(r.Context())
     if err != nil {
-        log.Printf("Error getting user ID from context: %v", err)
-        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+        problem.Unauthorized(w, r)
         return
     }
 
@@ -201,26 +222,185 @@ func (h *RideHandler) CancelRide(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     rideIDStr, ok := vars["id"]
     if !ok {
-        http.Error(w, "Missing ride ID", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "id", "Missing ride ID")
         return
     }
 
     rideID, err := uuid.Parse(rideIDStr)
     if err != nil {
-        http.Error(w, "Invalid ride ID", http.StatusBadRequest)
+        problem.InvalidParam(w, r, "id", "Invalid ride ID")
         return
     }
 
     // Cancel the ride
     if err := h.rideService.CancelRide(r.Context(), userID, rideID); err != nil {
-        log.Printf("Error cancelling ride: %v", err)
-        http.Error(w, "Failed to cancel ride: "+err.Error(), http.StatusInternalServerError)
+        logging.FromContext(r.Context()).Error("Error cancelling ride", "error", err)
+        problem.Error(w, r, err)
         return
     }
 
     // Return success response
+    w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(http.StatusOK)
     json.NewEncoder(w).Encode(map[string]string{
         "status": "cancelled",
     })
+}
+
+// CompleteRide handles a host marking a ride as completed
+func (h *RideHandler) CompleteRide(w http.ResponseWriter, r *http.Request) {
+    userID, err := middleware.GetUserIDFromContext(r.Context())
+    if err != nil {
+        logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+        problem.Unauthorized(w, r)
+        return
+    }
+
+    rideID, err := uuid.Parse(mux.Vars(r)["id"])
+    if err != nil {
+        problem.InvalidParam(w, r, "id", "Invalid ride ID")
+        return
+    }
+
+    ride, err := h.rideService.CompleteRide(r.Context(), userID, rideID)
+    if err != nil {
+        logging.FromContext(r.Context()).Error("Error completing ride", "error", err)
+        problem.Error(w, r, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(ride)
+}
+
+// UpdateRide handles a host changing the time, price or description of a ride
+func (h *RideHandler) UpdateRide(w http.ResponseWriter, r *http.Request) {
+    userID, err := middleware.GetUserIDFromContext(r.Context())
+    if err != nil {
+        logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+        problem.Unauthorized(w, r)
+        return
+    }
+
+    rideID, err := uuid.Parse(mux.Vars(r)["id"])
+    if err != nil {
+        problem.InvalidParam(w, r, "id", "Invalid ride ID")
+        return
+    }
+
+    var req models.UpdateRideRequest
+    if !decodeRequest(w, r, &req) {
+        return
+    }
+
+    ride, err := h.rideService.UpdateRide(r.Context(), userID, rideID, &req)
+    if err != nil {
+        logging.FromContext(r.Context()).Error("Error updating ride", "error", err)
+        problem.Error(w, r, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(ride)
+}
+
+// RequestToJoin handles a rider asking to join a ride
+func (h *RideHandler) RequestToJoin(w http.ResponseWriter, r *http.Request) {
+    userID, err := middleware.GetUserIDFromContext(r.Context())
+    if err != nil {
+        logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+        problem.Unauthorized(w, r)
+        return
+    }
+
+    rideID, err := uuid.Parse(mux.Vars(r)["id"])
+    if err != nil {
+        problem.InvalidParam(w, r, "id", "Invalid ride ID")
+        return
+    }
+
+    var req models.JoinRideRequest
+    if !decodeRequest(w, r, &req) {
+        return
+    }
+
+    request, err := h.rideService.RequestToJoin(r.Context(), userID, rideID, &req)
+    if err != nil {
+        logging.FromContext(r.Context()).Error("Error requesting to join ride", "error", err)
+        problem.Error(w, r, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(request)
+}
+
+// ListRideRequests returns the join requests for a ride the caller hosts
+func (h *RideHandler) ListRideRequests(w http.ResponseWriter, r *http.Request) {
+    userID, err := middleware.GetUserIDFromContext(r.Context())
+    if err != nil {
+        logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+        problem.Unauthorized(w, r)
+        return
+    }
+
+    rideID, err := uuid.Parse(mux.Vars(r)["id"])
+    if err != nil {
+        problem.InvalidParam(w, r, "id", "Invalid ride ID")
+        return
+    }
+
+    requests, err := h.rideService.ListRideRequests(r.Context(), userID, rideID)
+    if err != nil {
+        logging.FromContext(r.Context()).Error("Error fetching ride requests", "error", err)
+        problem.Error(w, r, err)
+        return
+    }
+
+    // Let the host see which riders are currently active
+    if err := h.presenceService.AttachRiderPresence(r.Context(), requests); err != nil {
+        logging.FromContext(r.Context()).Error("Error looking up rider presence", "error", err)
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(requests)
+}
+
+// DecideRideRequest handles the host accepting or rejecting a join request
+func (h *RideHandler) DecideRideRequest(w http.ResponseWriter, r *http.Request) {
+    userID, err := middleware.GetUserIDFromContext(r.Context())
+    if err != nil {
+        logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+        problem.Unauthorized(w, r)
+        return
+    }
+
+    vars := mux.Vars(r)
+    rideID, err := uuid.Parse(vars["id"])
+    if err != nil {
+        problem.InvalidParam(w, r, "id", "Invalid ride ID")
+        return
+    }
+
+    requestID, err := uuid.Parse(vars["requestId"])
+    if err != nil {
+        problem.InvalidParam(w, r, "requestId", "Invalid request ID")
+        return
+    }
+
+    var req models.DecideRideRequest
+    if !decodeRequest(w, r, &req) {
+        return
+    }
+
+    request, err := h.rideService.DecideRideRequest(r.Context(), userID, rideID, requestID, req.Status)
+    if err != nil {
+        logging.FromContext(r.Context()).Error("Error deciding ride request", "error", err)
+        problem.Error(w, r, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(request)
 }
\ No newline at end of file
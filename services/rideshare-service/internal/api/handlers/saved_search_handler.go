@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+type SavedSearchHandler struct {
+	savedSearchService *service.SavedSearchService
+}
+
+func NewSavedSearchHandler(savedSearchService *service.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{savedSearchService: savedSearchService}
+}
+
+// CreateSavedSearch saves a search the authenticated user is alerted about
+func (h *SavedSearchHandler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	var req models.SavedSearchRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	search, err := h.savedSearchService.CreateSavedSearch(r.Context(), userID, &req)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error saving search", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(search)
+}
+
+// ListSavedSearches returns the authenticated user's saved searches
+func (h *SavedSearchHandler) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	searches, err := h.savedSearchService.ListSavedSearches(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing saved searches", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(searches)
+}
+
+// GetSavedSearch returns one of the authenticated user's saved searches
+func (h *SavedSearchHandler) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, searchID, ok := savedSearchParams(w, r)
+	if !ok {
+		return
+	}
+
+	search, err := h.savedSearchService.GetSavedSearch(r.Context(), userID, searchID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching saved search", "searchID", searchID, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(search)
+}
+
+// UpdateSavedSearch replaces one of the authenticated user's saved searches
+func (h *SavedSearchHandler) UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, searchID, ok := savedSearchParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SavedSearchRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	search, err := h.savedSearchService.UpdateSavedSearch(r.Context(), userID, searchID, &req)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error updating saved search", "searchID", searchID, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(search)
+}
+
+// DeleteSavedSearch removes one of the authenticated user's saved searches
+func (h *SavedSearchHandler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, searchID, ok := savedSearchParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.savedSearchService.DeleteSavedSearch(r.Context(), userID, searchID); err != nil {
+		logging.FromContext(r.Context()).Error("Error deleting saved search", "searchID", searchID, "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func savedSearchParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	searchID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problem.InvalidParam(w, r, "id", "Invalid saved search ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, searchID, true
+}
@@ -2,13 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"time"
 
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"platform/shared/logging"
+	"platform/shared/problem"
 )
 
 type UserHandler struct {
@@ -21,70 +24,41 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 
 // RegisterRequest represents a request to register a new user
 type RegisterRequest struct {
-	Email       string `json:"email"`
-	Password    string `json:"password"`
-	FirstName   string `json:"firstName"`
-	LastName    string `json:"lastName"`
-	PhoneNumber string `json:"phoneNumber"`
-	DateOfBirth string `json:"dateOfBirth"` // ISO format: YYYY-MM-DD
+	Email       string `json:"email" validate:"required,email,max=255"`
+	Password    string `json:"password" validate:"min=8,max=72"` // bcrypt ignores bytes past 72
+	FirstName   string `json:"firstName" validate:"notblank,max=100"`
+	LastName    string `json:"lastName" validate:"notblank,max=100"`
+	PhoneNumber string `json:"phoneNumber" validate:"phone"`
+	DateOfBirth string `json:"dateOfBirth" validate:"adult"` // ISO format: YYYY-MM-DD
+	TimeZone    string `json:"timeZone,omitempty" validate:"omitempty,timezone"` // IANA zone, UTC when omitted
 }
 
 // LoginRequest represents a request to login
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// UpdateProfileRequest represents a user's changes to their own profile.
+// Omitted fields are left unchanged.
+type UpdateProfileRequest struct {
+	TimeZone *string `json:"timeZone,omitempty" validate:"omitempty,timezone"`
 }
 
 // RegisterUser handles user registration
 func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	// Check content type
 	contentType := r.Header.Get("Content-Type")
-	log.Printf("RegisterUser received Content-Type: %s", contentType)
+	logging.FromContext(r.Context()).Debug("RegisterUser received request", "contentType", contentType)
 	
 	// Parse request body
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding request body: %v", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Invalid request body: " + err.Error(),
-			"details": "Make sure you're sending properly formatted JSON with Content-Type: application/json",
-		})
-		return
-	}
-
-	// Validate required fields
-	if req.Email == "" || req.Password == "" || req.FirstName == "" || req.LastName == "" || req.PhoneNumber == "" || req.DateOfBirth == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Missing required fields",
-		})
-		return
-	}
-
-	// Parse date of birth
-	dob, err := time.Parse("2006-01-02", req.DateOfBirth)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Invalid date format for date of birth (use YYYY-MM-DD)",
-		})
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	// Check if user is at least 18 years old
-	minAge := 18
-	if time.Now().AddDate(-minAge, 0, 0).Before(dob) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "User must be at least 18 years old",
-		})
-		return
-	}
+	// Already checked by the adult rule
+	dob, _ := time.Parse("2006-01-02", req.DateOfBirth)
 
 	// Create user using service
 	user, err := h.userService.RegisterUser(
@@ -95,15 +69,12 @@ func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		req.LastName,
 		req.PhoneNumber,
 		dob,
+		req.TimeZone,
 	)
 
 	if err != nil {
-		log.Printf("Error registering user: %v", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Failed to register user: " + err.Error(),
-		})
+		logging.FromContext(r.Context()).Error("Error registering user", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -111,8 +82,10 @@ func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	// In production, we would use a proper JWT token
 	token := user.ID.String()
 
-	// Return successful response
+	// Return successful response. The token must not be cached, including
+	// by the idempotency middleware.
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
 	w.WriteHeader(http.StatusCreated)
 	
 	response := map[string]interface{}{
@@ -122,7 +95,7 @@ func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	
 	// Debug log
 	responseBytes, _ := json.Marshal(response)
-	log.Printf("RegisterUser response: %s", string(responseBytes))
+	logging.FromContext(r.Context()).Debug("RegisterUser response", "response", string(responseBytes))
 	
 	json.NewEncoder(w).Encode(response)
 }
@@ -131,40 +104,19 @@ func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	// Check content type
 	contentType := r.Header.Get("Content-Type")
-	log.Printf("LoginUser received Content-Type: %s", contentType)
+	logging.FromContext(r.Context()).Debug("LoginUser received request", "contentType", contentType)
 	
 	// Parse request body
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding request body: %v", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Invalid request body: " + err.Error(),
-			"details": "Make sure you're sending properly formatted JSON with Content-Type: application/json",
-		})
-		return
-	}
-
-	// Validate required fields
-	if req.Email == "" || req.Password == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Missing email or password",
-		})
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	// Authenticate user using service
 	user, err := h.userService.LoginUser(r.Context(), req.Email, req.Password)
 	if err != nil {
-		log.Printf("Error authenticating user: %v", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Invalid email or password",
-		})
+		logging.FromContext(r.Context()).Error("Error authenticating user", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -174,6 +126,7 @@ func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 
 	// Return successful response with token
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
 	
 	response := map[string]interface{}{
 		"token":     token,
@@ -181,11 +134,12 @@ func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		"firstName": user.FirstName,
 		"lastName":  user.LastName,
 		"email":     user.Email,
+		"timeZone":  user.TimeZone,
 	}
 	
 	// Debug log
 	responseBytes, _ := json.Marshal(response)
-	log.Printf("LoginUser response: %s", string(responseBytes))
+	logging.FromContext(r.Context()).Debug("LoginUser response", "response", string(responseBytes))
 	
 	json.NewEncoder(w).Encode(response)
 }
@@ -196,21 +150,21 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userIDStr, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Missing user ID", http.StatusBadRequest)
+		problem.InvalidParam(w, r, "id", "Missing user ID")
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		problem.InvalidParam(w, r, "id", "Invalid user ID format")
 		return
 	}
 
 	// Get user using service
 	user, err := h.userService.GetUserByID(r.Context(), userID)
 	if err != nil {
-		log.Printf("Error fetching user: %v", err)
-		http.Error(w, "Failed to get user: "+err.Error(), http.StatusNotFound)
+		logging.FromContext(r.Context()).Error("Error fetching user", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -224,4 +178,40 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		"role":      user.Role,
 		"rating":    user.AverageRating,
 	})
-}
\ No newline at end of file
+}
+
+// UpdateProfile applies the authenticated user's changes to their profile
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
+		return
+	}
+
+	var req UpdateProfileRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var user *models.User
+	if req.TimeZone != nil && *req.TimeZone != "" {
+		user, err = h.userService.UpdateTimeZone(r.Context(), userID, *req.TimeZone)
+	} else {
+		user, err = h.userService.GetUserByID(r.Context(), userID)
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error updating profile", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":        user.ID.String(),
+		"firstName": user.FirstName,
+		"lastName":  user.LastName,
+		"email":     user.Email,
+		"timeZone":  user.TimeZone,
+	})
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/api/middleware"
@@ -10,6 +9,8 @@ import (
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"platform/shared/logging"
+	"platform/shared/problem"
 )
 
 type VehicleHandler struct {
@@ -25,49 +26,28 @@ func (h *VehicleHandler) CreateVehicle(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
 	userID, err := middleware.GetUserIDFromContext(r.Context())
 	if (err != nil) {
-		log.Printf("Error getting user ID from context: %v", err)
-		
-		// Return detailed error for debugging
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Authentication error: " + err.Error(),
-			"details": "Failed to get user ID from request context. Make sure your token is valid and properly formatted.",
-		})
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
 		return
 	}
 	
-	log.Printf("Creating vehicle for user ID: %s", userID.String())
+	logging.FromContext(r.Context()).Debug("Creating vehicle", "userID", userID)
 
 	// Parse request body
 	var req models.CreateVehicleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding request body: %v", err)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Invalid request format: " + err.Error(),
-			"details": "Request body could not be parsed. Ensure you're sending valid JSON.",
-		})
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 	
 	// Log the request data
 	reqData, _ := json.Marshal(req)
-	log.Printf("Vehicle creation request data: %s", string(reqData))
+	logging.FromContext(r.Context()).Debug("Vehicle creation request", "request", string(reqData))
 
 	// Create vehicle using service
 	vehicle, err := h.vehicleService.CreateVehicle(r.Context(), userID, &req)
 	if err != nil {
-		log.Printf("Error creating vehicle: %v", err)
-		
-		// Return a properly formatted JSON error
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		errorResponse := map[string]string{
-			"error": "Failed to create vehicle: " + err.Error(),
-		}
-		json.NewEncoder(w).Encode(errorResponse)
+		logging.FromContext(r.Context()).Error("Error creating vehicle", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -82,7 +62,7 @@ func (h *VehicleHandler) CreateVehicle(w http.ResponseWriter, r *http.Request) {
 	
 	// Debug log the response before sending
 	responseBytes, _ := json.Marshal(response)
-	log.Printf("DEBUG: Sending vehicle creation response: %s", string(responseBytes))
+	logging.FromContext(r.Context()).Debug("Sending vehicle creation response", "response", string(responseBytes))
 	
 	// Write the response
 	json.NewEncoder(w).Encode(response)
@@ -93,16 +73,16 @@ func (h *VehicleHandler) GetUserVehiclesForAuthUser(w http.ResponseWriter, r *ht
 	// Get user ID from context (set by auth middleware)
 	userID, err := middleware.GetUserIDFromContext(r.Context())
 	if err != nil {
-		log.Printf("Error getting user ID from context: %v", err)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
 		return
 	}
 
 	// Get vehicles using service
 	vehicles, err := h.vehicleService.GetVehiclesByUserID(r.Context(), userID)
 	if err != nil {
-		log.Printf("Error fetching vehicles: %v", err)
-		http.Error(w, "Failed to get vehicles", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error fetching vehicles", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -110,8 +90,8 @@ func (h *VehicleHandler) GetUserVehiclesForAuthUser(w http.ResponseWriter, r *ht
 	w.Header().Set("Content-Type", "application/json")
 	vehicleBytes, err := json.Marshal(vehicles)
 	if err != nil {
-		log.Printf("Error marshalling vehicle list: %v", err)
-		http.Error(w, "Failed to serialize vehicles", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error marshalling vehicle list", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 	w.Write(vehicleBytes)
@@ -123,21 +103,21 @@ func (h *VehicleHandler) GetUserVehicles(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	userIDStr, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Missing user ID", http.StatusBadRequest)
+		problem.InvalidParam(w, r, "id", "Missing user ID")
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		problem.InvalidParam(w, r, "id", "Invalid user ID")
 		return
 	}
 
 	// Get vehicles using service
 	vehicles, err := h.vehicleService.GetVehiclesByUserID(r.Context(), userID)
 	if err != nil {
-		log.Printf("Error fetching vehicles: %v", err)
-		http.Error(w, "Failed to get vehicles", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error fetching vehicles", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -145,8 +125,8 @@ func (h *VehicleHandler) GetUserVehicles(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	vehicleBytes, err := json.Marshal(vehicles)
 	if err != nil {
-		log.Printf("Error marshalling vehicle list: %v", err)
-		http.Error(w, "Failed to serialize vehicles", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error marshalling vehicle list", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 	w.Write(vehicleBytes)
@@ -157,8 +137,8 @@ func (h *VehicleHandler) DeleteVehicle(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID, err := middleware.GetUserIDFromContext(r.Context())
 	if err != nil {
-		log.Printf("Error getting user ID from context: %v", err)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		logging.FromContext(r.Context()).Warn("Error getting user ID from context", "error", err)
+		problem.Unauthorized(w, r)
 		return
 	}
 
@@ -166,20 +146,20 @@ func (h *VehicleHandler) DeleteVehicle(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	vehicleIDStr, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Missing vehicle ID", http.StatusBadRequest)
+		problem.InvalidParam(w, r, "id", "Missing vehicle ID")
 		return
 	}
 
 	vehicleID, err := uuid.Parse(vehicleIDStr)
 	if err != nil {
-		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		problem.InvalidParam(w, r, "id", "Invalid vehicle ID")
 		return
 	}
 
 	// Delete the vehicle
 	if err := h.vehicleService.DeleteVehicle(r.Context(), userID, vehicleID); err != nil {
-		log.Printf("Error deleting vehicle: %v", err)
-		http.Error(w, "Failed to delete vehicle", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error deleting vehicle", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -4,12 +4,14 @@ package middleware
 import (
 	"context"
 	"errors"
-	"log"
 	"net/http"
 	"strings"
 
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/service"
 	"github.com/google/uuid"
+	"platform/shared/logging"
+	"platform/shared/problem"
 )
 
 // Define a type for the context key to avoid collisions
@@ -23,35 +25,35 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// This needs to be set in the main handler setup
 		userService, ok := r.Context().Value("userService").(*service.UserService)
 		if !ok {
-			log.Println("User service not found in context")
-			http.Error(w, "Server configuration error", http.StatusInternalServerError)
+			logging.FromContext(r.Context()).Error("User service not found in context")
+			problem.New(http.StatusInternalServerError, "server_misconfigured", "Server configuration error").Write(w, r)
 			return
 		}
 
 		// Get token from Authorization header
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			problem.New(http.StatusUnauthorized, "missing_token", "Authorization header required").Write(w, r)
 			return
 		}
 
 		// Check for Bearer prefix
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+			problem.New(http.StatusUnauthorized, "invalid_token", "Invalid authorization format").Write(w, r)
 			return
 		}
 
 		// Extract token
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		if token == "" {
-			http.Error(w, "Empty token", http.StatusUnauthorized)
+			problem.New(http.StatusUnauthorized, "missing_token", "Empty token").Write(w, r)
 			return
 		}
 
 		// Validate token using user service
 		userID, err := userService.ValidateToken(r.Context(), token)
 		if err != nil {
-			http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+			problem.New(http.StatusUnauthorized, "invalid_token", "Invalid or expired token").Write(w, r)
 			return
 		}
 
@@ -61,6 +63,71 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// OptionalAuthMiddleware identifies the caller when a valid bearer token is
+// present but lets anonymous requests through. Public endpoints use it to
+// personalise results, e.g. hiding rides from blocked users.
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userService, ok := r.Context().Value("userService").(*service.UserService)
+		authHeader := r.Header.Get("Authorization")
+		if !ok || !strings.HasPrefix(authHeader, "Bearer ") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(authHeader, "Bearer ")
+		userID, err := userService.ValidateToken(r.Context(), token)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), UserIDKey, userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// QueryTokenMiddleware lets WebSocket clients, which cannot set headers on the
+// upgrade request, pass their token as the access_token query parameter. It
+// must be registered before AuthMiddleware.
+func QueryTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if token := r.URL.Query().Get("access_token"); token != "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AdminMiddleware restricts a route to users with the admin role.
+// It must be registered after AuthMiddleware.
+func AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userService, ok := r.Context().Value("userService").(*service.UserService)
+		if !ok {
+			logging.FromContext(r.Context()).Error("User service not found in context")
+			problem.New(http.StatusInternalServerError, "server_misconfigured", "Server configuration error").Write(w, r)
+			return
+		}
+
+		userID, err := GetUserIDFromContext(r.Context())
+		if err != nil {
+			problem.Unauthorized(w, r)
+			return
+		}
+
+		user, err := userService.GetUserByID(r.Context(), userID)
+		if err != nil || user.Role != string(models.RoleAdmin) {
+			problem.New(http.StatusForbidden, "admin_required", "Admin access required").Write(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // GetUserIDFromContext retrieves the authenticated user's ID from the request context
 func GetUserIDFromContext(ctx context.Context) (uuid.UUID, error) {
 	userID, ok := ctx.Value(UserIDKey).(uuid.UUID)
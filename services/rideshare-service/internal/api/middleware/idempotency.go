@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/idempotency"
+	"platform/shared/logging"
+	"platform/shared/problem"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client-generated key of a mutating request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader marks a response replayed from an earlier request
+	IdempotentReplayHeader = "Idempotent-Replayed"
+	// Longest key a client may send
+	maxIdempotencyKeyLength = 255
+	// Largest body read to fingerprint a request. Multipart uploads are
+	// larger and are not deduplicated.
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// Headers recorded with a response and sent again on replay
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// IdempotencyMiddleware makes mutating requests that carry an Idempotency-Key
+// header safe to retry. The first request with a key runs and its response is
+// recorded; retries with the same key and payload get that response back
+// without running the handler again.
+//
+// Keys belong to the signed-in user rather than their token, so a retry
+// after a token refresh still matches, and the middleware must run after
+// AuthMiddleware. Anonymous callers share one scope. Either way a key reused
+// for a different request gets a 422.
+//
+// Server errors are not recorded, so a request that failed with a 5xx can be
+// retried with the same key. Responses marked Cache-Control: no-store, such
+// as those carrying tokens, are not kept either: a retry gets a 409 instead
+// of the body. A nil store disables the middleware.
+func IdempotencyMiddleware(store idempotency.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutating(r.Method) || isMultipart(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				problem.InvalidParam(w, r, IdempotencyKeyHeader, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				problem.New(http.StatusRequestEntityTooLarge, "body_too_large",
+					"Request body is too large to be made idempotent").Write(w, r)
+				return
+			case err != nil:
+				problem.InvalidBody(w, r)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := hashOf(r.Method, r.URL.RequestURI(), string(body))
+			storeKey := hashOf("anonymous", key)
+			if userID, err := GetUserIDFromContext(r.Context()); err == nil {
+				storeKey = hashOf("user", userID.String(), key)
+			}
+
+			recorded, err := store.Begin(r.Context(), storeKey, fingerprint)
+			switch {
+			case errors.Is(err, idempotency.ErrFingerprintMismatch):
+				problem.New(http.StatusUnprocessableEntity, "idempotency_key_reused",
+					"Idempotency-Key was already used for a different request").Write(w, r)
+				return
+			case errors.Is(err, idempotency.ErrInProgress):
+				problem.New(http.StatusConflict, "idempotency_request_in_progress",
+					"A request with this Idempotency-Key is still being processed").Write(w, r)
+				return
+			case err != nil:
+				// Availability wins over deduplication when the store is down
+				logging.FromContext(r.Context()).Warn("Error claiming idempotency key, handling request without it", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			case recorded != nil:
+				replay(w, r, recorded)
+				return
+			}
+
+			// The response is recorded even if the client has gone away
+			ctx := context.WithoutCancel(r.Context())
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				// Give the key up if the handler panicked or failed
+				if !completed {
+					if err := store.Release(ctx, storeKey); err != nil {
+						logging.FromContext(r.Context()).Error("Error releasing idempotency key", "error", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			resp := &idempotency.Response{
+				Status: rec.status,
+				Header: http.Header{},
+				Body:   rec.body.Bytes(),
+			}
+			for _, name := range replayedHeaders {
+				if value := rec.Header().Get(name); value != "" {
+					resp.Header.Set(name, value)
+				}
+			}
+			if strings.Contains(rec.Header().Get("Cache-Control"), "no-store") {
+				resp = &idempotency.Response{Status: rec.status, Withheld: true}
+			}
+			if err := store.Complete(ctx, storeKey, resp); err != nil {
+				logging.FromContext(r.Context()).Error("Error recording idempotent response", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return strings.HasPrefix(mediaType, "multipart/")
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// hashOf hashes parts separated by newlines, keeping request bodies, which
+// may hold credentials, out of the store
+func hashOf(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		io.WriteString(h, part)
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay writes a recorded response
+func replay(w http.ResponseWriter, r *http.Request, resp *idempotency.Response) {
+	if resp.Withheld {
+		problem.New(http.StatusConflict, "idempotent_response_withheld",
+			"A request with this Idempotency-Key already succeeded; its response is not kept").Write(w, r)
+		return
+	}
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayHeader, "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// recordingWriter passes a response through while keeping a copy
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recordingWriter) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recordingWriter) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
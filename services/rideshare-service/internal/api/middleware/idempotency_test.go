@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/idempotency"
+	"github.com/google/uuid"
+)
+
+func newIdempotentHandler(calls *int) http.Handler {
+	return IdempotencyMiddleware(idempotency.NewMemoryStore(time.Hour))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"call":%d}`, *calls)
+		}))
+}
+
+// idempotentRequest builds a request from user, as AuthMiddleware would
+// leave it, or an anonymous one when user is uuid.Nil
+func idempotentRequest(key, body string, user uuid.UUID) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/rides", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	if user != uuid.Nil {
+		req.Header.Set("Authorization", "Bearer "+uuid.NewString())
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey, user))
+	}
+	return req
+}
+
+func TestIdempotencyMiddlewareReplaysResponse(t *testing.T) {
+	user := uuid.New()
+	calls := 0
+	handler := newIdempotentHandler(&calls)
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("k1", `{"a":1}`, user))
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("k1", `{"a":1}`, user))
+
+	if calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the original response, got %d %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(IdempotentReplayHeader) != "true" || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected replay headers %v", retry.Header())
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsReusedKey(t *testing.T) {
+	user := uuid.New()
+	calls := 0
+	handler := newIdempotentHandler(&calls)
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k1", `{"a":1}`, user))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("k1", `{"a":2}`, user))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", rec.Code)
+	}
+	if calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", calls)
+	}
+}
+
+func TestIdempotencyMiddlewareScopesKeysToCaller(t *testing.T) {
+	calls := 0
+	handler := newIdempotentHandler(&calls)
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k1", `{"a":1}`, uuid.New()))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("k1", `{"a":1}`, uuid.New()))
+
+	if calls != 2 || rec.Header().Get(IdempotentReplayHeader) != "" {
+		t.Errorf("expected another caller's key to be independent, ran %d times", calls)
+	}
+}
+
+func TestIdempotencyMiddlewareSurvivesTokenRefresh(t *testing.T) {
+	user := uuid.New()
+	calls := 0
+	handler := newIdempotentHandler(&calls)
+
+	// Each request carries a fresh token for the same user
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k1", `{"a":1}`, user))
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("k1", `{"a":1}`, user))
+
+	if calls != 1 || retry.Header().Get(IdempotentReplayHeader) != "true" {
+		t.Errorf("expected the retry with a refreshed token to be replayed, ran %d times", calls)
+	}
+}
+
+func TestIdempotencyMiddlewareAnonymousKeys(t *testing.T) {
+	calls := 0
+	handler := newIdempotentHandler(&calls)
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k1", `{"email":"a@uni.edu"}`, uuid.Nil))
+
+	// An identical retry is replayed
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("k1", `{"email":"a@uni.edu"}`, uuid.Nil))
+	if calls != 1 || retry.Header().Get(IdempotentReplayHeader) != "true" || retry.Body.String() != `{"call":1}` {
+		t.Errorf("expected the identical anonymous retry to be replayed, got %s after %d calls", retry.Body.String(), calls)
+	}
+
+	// The same key with a different body is rejected
+	other := httptest.NewRecorder()
+	handler.ServeHTTP(other, idempotentRequest("k1", `{"email":"b@uni.edu"}`, uuid.Nil))
+	if calls != 1 || other.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a reused anonymous key, got %d after %d calls", other.Code, calls)
+	}
+}
+
+func TestIdempotencyMiddlewareWithholdsNoStoreResponses(t *testing.T) {
+	calls := 0
+	handler := IdempotencyMiddleware(idempotency.NewMemoryStore(time.Hour))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"token":"secret"}`)
+		}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k1", `{"a":1}`, uuid.Nil))
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("k1", `{"a":1}`, uuid.Nil))
+
+	if calls != 1 || retry.Code != http.StatusConflict || strings.Contains(retry.Body.String(), "secret") {
+		t.Errorf("expected a 409 without the token, got %d %s after %d calls", retry.Code, retry.Body.String(), calls)
+	}
+}
+
+func TestIdempotencyMiddlewareLimitsBodySize(t *testing.T) {
+	calls := 0
+	handler := newIdempotentHandler(&calls)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("k1", strings.Repeat("a", maxIdempotentBodyBytes+1), uuid.New()))
+
+	if rec.Code != http.StatusRequestEntityTooLarge || calls != 0 {
+		t.Errorf("expected 413 without running the handler, got %d after %d calls", rec.Code, calls)
+	}
+}
+
+func TestIdempotencyMiddlewareSkipsMultipart(t *testing.T) {
+	calls := 0
+	handler := newIdempotentHandler(&calls)
+
+	for range 2 {
+		req := idempotentRequest("k1", strings.Repeat("a", maxIdempotentBodyBytes+1), uuid.New())
+		req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected multipart requests to bypass the middleware, ran %d times", calls)
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/audit"
+	"github.com/google/uuid"
+	"platform/shared/logging"
+)
+
+// RequestIDHeader carries the ID that ties a request to its logs and audit entries
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the context key of the request ID
+const RequestIDKey ContextKey = "requestID"
+
+// Longest request ID accepted from a client or the gateway
+const maxRequestIDLength = 100
+
+// RequestIDMiddleware gives every request an ID, reusing the one set by the
+// gateway when there is one, and echoes it in the response. Handlers log
+// through logging.FromContext so their lines carry the ID.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		ctx = logging.WithContext(ctx, slog.Default().With("request_id", requestID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestIDFromContext returns the ID set by RequestIDMiddleware, or an
+// empty string outside a request
+func GetRequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+// AuditMetadataMiddleware records who sent a request, so that changes made
+// while handling it can be attributed in the audit log. It must be registered
+// after RequestIDMiddleware.
+func AuditMetadataMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := audit.WithMetadata(r.Context(), audit.Metadata{
+			RequestID: GetRequestIDFromContext(r.Context()),
+			IP:        clientIP(r),
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientIP returns the address of the original client. The service is only
+// reachable through the gateway, so the first X-Forwarded-For hop is trusted.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		if ip := net.ParseIP(strings.TrimSpace(first)); ip != nil {
+			return ip.String()
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
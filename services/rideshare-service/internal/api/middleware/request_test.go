@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/audit"
+	"platform/shared/logging"
+)
+
+func TestRequestMetadataReachesAuditLog(t *testing.T) {
+	var meta audit.Metadata
+	handler := RequestIDMiddleware(AuditMetadataMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta = audit.MetadataFrom(r.Context())
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/rides", nil)
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+	req.Header.Set("User-Agent", "campus-app/1.0")
+	req.Header.Set(RequestIDHeader, "gateway-request")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	want := audit.Metadata{RequestID: "gateway-request", IP: "203.0.113.7", UserAgent: "campus-app/1.0"}
+	if meta != want {
+		t.Errorf("metadata = %+v, want %+v", meta, want)
+	}
+	if got := rec.Header().Get(RequestIDHeader); got != "gateway-request" {
+		t.Errorf("response request ID = %q", got)
+	}
+}
+
+func TestRequestIDIsGeneratedWhenMissing(t *testing.T) {
+	var requestID string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = GetRequestIDFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	if requestID == "" || rec.Header().Get(RequestIDHeader) != requestID {
+		t.Errorf("request ID %q, response header %q", requestID, rec.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestRequestLoggerCarriesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("handling")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	req.Header.Set(RequestIDHeader, "gateway-request")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("error decoding log line %q: %v", buf.String(), err)
+	}
+	if line["request_id"] != "gateway-request" {
+		t.Errorf("request_id = %v", line["request_id"])
+	}
+}
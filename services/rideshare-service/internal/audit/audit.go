@@ -0,0 +1,165 @@
+// Package audit records an append-only history of changes to rides, ride
+// requests, vehicles and users
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Entity types, named after the table that holds the entity
+const (
+	EntityRide             = "rides"
+	EntityRideRequest      = "ride_requests"
+	EntityVehicle          = "vehicles"
+	EntityUser             = "users"
+	EntityCommunityProfile = "community_profiles"
+	EntityDocument         = "documents"
+)
+
+// Actions recorded in the log
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionCancel   = "cancel"
+	ActionDelete   = "delete"
+	ActionAccept   = "accept"
+	ActionReject   = "reject"
+	ActionRegister = "register"
+	ActionLogin    = "login"
+	ActionVerify   = "verify"
+	ActionComplete = "complete"
+)
+
+// Metadata describes the HTTP request a change was made in
+type Metadata struct {
+	RequestID string
+	IP        string
+	UserAgent string
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a context carrying the request metadata to record
+func WithMetadata(ctx context.Context, meta Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, meta)
+}
+
+// MetadataFrom returns the request metadata in ctx, which is empty for
+// changes made outside a request
+func MetadataFrom(ctx context.Context) Metadata {
+	meta, _ := ctx.Value(metadataKey{}).(Metadata)
+	return meta
+}
+
+// Entry is a change to record. Before is nil for created entities and After
+// is nil for deleted ones; both are stored as their JSON encoding.
+type Entry struct {
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	OwnerID    uuid.UUID
+	ActorID    *uuid.UUID
+	Before     interface{}
+	After      interface{}
+}
+
+// Record writes entry to the audit log. Like outbox.Append, it must be called
+// with the transaction that makes the change, so the log holds a change if and
+// only if it committed.
+func Record(ctx context.Context, tx *sql.Tx, entry Entry) error {
+	before, after, err := Diff(entry.Before, entry.After)
+	if err != nil {
+		return fmt.Errorf("error diffing %s %s: %w", entry.EntityType, entry.EntityID, err)
+	}
+
+	query := `
+		INSERT INTO audit_log (
+			user_id, owner_id, action, table_name, record_id,
+			old_values, new_values, request_id, ip_address, user_agent
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	meta := MetadataFrom(ctx)
+	_, err = tx.ExecContext(ctx, query,
+		entry.ActorID,
+		uuid.NullUUID{UUID: entry.OwnerID, Valid: entry.OwnerID != uuid.Nil},
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID.String(),
+		nullString(string(before)),
+		nullString(string(after)),
+		nullString(meta.RequestID),
+		nullString(meta.IP),
+		nullString(meta.UserAgent),
+	)
+	if err != nil {
+		return fmt.Errorf("error writing %s of %s to audit log: %w", entry.Action, entry.EntityType, err)
+	}
+	return nil
+}
+
+// Diff compares the JSON encodings of before and after field by field and
+// returns the old and new values of the fields that differ. A nil side is
+// returned as nil, and the other side is returned whole.
+func Diff(before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	oldFields, err := fields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	newFields, err := fields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if oldFields == nil || newFields == nil {
+		return encode(oldFields), encode(newFields), nil
+	}
+
+	changedOld := map[string]json.RawMessage{}
+	changedNew := map[string]json.RawMessage{}
+	for name, value := range oldFields {
+		if newValue, ok := newFields[name]; !ok || !bytes.Equal(value, newValue) {
+			changedOld[name] = value
+		}
+	}
+	for name, value := range newFields {
+		if oldValue, ok := oldFields[name]; !ok || !bytes.Equal(value, oldValue) {
+			changedNew[name] = value
+		}
+	}
+	return encode(changedOld), encode(changedNew), nil
+}
+
+// fields splits the JSON encoding of v into its top-level fields
+func fields(v interface{}) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// encode marshals fields, keeping nil as nil so it is stored as SQL NULL
+func encode(fields map[string]json.RawMessage) json.RawMessage {
+	if fields == nil {
+		return nil
+	}
+	data, _ := json.Marshal(fields)
+	return data
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
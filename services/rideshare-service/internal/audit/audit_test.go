@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type vehicle struct {
+	Make     string `json:"make"`
+	Color    string `json:"color"`
+	Capacity int    `json:"capacity"`
+	Secret   string `json:"-"`
+}
+
+func TestDiffKeepsOnlyChangedFields(t *testing.T) {
+	before := vehicle{Make: "Toyota", Color: "red", Capacity: 4, Secret: "a"}
+	after := vehicle{Make: "Toyota", Color: "blue", Capacity: 4, Secret: "b"}
+
+	oldValues, newValues, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if got := string(oldValues); got != `{"color":"red"}` {
+		t.Errorf("old values = %s", got)
+	}
+	if got := string(newValues); got != `{"color":"blue"}` {
+		t.Errorf("new values = %s", got)
+	}
+}
+
+func TestDiffRecordsCreatedAndDeletedEntitiesWhole(t *testing.T) {
+	v := &vehicle{Make: "Toyota", Color: "red", Capacity: 4}
+
+	oldValues, newValues, err := Diff(nil, v)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if oldValues != nil {
+		t.Errorf("old values of a created entity = %s, want nil", oldValues)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(newValues, &fields); err != nil || len(fields) != 3 {
+		t.Errorf("new values of a created entity = %s", newValues)
+	}
+
+	var missing *vehicle
+	oldValues, newValues, err = Diff(v, missing)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if newValues != nil || oldValues == nil {
+		t.Errorf("deleted entity diffed to %s -> %s", oldValues, newValues)
+	}
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	if meta := MetadataFrom(context.Background()); meta != (Metadata{}) {
+		t.Errorf("metadata of a bare context = %+v", meta)
+	}
+
+	want := Metadata{RequestID: "req-1", IP: "10.0.0.1", UserAgent: "test"}
+	if got := MetadataFrom(WithMetadata(context.Background(), want)); got != want {
+		t.Errorf("MetadataFrom = %+v, want %+v", got, want)
+	}
+}
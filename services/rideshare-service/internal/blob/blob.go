@@ -0,0 +1,46 @@
+// Package blob stores uploaded files, such as verification documents, outside
+// the database. Files are addressed by a key chosen by the caller.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned when no blob is stored under a key
+var ErrNotFound = errors.New("blob not found")
+
+// Store holds blobs by key
+type Store interface {
+	// Put stores the contents of r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns the blob stored under key; callers must close it
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidKey reports whether key can be used with every store: slash-separated
+// segments of letters, digits, dashes, underscores and dots, none of them
+// empty, "." or ".."
+func ValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, c := range segment {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			case c == '-', c == '_', c == '.':
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	dir, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, store := range map[string]Store{"dir": dir, "memory": NewMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			key := "documents/abc-123"
+
+			if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound before Put, got %v", err)
+			}
+
+			if err := store.Put(ctx, key, strings.NewReader("first")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := store.Put(ctx, key, strings.NewReader("second")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := read(t, store, key); got != "second" {
+				t.Errorf("expected the replaced blob, got %q", got)
+			}
+
+			if err := store.Delete(ctx, key); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound after Delete, got %v", err)
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				t.Errorf("expected deleting a missing blob to succeed, got %v", err)
+			}
+
+			if err := store.Put(ctx, "../escape", strings.NewReader("x")); err == nil {
+				t.Errorf("expected an invalid key to be refused")
+			}
+		})
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"documents/abc_1.pdf": true,
+		"a":                   true,
+		"":                    false,
+		"/abs":                false,
+		"a//b":                false,
+		"a/../b":              false,
+		"a/./b":               false,
+		`a\b`:                 false,
+		"a b":                 false,
+	} {
+		if got := ValidKey(key); got != want {
+			t.Errorf("ValidKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func read(t *testing.T, store Store, key string) string {
+	t.Helper()
+	rc, err := store.Open(context.Background(), key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,106 @@
+package chat
+
+import (
+	"sync"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// Event types pushed to subscribers
+const (
+	EventMessage = "message"
+	EventRead    = "read"
+)
+
+// subscriptionBuffer is how many events a subscriber may fall behind before
+// it is dropped. Dropped clients reconnect and catch up over HTTP.
+const subscriptionBuffer = 32
+
+// Event is pushed to every connection subscribed to a ride's conversation
+type Event struct {
+	Type    string                  `json:"type"`
+	Message *models.ChatMessage     `json:"message,omitempty"`
+	Receipt *models.ChatReadReceipt `json:"receipt,omitempty"`
+}
+
+// Hub fans chat events out to the live connections of each ride. It lives in
+// memory, so with more than one replica a client only gets live events for
+// messages sent through the replica it is connected to; clients on other
+// replicas see them on their next HTTP poll. Relaying events through Redis
+// pub/sub would lift that limit.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[uuid.UUID]map[*Subscription]struct{}
+}
+
+// Subscription receives the events of a single ride until it is closed
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	rideID uuid.UUID
+	hub    *Hub
+	once   sync.Once
+}
+
+// NewHub creates an empty Hub
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uuid.UUID]map[*Subscription]struct{})}
+}
+
+// Subscribe registers a new listener for a ride's conversation
+func (h *Hub) Subscribe(rideID uuid.UUID) *Subscription {
+	ch := make(chan Event, subscriptionBuffer)
+	sub := &Subscription{C: ch, ch: ch, rideID: rideID, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subs[rideID] == nil {
+		h.subs[rideID] = make(map[*Subscription]struct{})
+	}
+	h.subs[rideID][sub] = struct{}{}
+
+	return sub
+}
+
+// Publish delivers an event to every subscriber of a ride without blocking.
+// Subscribers whose buffer is full are closed.
+func (h *Hub) Publish(rideID uuid.UUID, event Event) {
+	h.mu.RLock()
+	var slow []*Subscription
+	for sub := range h.subs[rideID] {
+		select {
+		case sub.ch <- event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, sub := range slow {
+		sub.Close()
+	}
+}
+
+// Subscribers returns the number of live subscriptions for a ride
+func (h *Hub) Subscribers(rideID uuid.UUID) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs[rideID])
+}
+
+// Close unregisters the subscription and closes its channel. It is safe to
+// call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+
+		delete(s.hub.subs[s.rideID], s)
+		if len(s.hub.subs[s.rideID]) == 0 {
+			delete(s.hub.subs, s.rideID)
+		}
+		close(s.ch)
+	})
+}
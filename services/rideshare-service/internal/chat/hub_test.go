@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestHubDeliversOnlyToRideSubscribers(t *testing.T) {
+	hub := NewHub()
+	rideA := uuid.New()
+	rideB := uuid.New()
+
+	subA := hub.Subscribe(rideA)
+	defer subA.Close()
+	subB := hub.Subscribe(rideB)
+	defer subB.Close()
+
+	msg := &models.ChatMessage{ID: uuid.New(), RideID: rideA, Body: "hello"}
+	hub.Publish(rideA, Event{Type: EventMessage, Message: msg})
+
+	select {
+	case ev := <-subA.C:
+		if ev.Message == nil || ev.Message.ID != msg.ID {
+			t.Fatalf("expected message %s, got %+v", msg.ID, ev)
+		}
+	default:
+		t.Fatal("expected subscriber of ride A to receive the event")
+	}
+
+	select {
+	case ev := <-subB.C:
+		t.Fatalf("subscriber of ride B should not receive events for ride A, got %+v", ev)
+	default:
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	rideID := uuid.New()
+	sub := hub.Subscribe(rideID)
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		hub.Publish(rideID, Event{Type: EventMessage})
+	}
+
+	if n := hub.Subscribers(rideID); n != 0 {
+		t.Fatalf("expected slow subscriber to be removed, %d still registered", n)
+	}
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	if received != subscriptionBuffer {
+		t.Fatalf("expected %d buffered events before close, got %d", subscriptionBuffer, received)
+	}
+
+	// Closing again must not panic
+	sub.Close()
+}
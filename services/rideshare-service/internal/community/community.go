@@ -0,0 +1,78 @@
+// Package community decides who may share a ride from the verified campus
+// attributes of the host and the rider and the restrictions each of them set
+package community
+
+import (
+	"strings"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+)
+
+// Eligible reports whether rider may see and join a ride hosted by host with
+// the given audience. Every restriction set by the ride or by the rider's
+// preferences requires both users to have the same verified attribute, so
+// unverified profiles and missing attributes never match. Rides without
+// restrictions are open to riders without preferences, including anonymous
+// ones with no profile.
+func Eligible(audience models.Audience, host, rider *models.CommunityProfile) bool {
+	required := audience
+	if rider != nil {
+		required = Union(audience, rider.Preferences)
+	}
+	if !Restricted(required) {
+		return true
+	}
+	if !host.Verified() || !rider.Verified() {
+		return false
+	}
+
+	return (!required.SameUniversity || same(host.University, rider.University)) &&
+		(!required.SameFaculty || same(host.Faculty, rider.Faculty)) &&
+		(!required.SameBatch || same(host.Batch, rider.Batch)) &&
+		(!required.SameGender || same(host.Gender, rider.Gender))
+}
+
+// Restricted reports whether audience sets any restriction
+func Restricted(audience models.Audience) bool {
+	return audience != models.Audience{}
+}
+
+// Union returns the restrictions set by either audience
+func Union(a, b models.Audience) models.Audience {
+	return models.Audience{
+		SameUniversity: a.SameUniversity || b.SameUniversity,
+		SameFaculty:    a.SameFaculty || b.SameFaculty,
+		SameBatch:      a.SameBatch || b.SameBatch,
+		SameGender:     a.SameGender || b.SameGender,
+	}
+}
+
+// Unsupported returns the JSON names of the restrictions in audience that
+// profile has no verified attribute for. A host cannot restrict a ride to
+// riders who share an attribute the host has not verified.
+func Unsupported(audience models.Audience, profile *models.CommunityProfile) []string {
+	verified := profile.Verified()
+	var missing []string
+	check := func(restricted bool, name, value string) {
+		if restricted && (!verified || strings.TrimSpace(value) == "") {
+			missing = append(missing, name)
+		}
+	}
+
+	var university, faculty, batch, gender string
+	if profile != nil {
+		university, faculty, batch, gender = profile.University, profile.Faculty, profile.Batch, profile.Gender
+	}
+	check(audience.SameUniversity, "sameUniversity", university)
+	check(audience.SameFaculty, "sameFaculty", faculty)
+	check(audience.SameBatch, "sameBatch", batch)
+	check(audience.SameGender, "sameGender", gender)
+	return missing
+}
+
+// same compares attributes ignoring case and surrounding space. Empty
+// attributes match nothing.
+func same(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	return a != "" && strings.EqualFold(a, b)
+}
@@ -0,0 +1,69 @@
+package community
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+)
+
+func verified(university, batch, gender string) *models.CommunityProfile {
+	now := time.Now()
+	return &models.CommunityProfile{University: university, Batch: batch, Gender: gender, VerifiedAt: &now}
+}
+
+func TestEligibleAppliesRideRestrictions(t *testing.T) {
+	host := verified("Columbia", "2026", "female")
+	sameGender := models.Audience{SameGender: true}
+
+	tests := []struct {
+		name     string
+		audience models.Audience
+		rider    *models.CommunityProfile
+		want     bool
+	}{
+		{"open ride, anonymous rider", models.Audience{}, nil, true},
+		{"restricted ride, anonymous rider", sameGender, nil, false},
+		{"same gender", sameGender, verified("NYU", "2024", "Female"), true},
+		{"different gender", sameGender, verified("Columbia", "2026", "male"), false},
+		{"unverified rider", sameGender, &models.CommunityProfile{Gender: "female"}, false},
+		{"same university and batch", models.Audience{SameUniversity: true, SameBatch: true}, verified(" columbia ", "2026", "male"), true},
+		{"different batch", models.Audience{SameUniversity: true, SameBatch: true}, verified("Columbia", "2025", "female"), false},
+		{"missing faculty", models.Audience{SameFaculty: true}, verified("Columbia", "2026", "female"), false},
+	}
+	for _, tt := range tests {
+		if got := Eligible(tt.audience, host, tt.rider); got != tt.want {
+			t.Errorf("%s: Eligible = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEligibleAppliesRiderPreferences(t *testing.T) {
+	rider := verified("Columbia", "2026", "female")
+	rider.Preferences = models.Audience{SameGender: true}
+
+	if !Eligible(models.Audience{}, verified("NYU", "2020", "female"), rider) {
+		t.Error("expected a verified host of the same gender to match")
+	}
+	if Eligible(models.Audience{}, verified("Columbia", "2026", "male"), rider) {
+		t.Error("expected a host of another gender to be filtered out")
+	}
+	if Eligible(models.Audience{}, &models.CommunityProfile{Gender: "female"}, rider) {
+		t.Error("expected an unverified host to be filtered out")
+	}
+}
+
+func TestUnsupportedListsUnverifiedRestrictions(t *testing.T) {
+	audience := models.Audience{SameUniversity: true, SameFaculty: true}
+
+	if got := Unsupported(audience, verified("Columbia", "2026", "female")); !reflect.DeepEqual(got, []string{"sameFaculty"}) {
+		t.Errorf("Unsupported = %v", got)
+	}
+	if got := Unsupported(audience, nil); len(got) != 2 {
+		t.Errorf("Unsupported without a profile = %v", got)
+	}
+	if got := Unsupported(models.Audience{}, nil); len(got) != 0 {
+		t.Errorf("Unsupported of an open ride = %v", got)
+	}
+}
@@ -5,22 +5,37 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the complete application configuration
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
+	Server        ServerConfig       `yaml:"server"`
+	Database      DatabaseConfig     `yaml:"database"`
+	Redis         RedisConfig        `yaml:"redis"`
+	Presence      PresenceConfig     `yaml:"presence"`
+	Notifications NotificationConfig `yaml:"notifications"`
+	RabbitMQ      RabbitMQConfig     `yaml:"rabbitmq"`
+	Outbox        OutboxConfig       `yaml:"outbox"`
+	Contract      ContractConfig     `yaml:"contract"`
+	Idempotency   IdempotencyConfig  `yaml:"idempotency"`
+	Geocoding     GeocodingConfig    `yaml:"geocoding"`
+	Fares         FareConfig         `yaml:"fares"`
+	Impact        ImpactConfig       `yaml:"impact"`
+	Calendar      CalendarConfig     `yaml:"calendar"`
+	Documents     DocumentConfig     `yaml:"documents"`
 }
 
 // ServerConfig holds server-related settings
 type ServerConfig struct {
-	Port         string `yaml:"port"`
-	ReadTimeout  int    `yaml:"read_timeout"`
-	WriteTimeout int    `yaml:"write_timeout"`
-	LogLevel     string `yaml:"log_level"`
+	Port             string   `yaml:"port"`
+	ReadTimeout      int      `yaml:"read_timeout"`
+	WriteTimeout     int      `yaml:"write_timeout"`
+	LogLevel         string   `yaml:"log_level"`
+	ReadinessTimeout int      `yaml:"readiness_timeout"` // Seconds each readiness check may take
+	AllowedOrigins   []string `yaml:"allowed_origins"`   // Web origins allowed to open chat sockets
 }
 
 // DatabaseConfig holds database connection settings
@@ -42,15 +57,121 @@ type DBConnection struct {
 	SSLMode  string `yaml:"sslmode"`
 }
 
+// RedisConfig holds Redis connection settings.
+// An empty address disables Redis and in-memory fallbacks are used instead.
+type RedisConfig struct {
+	Address  string `yaml:"address"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// PresenceConfig holds online presence tracking settings
+type PresenceConfig struct {
+	TTL          int `yaml:"ttl"`            // Seconds a heartbeat keeps a user online
+	LastSeenDays int `yaml:"last_seen_days"` // Days the last-seen time is retained
+}
+
+// RabbitMQConfig holds message broker settings.
+// An empty URI keeps domain events in-process.
+type RabbitMQConfig struct {
+	URI      string `yaml:"uri"`
+	Exchange string `yaml:"exchange"` // Topic exchange domain events are published to
+}
+
+// OutboxConfig holds transactional outbox relay settings
+type OutboxConfig struct {
+	PollInterval int `yaml:"poll_interval_ms"` // Milliseconds between relay runs
+	BatchSize    int `yaml:"batch_size"`       // Events published per relay transaction
+}
+
+// ContractConfig holds OpenAPI contract validation settings
+type ContractConfig struct {
+	SpecPath string `yaml:"spec_path"` // Path to api-specs/openapi.yaml
+	Mode     string `yaml:"mode"`      // off, log or strict
+}
+
+// IdempotencyConfig holds Idempotency-Key settings
+type IdempotencyConfig struct {
+	TTL int `yaml:"ttl"` // Seconds a key and its response are remembered
+}
+
+// GeocodingConfig holds address geocoding settings
+type GeocodingConfig struct {
+	GazetteerPath string `yaml:"gazetteer_path"` // Empty uses the bundled gazetteer
+}
+
+// FareConfig holds the cost-of-driving model rides are priced with
+type FareConfig struct {
+	FuelPricePerLiter float64 `yaml:"fuel_price_per_liter"`
+	LitersPer100Km    float64 `yaml:"liters_per_100km"` // Fuel consumption
+	WearCostPerKm     float64 `yaml:"wear_cost_per_km"` // Tyres, maintenance and depreciation
+	RoadFactor        float64 `yaml:"road_factor"`      // Road distance per straight-line distance
+	Currency          string  `yaml:"currency"`
+}
+
+// ImpactConfig holds the assumptions behind the emissions shared rides avoid
+type ImpactConfig struct {
+	BaselineFuelType   string  `yaml:"baseline_fuel_type"`  // Fuel of the car a passenger would otherwise drive
+	BaselineEfficiency float64 `yaml:"baseline_efficiency"` // Its consumption per 100 km
+	GridKgCO2PerKWh    float64 `yaml:"grid_kg_co2_per_kwh"` // Emissions of charging electric cars
+}
+
+// CalendarConfig holds iCalendar feed settings
+type CalendarConfig struct {
+	FeedBase       string `yaml:"feed_base"`       // Feed tokens are appended, with .ics, to make feed URLs
+	RefreshMinutes int    `yaml:"refresh_minutes"` // How often subscribed apps are asked to refresh
+}
+
+// DocumentConfig holds verification document settings.
+// An empty storage directory keeps uploads in memory.
+type DocumentConfig struct {
+	StorageDir         string `yaml:"storage_dir"`
+	MaxUploadMB        int    `yaml:"max_upload_mb"`
+	RequiredForHosting bool   `yaml:"required_for_hosting"` // Hosts need approved, unexpired documents to offer rides
+	ExpiryReminderDays int    `yaml:"expiry_reminder_days"` // Warn owners this long before a document expires
+	ReminderInterval   int    `yaml:"reminder_interval"`    // Seconds between expiry sweeps
+}
+
+// NotificationConfig holds notification delivery settings
+type NotificationConfig struct {
+	SMTP                SMTPConfig    `yaml:"smtp"`
+	Webhook             WebhookConfig `yaml:"webhook"`
+	MaxAttempts         int           `yaml:"max_attempts"`          // Delivery attempts per channel
+	InitialBackoffMs    int           `yaml:"initial_backoff_ms"`    // Delay before the first retry
+	MaxBackoffMs        int           `yaml:"max_backoff_ms"`        // Upper bound on the retry delay
+	DeliveryInterval    int           `yaml:"delivery_interval"`     // Seconds between sweeps of queued email and webhook deliveries
+	DeliveryBatchSize   int           `yaml:"delivery_batch_size"`   // Deliveries claimed per sweep
+	ReminderLeadMinutes int           `yaml:"reminder_lead_minutes"` // How long before departure to send "starting soon"
+	ReminderInterval    int           `yaml:"reminder_interval"`     // Seconds between reminder sweeps
+	RideLinkBase        string        `yaml:"ride_link_base"`        // Ride IDs are appended to make links in messages
+}
+
+// SMTPConfig holds SMTP relay settings. An empty host disables email.
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+	Timeout  int    `yaml:"timeout"` // Seconds allowed for connecting and sending one message
+}
+
+// WebhookConfig holds outgoing webhook settings
+type WebhookConfig struct {
+	Secret  string `yaml:"secret"`  // Used to sign request bodies
+	Timeout int    `yaml:"timeout"` // Seconds
+}
+
 // LoadConfig loads configuration from a YAML file and overrides with environment variables
 func LoadConfig(configPath string) (*Config, error) {
 	// Initialize with defaults
 	cfg := &Config{
 		Server: ServerConfig{
-			Port:         "8080",
-			ReadTimeout:  30,
-			WriteTimeout: 30,
-			LogLevel:     "info",
+			Port:             "8080",
+			ReadTimeout:      30,
+			WriteTimeout:     30,
+			LogLevel:         "info",
+			ReadinessTimeout: 2,
 		},
 		Database: DatabaseConfig{
 			Primary: DBConnection{
@@ -65,6 +186,65 @@ func LoadConfig(configPath string) (*Config, error) {
 			MaxIdleConns:   5,
 			ConnMaxLifetime: 300, // 5 minutes
 		},
+		Presence: PresenceConfig{
+			TTL:          90,
+			LastSeenDays: 30,
+		},
+		Notifications: NotificationConfig{
+			SMTP: SMTPConfig{
+				Port:    "587",
+				From:    "no-reply@rideshare.local",
+				Timeout: 30,
+			},
+			Webhook: WebhookConfig{
+				Timeout: 10,
+			},
+			MaxAttempts:         5,
+			InitialBackoffMs:    2000,
+			MaxBackoffMs:        30000,
+			DeliveryInterval:    5,
+			DeliveryBatchSize:   50,
+			ReminderLeadMinutes: 30,
+			ReminderInterval:    60,
+			RideLinkBase:        "http://localhost:8080/api/rides/",
+		},
+		RabbitMQ: RabbitMQConfig{
+			Exchange: "rideshare.events",
+		},
+		Outbox: OutboxConfig{
+			PollInterval: 500,
+			BatchSize:    100,
+		},
+		Contract: ContractConfig{
+			SpecPath: "../../api-specs/openapi.yaml",
+			Mode:     "log",
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 86400, // 24 hours
+		},
+		Fares: FareConfig{
+			FuelPricePerLiter: 1.00,
+			LitersPer100Km:    8,
+			WearCostPerKm:     0.10,
+			RoadFactor:        1.3,
+			Currency:          "USD",
+		},
+		Impact: ImpactConfig{
+			BaselineFuelType:   "petrol",
+			BaselineEfficiency: 7.5,
+			GridKgCO2PerKWh:    0.4,
+		},
+		Calendar: CalendarConfig{
+			FeedBase:       "http://localhost:8080/api/calendar/",
+			RefreshMinutes: 60,
+		},
+		Documents: DocumentConfig{
+			StorageDir:         "./data/documents",
+			MaxUploadMB:        10,
+			RequiredForHosting: true,
+			ExpiryReminderDays: 14,
+			ReminderInterval:   3600,
+		},
 	}
 
 	// Look for config file
@@ -127,6 +307,12 @@ func applyEnvOverrides(cfg *Config) {
 	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
 		cfg.Server.LogLevel = logLevel
 	}
+	if timeout := getEnvInt("SERVER_READINESS_TIMEOUT", 0); timeout > 0 {
+		cfg.Server.ReadinessTimeout = timeout
+	}
+	if origins := os.Getenv("SERVER_ALLOWED_ORIGINS"); origins != "" {
+		cfg.Server.AllowedOrigins = strings.Split(origins, ",")
+	}
 
 	// Database primary connection
 	if host := os.Getenv("DB_HOST"); host != "" {
@@ -158,6 +344,103 @@ func applyEnvOverrides(cfg *Config) {
 	if connMaxLifetime := getEnvInt("DB_CONN_MAX_LIFETIME", 0); connMaxLifetime > 0 {
 		cfg.Database.ConnMaxLifetime = connMaxLifetime
 	}
+
+	// Redis settings
+	if redisAddr := os.Getenv("REDIS_ADDRESS"); redisAddr != "" {
+		cfg.Redis.Address = redisAddr
+	}
+	if redisPassword := os.Getenv("REDIS_PASSWORD"); redisPassword != "" {
+		cfg.Redis.Password = redisPassword
+	}
+	if redisDB := getEnvInt("REDIS_DB", -1); redisDB >= 0 {
+		cfg.Redis.DB = redisDB
+	}
+
+	// Presence settings
+	if ttl := getEnvInt("PRESENCE_TTL", 0); ttl > 0 {
+		cfg.Presence.TTL = ttl
+	}
+
+	// Notification settings
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		cfg.Notifications.SMTP.Host = smtpHost
+	}
+	if smtpPort := os.Getenv("SMTP_PORT"); smtpPort != "" {
+		cfg.Notifications.SMTP.Port = smtpPort
+	}
+	if smtpUser := os.Getenv("SMTP_USERNAME"); smtpUser != "" {
+		cfg.Notifications.SMTP.Username = smtpUser
+	}
+	if smtpPassword := os.Getenv("SMTP_PASSWORD"); smtpPassword != "" {
+		cfg.Notifications.SMTP.Password = smtpPassword
+	}
+	if smtpFrom := os.Getenv("SMTP_FROM"); smtpFrom != "" {
+		cfg.Notifications.SMTP.From = smtpFrom
+	}
+	if webhookSecret := os.Getenv("WEBHOOK_SECRET"); webhookSecret != "" {
+		cfg.Notifications.Webhook.Secret = webhookSecret
+	}
+	if rideLinkBase := os.Getenv("RIDE_LINK_BASE"); rideLinkBase != "" {
+		cfg.Notifications.RideLinkBase = rideLinkBase
+	}
+
+	// RabbitMQ settings
+	if rabbitURI := os.Getenv("RABBITMQ_URI"); rabbitURI != "" {
+		cfg.RabbitMQ.URI = rabbitURI
+	}
+	if exchange := os.Getenv("RABBITMQ_EXCHANGE"); exchange != "" {
+		cfg.RabbitMQ.Exchange = exchange
+	}
+
+	// Idempotency settings
+	if ttl := getEnvInt("IDEMPOTENCY_TTL", 0); ttl > 0 {
+		cfg.Idempotency.TTL = ttl
+	}
+
+	// Geocoding settings
+	if gazetteerPath := os.Getenv("GAZETTEER_PATH"); gazetteerPath != "" {
+		cfg.Geocoding.GazetteerPath = gazetteerPath
+	}
+
+	// Fare settings
+	if fuelPrice := getEnvFloat("FARE_FUEL_PRICE_PER_LITER", 0); fuelPrice > 0 {
+		cfg.Fares.FuelPricePerLiter = fuelPrice
+	}
+	if consumption := getEnvFloat("FARE_LITERS_PER_100KM", 0); consumption > 0 {
+		cfg.Fares.LitersPer100Km = consumption
+	}
+	if wear := getEnvFloat("FARE_WEAR_COST_PER_KM", -1); wear >= 0 {
+		cfg.Fares.WearCostPerKm = wear
+	}
+	if currency := os.Getenv("FARE_CURRENCY"); currency != "" {
+		cfg.Fares.Currency = currency
+	}
+
+	// Impact settings
+	if gridFactor := getEnvFloat("IMPACT_GRID_KG_CO2_PER_KWH", -1); gridFactor >= 0 {
+		cfg.Impact.GridKgCO2PerKWh = gridFactor
+	}
+
+	// Calendar settings
+	if feedBase := os.Getenv("CALENDAR_FEED_BASE"); feedBase != "" {
+		cfg.Calendar.FeedBase = feedBase
+	}
+
+	// Document settings
+	if storageDir := os.Getenv("DOCUMENT_STORAGE_DIR"); storageDir != "" {
+		cfg.Documents.StorageDir = storageDir
+	}
+	if required := os.Getenv("DOCUMENTS_REQUIRED_FOR_HOSTING"); required != "" {
+		cfg.Documents.RequiredForHosting = required == "true"
+	}
+
+	// Contract validation settings
+	if specPath := os.Getenv("OPENAPI_SPEC_PATH"); specPath != "" {
+		cfg.Contract.SpecPath = specPath
+	}
+	if mode := os.Getenv("CONTRACT_MODE"); mode != "" {
+		cfg.Contract.Mode = mode
+	}
 }
 
 // getEnvInt gets an environment variable as an integer
@@ -168,4 +451,14 @@ func getEnvInt(key string, defaultVal int) int {
 		}
 	}
 	return defaultVal
+}
+
+// getEnvFloat gets an environment variable as a float
+func getEnvFloat(key string, defaultVal float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultVal
 }
\ No newline at end of file
@@ -5,11 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/config"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/metrics"
 	_ "github.com/lib/pq"
 )
 
@@ -39,8 +40,8 @@ func NewDBManager(cfg *config.DatabaseConfig) (*DBManager, error) {
 	for _, replicaCfg := range cfg.Replicas {
 		replicaDB, err := connectToDB(replicaCfg)
 		if (err != nil) {
-			log.Printf("Warning: failed to connect to replica db %s:%s: %v", 
-				replicaCfg.Host, replicaCfg.Port, err)
+			slog.Warn("Failed to connect to replica database",
+				"host", replicaCfg.Host, "port", replicaCfg.Port, "error", err)
 			continue
 		}
 
@@ -104,6 +105,23 @@ func (m *DBManager) GetReplica() *sql.DB {
 	return db
 }
 
+// Pools returns the primary and every replica, keyed by the names they are
+// reported under in metrics and readiness checks
+func (m *DBManager) Pools() map[string]*sql.DB {
+	pools := map[string]*sql.DB{"primary": m.primary}
+	for i, replica := range m.replicas {
+		pools[fmt.Sprintf("replica-%d", i)] = replica
+	}
+	return pools
+}
+
+// WatchPools exports the pool statistics of the primary and every replica
+func (m *DBManager) WatchPools() {
+	for name, pool := range m.Pools() {
+		metrics.WatchDB(name, pool)
+	}
+}
+
 // Close closes all database connections
 func (m *DBManager) Close() {
 	if m.primary != nil {
@@ -115,6 +133,25 @@ func (m *DBManager) Close() {
 	}
 }
 
+// WithTx runs fn in a transaction on the primary database. The transaction
+// is committed if fn returns nil and rolled back otherwise.
+func (m *DBManager) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.primary.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
 // ExecContext executes a query on the primary database
 func (m *DBManager) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	return m.primary.ExecContext(ctx, query, args...)
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+)
+
+// migrationLockKey is the advisory lock held while migrating, so instances
+// starting together apply each migration once
+const migrationLockKey = 727002
+
+// Migrate applies the .sql files in fsys that have not been applied yet, in
+// name order and each in its own transaction, and records them in
+// schema_migrations
+func Migrate(ctx context.Context, db *sql.DB, fsys fs.FS) error {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return fmt.Errorf("error listing migrations: %w", err)
+	}
+
+	// The lock belongs to the session, so every statement must use one connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting a connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("error taking migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	for _, name := range names {
+		var applied bool
+		err := conn.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)", name).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("error checking migration %s: %w", name, err)
+		}
+		if applied {
+			continue
+		}
+
+		if err := applyMigration(ctx, conn, fsys, name); err != nil {
+			return err
+		}
+		slog.Info("Applied database migration", "name", name)
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, fsys fs.FS, name string) error {
+	body, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("error reading migration %s: %w", name, err)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+		return fmt.Errorf("error applying migration %s: %w", name, err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", name); err != nil {
+		return fmt.Errorf("error recording migration %s: %w", name, err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,107 @@
+// Package document holds the rules for the verification documents hosts must
+// upload before offering rides: which documents are needed, which files are
+// accepted and when a document counts as valid.
+package document
+
+import (
+	"net/http"
+	"time"
+)
+
+// Document types. Licenses belong to a driver, the others to a vehicle.
+const (
+	TypeDriverLicense       = "driver_license"
+	TypeVehicleRegistration = "vehicle_registration"
+	TypeVehicleInsurance    = "vehicle_insurance"
+)
+
+// Review statuses
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// DateFormat is how expiry dates are written
+const DateFormat = "2006-01-02"
+
+// DriverTypes are the documents a host needs for themselves
+var DriverTypes = []string{TypeDriverLicense}
+
+// VehicleTypes are the documents a host needs for the vehicle they drive
+var VehicleTypes = []string{TypeVehicleRegistration, TypeVehicleInsurance}
+
+// contentTypes are the file formats accepted, as sniffed from their contents
+var contentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+// ValidType reports whether t is a known document type
+func ValidType(t string) bool {
+	return t == TypeDriverLicense || IsVehicleType(t)
+}
+
+// IsVehicleType reports whether documents of type t belong to a vehicle
+func IsVehicleType(t string) bool {
+	for _, v := range VehicleTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Label names a document type in messages to users
+func Label(t string) string {
+	switch t {
+	case TypeDriverLicense:
+		return "driver's license"
+	case TypeVehicleRegistration:
+		return "vehicle registration"
+	case TypeVehicleInsurance:
+		return "vehicle insurance"
+	default:
+		return "document"
+	}
+}
+
+// ContentType sniffs the format of a file from its first bytes and reports
+// whether it is accepted. The name a client gives a file is not trusted.
+func ContentType(head []byte) (string, bool) {
+	contentType := http.DetectContentType(head)
+	return contentType, contentTypes[contentType]
+}
+
+// Summary is what decides whether a document is valid
+type Summary struct {
+	Type      string
+	Status    string
+	ExpiresOn time.Time // Date the document expires, in UTC
+}
+
+// Valid reports whether the document is approved and not expired at now.
+// A document is valid through the whole of its expiry date.
+func (s Summary) Valid(now time.Time) bool {
+	return s.Status == StatusApproved && now.Before(s.ExpiresOn.AddDate(0, 0, 1))
+}
+
+// Missing returns the types in required that no document in docs makes
+// valid at now, in the order required lists them
+func Missing(required []string, docs []Summary, now time.Time) []string {
+	valid := map[string]bool{}
+	for _, doc := range docs {
+		if doc.Valid(now) {
+			valid[doc.Type] = true
+		}
+	}
+
+	var missing []string
+	for _, t := range required {
+		if !valid[t] {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,62 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMissing(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+
+	docs := []Summary{
+		// Expires today, so still valid until midnight
+		{Type: TypeVehicleRegistration, Status: StatusApproved, ExpiresOn: day(10)},
+		// Expired yesterday
+		{Type: TypeVehicleInsurance, Status: StatusApproved, ExpiresOn: day(9)},
+		// Not approved yet
+		{Type: TypeVehicleInsurance, Status: StatusPending, ExpiresOn: day(30)},
+		{Type: TypeDriverLicense, Status: StatusRejected, ExpiresOn: day(30)},
+	}
+
+	required := append(append([]string{}, DriverTypes...), VehicleTypes...)
+	got := Missing(required, docs, now)
+	want := []string{TypeDriverLicense, TypeVehicleInsurance}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v missing, got %v", want, got)
+	}
+
+	docs = append(docs, Summary{Type: TypeDriverLicense, Status: StatusApproved, ExpiresOn: day(30)},
+		Summary{Type: TypeVehicleInsurance, Status: StatusApproved, ExpiresOn: day(11)})
+	if got := Missing(required, docs, now); len(got) != 0 {
+		t.Errorf("expected nothing missing, got %v", got)
+	}
+}
+
+func TestContentType(t *testing.T) {
+	for name, tc := range map[string]struct {
+		head []byte
+		want string
+		ok   bool
+	}{
+		"pdf":  {[]byte("%PDF-1.7\n"), "application/pdf", true},
+		"png":  {[]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png", true},
+		"jpeg": {[]byte("\xff\xd8\xff\xe0\x00\x10JFIF"), "image/jpeg", true},
+		"html": {[]byte("<html><body>"), "text/html; charset=utf-8", false},
+	} {
+		got, ok := ContentType(tc.head)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%s: expected %q %v, got %q %v", name, tc.want, tc.ok, got, ok)
+		}
+	}
+}
+
+func TestTypes(t *testing.T) {
+	if !ValidType(TypeDriverLicense) || !ValidType(TypeVehicleInsurance) || ValidType("passport") {
+		t.Errorf("unexpected ValidType results")
+	}
+	if IsVehicleType(TypeDriverLicense) || !IsVehicleType(TypeVehicleRegistration) {
+		t.Errorf("unexpected IsVehicleType results")
+	}
+}
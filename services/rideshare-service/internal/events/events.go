@@ -0,0 +1,168 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type identifies a domain event. It doubles as the RabbitMQ routing key.
+type Type string
+
+const (
+	RideCreated      Type = "ride.created"
+	RideUpdated      Type = "ride.updated"
+	RideCancelled    Type = "ride.cancelled"
+	RideCompleted    Type = "ride.completed"
+	RideStartingSoon Type = "ride.starting_soon"
+
+	RequestCreated  Type = "ride_request.created"
+	RequestAccepted Type = "ride_request.accepted"
+	RequestRejected Type = "ride_request.rejected"
+
+	VehicleCreated     Type = "vehicle.created"
+	VehicleUpdated     Type = "vehicle.updated"
+	VehicleDeactivated Type = "vehicle.deactivated"
+
+	SavedSearchMatched Type = "saved_search.matched"
+
+	DocumentUploaded Type = "document.uploaded"
+	DocumentApproved Type = "document.approved"
+	DocumentRejected Type = "document.rejected"
+	DocumentExpiring Type = "document.expiring"
+)
+
+// Aggregates that events are ordered by
+const (
+	AggregateRide        = "ride"
+	AggregateRideRequest = "ride_request"
+	AggregateVehicle     = "vehicle"
+	AggregateSavedSearch = "saved_search"
+	AggregateDocument    = "document"
+)
+
+// Event is the envelope every domain event is published in. ID is stable
+// across redeliveries, so consumers can use it to drop duplicates.
+type Event struct {
+	ID            uuid.UUID       `json:"id"`
+	Type          Type            `json:"type"`
+	Version       int             `json:"version"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   uuid.UUID       `json:"aggregateId"`
+	// Sequence increases with every event written; set by the outbox relay
+	Sequence   int64           `json:"sequence,omitempty"`
+	ActorID    *uuid.UUID      `json:"actorId,omitempty"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// New creates an event with a fresh ID, encoding data with the current
+// schema version for its type
+func New(eventType Type, aggregateType string, aggregateID uuid.UUID, actorID *uuid.UUID, data interface{}) (Event, error) {
+	version, ok := schemaVersions[eventType]
+	if !ok {
+		return Event{}, fmt.Errorf("unknown event type: %s", eventType)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("error encoding %s event: %w", eventType, err)
+	}
+
+	return Event{
+		ID:            uuid.New(),
+		Type:          eventType,
+		Version:       version,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		ActorID:       actorID,
+		OccurredAt:    time.Now().UTC(),
+		Data:          raw,
+	}, nil
+}
+
+// Decode unmarshals the event data into v, which must be the schema struct
+// for the event's type and version
+func (e Event) Decode(v interface{}) error {
+	if current, ok := schemaVersions[e.Type]; !ok || e.Version > current {
+		return fmt.Errorf("unsupported %s event version: %d", e.Type, e.Version)
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("error decoding %s event: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Handler reacts to a published event
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher publishes domain events
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Bus is an in-process Publisher that hands every event to each subscribed
+// handler. Handlers run concurrently, and Publish waits for all of them so a
+// failure is reported back to the outbox relay, which then retries the event.
+// Handlers must therefore cope with seeing an event more than once.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates an empty Bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for every event published on the bus
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish dispatches the event to all handlers and returns their joined
+// errors once every handler has finished
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers...)
+	b.mu.RUnlock()
+
+	errs := make([]error, len(handlers))
+	var wg sync.WaitGroup
+	for i, handler := range handlers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := handler(ctx, event); err != nil {
+				errs[i] = fmt.Errorf("error handling %s event %s: %w", event.Type, event.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+type fanout []Publisher
+
+// Fanout returns a Publisher that publishes to each publisher in turn,
+// stopping at the first error
+func Fanout(publishers ...Publisher) Publisher {
+	return fanout(publishers)
+}
+
+func (f fanout) Publish(ctx context.Context, event Event) error {
+	for _, p := range f {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
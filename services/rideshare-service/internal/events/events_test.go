@@ -0,0 +1,37 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBusPublishWaitsForHandlersAndReportsFailures(t *testing.T) {
+	bus := NewBus()
+	var handled atomic.Int32
+	failure := errors.New("inbox unavailable")
+
+	bus.Subscribe(func(ctx context.Context, e Event) error {
+		handled.Add(1)
+		return nil
+	})
+	bus.Subscribe(func(ctx context.Context, e Event) error {
+		handled.Add(1)
+		return failure
+	})
+
+	err := bus.Publish(context.Background(), Event{ID: uuid.New(), Type: RideCreated})
+	if handled.Load() != 2 {
+		t.Errorf("Publish returned after %d of 2 handlers", handled.Load())
+	}
+	if !errors.Is(err, failure) {
+		t.Errorf("Publish = %v, want the handler's error so the relay retries", err)
+	}
+
+	if err := NewBus().Publish(context.Background(), Event{}); err != nil {
+		t.Errorf("Publish without handlers = %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// schemaVersions is the current data schema version of each event type.
+// Bump a version when a change to its schema could break consumers, such as
+// removing or renaming a field; adding optional fields does not need a bump.
+var schemaVersions = map[Type]int{
+	RideCreated:      1,
+	RideUpdated:      1,
+	RideCancelled:    1,
+	RideCompleted:    1,
+	RideStartingSoon: 1,
+
+	RequestCreated:  1,
+	RequestAccepted: 1,
+	RequestRejected: 1,
+
+	VehicleCreated:     1,
+	VehicleUpdated:     1,
+	VehicleDeactivated: 1,
+
+	SavedSearchMatched: 1,
+
+	DocumentUploaded: 1,
+	DocumentApproved: 1,
+	DocumentRejected: 1,
+	DocumentExpiring: 1,
+}
+
+// RideV1 is the data of ride.* events
+type RideV1 struct {
+	RideID             uuid.UUID `json:"rideId"`
+	HostID             uuid.UUID `json:"hostId"`
+	VehicleID          uuid.UUID `json:"vehicleId"`
+	Status             string    `json:"status"`
+	OriginAddress      string    `json:"originAddress"`
+	DestinationAddress string    `json:"destinationAddress"`
+	DepartureTime      time.Time `json:"departureTime"`
+	AvailableSeats     int       `json:"availableSeats"`
+	PricePerSeat       float64   `json:"pricePerSeat"`
+	// TimeZone is the IANA zone the ride is scheduled in
+	TimeZone string `json:"timeZone,omitempty"`
+	// Changes describes what changed, for ride.updated
+	Changes []string `json:"changes,omitempty"`
+}
+
+// RideRequestV1 is the data of ride_request.* events
+type RideRequestV1 struct {
+	RequestID      uuid.UUID `json:"requestId"`
+	RideID         uuid.UUID `json:"rideId"`
+	RiderID        uuid.UUID `json:"riderId"`
+	Status         string    `json:"status"`
+	SeatsRequested int       `json:"seatsRequested"`
+}
+
+// SavedSearchMatchV1 is the data of saved_search.matched events, published
+// once per search the first time a ride matches it
+type SavedSearchMatchV1 struct {
+	SearchID uuid.UUID `json:"searchId"`
+	UserID   uuid.UUID `json:"userId"`
+	RideID   uuid.UUID `json:"rideId"`
+	Name     string    `json:"name,omitempty"`
+}
+
+// DocumentV1 is the data of document.* events. VehicleID is set for vehicle
+// documents, and Reason for rejections.
+type DocumentV1 struct {
+	DocumentID uuid.UUID  `json:"documentId"`
+	UserID     uuid.UUID  `json:"userId"`
+	VehicleID  *uuid.UUID `json:"vehicleId,omitempty"`
+	Type       string     `json:"type"`
+	Status     string     `json:"status"`
+	ExpiresOn  string     `json:"expiresOn"` // YYYY-MM-DD
+	Reason     string     `json:"reason,omitempty"`
+}
+
+// VehicleV1 is the data of vehicle.* events
+type VehicleV1 struct {
+	VehicleID uuid.UUID `json:"vehicleId"`
+	UserID    uuid.UUID `json:"userId"`
+	Make      string    `json:"make"`
+	Model     string    `json:"model"`
+	Year      int       `json:"year"`
+	Capacity  int       `json:"capacity"`
+	IsActive  bool      `json:"isActive"`
+}
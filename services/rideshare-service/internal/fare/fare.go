@@ -0,0 +1,147 @@
+// Package fare prices shared rides at cost. Hosts share what a trip costs
+// to drive with their passengers rather than charging for profit, and each
+// passenger pays for the part of the route they ride plus any detour they
+// add.
+package fare
+
+import (
+	"math"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/geocode"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/route"
+)
+
+// Model is the cost of driving a car
+type Model struct {
+	FuelPricePerLiter float64
+	LitersPer100Km    float64
+	WearCostPerKm     float64 // Tyres, maintenance and depreciation
+	RoadFactor        float64 // Road distance per straight-line distance
+	Currency          string
+}
+
+// CostPerKm is what driving one kilometre costs
+func (m Model) CostPerKm() float64 {
+	return m.FuelPricePerLiter*m.LitersPer100Km/100 + m.WearCostPerKm
+}
+
+// RoadKm estimates the road distance between two points in kilometres
+func (m Model) RoadKm(lat1, lon1, lat2, lon2 float64) float64 {
+	factor := m.RoadFactor
+	if factor < 1 {
+		factor = 1
+	}
+	return geocode.DistanceMeters(lat1, lon1, lat2, lon2) / 1000 * factor
+}
+
+// PathKm estimates the road distance through points in order
+func (m Model) PathKm(points ...route.Point) float64 {
+	km := 0.0
+	for i := 1; i < len(points); i++ {
+		km += m.RoadKm(points[i-1].Lat, points[i-1].Lon, points[i].Lat, points[i].Lon)
+	}
+	return km
+}
+
+// Estimate prices a trip of distanceKm in a car offering seats passenger
+// seats. The suggested price splits the cost between the passengers and the
+// driver, who pays a share too. The maximum price recovers the whole cost
+// from a full car, so hosts never make a profit.
+func (m Model) Estimate(distanceKm float64, seats int) models.FareEstimate {
+	if seats < 1 {
+		seats = 1
+	}
+	tripCost := distanceKm * m.CostPerKm()
+
+	return models.FareEstimate{
+		DistanceKm:            round(distanceKm),
+		CostPerKm:             round(m.CostPerKm()),
+		TripCost:              round(tripCost),
+		Seats:                 seats,
+		SuggestedPricePerSeat: round(tripCost / float64(seats+1)),
+		MaxPricePerSeat:       round(tripCost / float64(seats)),
+		Currency:              m.Currency,
+	}
+}
+
+// MaxPricePerSeat is the most a host may charge per seat for a route
+// through stops
+func (m Model) MaxPricePerSeat(stops []route.Point, seats int) float64 {
+	return m.Estimate(m.PathKm(stops...), seats).MaxPricePerSeat
+}
+
+// Trip is a passenger's part of a ride
+type Trip struct {
+	OriginLat, OriginLon   float64       // Start of the ride
+	DestLat, DestLon       float64       // End of the ride
+	Waypoints              []route.Point // Stops between origin and destination, in order
+	PickupLat, PickupLon   float64
+	DropoffLat, DropoffLon float64
+	// The passenger boards on the leg leaving PickupStop and leaves on the
+	// leg reaching DropoffStop. A zero DropoffStop means the destination.
+	PickupStop, DropoffStop int
+	PricePerSeat            float64
+	Seats                   int
+}
+
+// stops lists every stop of the trip's ride, origin and destination included
+func (trip Trip) stops() []route.Point {
+	stops := make([]route.Point, 0, len(trip.Waypoints)+2)
+	stops = append(stops, route.Point{Lat: trip.OriginLat, Lon: trip.OriginLon})
+	stops = append(stops, trip.Waypoints...)
+	return append(stops, route.Point{Lat: trip.DestLat, Lon: trip.DestLon})
+}
+
+// Share computes a passenger's fair share of a ride. Seats are charged for
+// the fraction of the route between pickup and dropoff, and the detour to
+// collect and drop off the passenger is charged at cost.
+func (m Model) Share(trip Trip) models.FareBreakdown {
+	stops := trip.stops()
+	pickupStop, dropoffStop := trip.PickupStop, trip.DropoffStop
+	if dropoffStop <= 0 || dropoffStop >= len(stops) {
+		dropoffStop = len(stops) - 1
+	}
+	if pickupStop < 0 || pickupStop >= dropoffStop {
+		pickupStop = 0
+	}
+	pickup := route.Point{Lat: trip.PickupLat, Lon: trip.PickupLon}
+	dropoff := route.Point{Lat: trip.DropoffLat, Lon: trip.DropoffLon}
+
+	// The passenger rides past the stops between their own
+	routeKm := m.PathKm(stops...)
+	ridden := append(append([]route.Point{pickup}, stops[pickupStop+1:dropoffStop]...), dropoff)
+	segmentKm := m.PathKm(ridden...)
+
+	share := 1.0
+	if routeKm > 0 {
+		share = math.Min(segmentKm/routeKm, 1)
+	}
+
+	// Going via the pickup and dropoff instead of straight along the route
+	viaKm := m.PathKm(append(append([]route.Point{}, stops[:pickupStop+1]...), pickup)...) +
+		segmentKm +
+		m.PathKm(append([]route.Point{dropoff}, stops[dropoffStop:]...)...)
+	detourKm := math.Max(viaKm-routeKm, 0)
+
+	segmentFare := trip.PricePerSeat * float64(trip.Seats) * share
+	detourFare := detourKm * m.CostPerKm()
+
+	return models.FareBreakdown{
+		PricePerSeat: trip.PricePerSeat,
+		Seats:        trip.Seats,
+		RouteKm:      round(routeKm),
+		SegmentKm:    round(segmentKm),
+		SegmentShare: round(share),
+		SegmentFare:  round(segmentFare),
+		DetourKm:     round(detourKm),
+		DetourFare:   round(detourFare),
+		Total:        round(segmentFare + detourFare),
+		Currency:     m.Currency,
+	}
+}
+
+// round rounds to cents, or hundredths of a kilometre
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
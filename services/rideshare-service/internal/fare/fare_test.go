@@ -0,0 +1,91 @@
+package fare
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/route"
+)
+
+var testModel = Model{
+	FuelPricePerLiter: 1.5,
+	LitersPer100Km:    8,
+	WearCostPerKm:     0.08,
+	RoadFactor:        1,
+	Currency:          "USD",
+}
+
+func TestEstimateNeverAllowsProfit(t *testing.T) {
+	estimate := testModel.Estimate(100, 3)
+
+	if estimate.CostPerKm != 0.2 || estimate.TripCost != 20 {
+		t.Fatalf("cost = %v/km, %v per trip", estimate.CostPerKm, estimate.TripCost)
+	}
+	// The driver pays a share of the suggested price
+	if estimate.SuggestedPricePerSeat != 5 {
+		t.Errorf("suggested price = %v, want 5", estimate.SuggestedPricePerSeat)
+	}
+	// A full car at the maximum price only covers the cost
+	if got := estimate.MaxPricePerSeat * float64(estimate.Seats); math.Abs(got-estimate.TripCost) > 0.01 {
+		t.Errorf("full car at the maximum price pays %v for a %v trip", got, estimate.TripCost)
+	}
+}
+
+func TestShareChargesForSegmentAndDetour(t *testing.T) {
+	// A ride due north along a meridian, about 111 km per degree
+	trip := Trip{
+		OriginLat: 30, OriginLon: 31,
+		DestLat: 31, DestLon: 31,
+		PickupLat: 30.5, PickupLon: 31,
+		DropoffLat: 31, DropoffLon: 31,
+		PricePerSeat: 10,
+		Seats:        2,
+	}
+
+	onRoute := testModel.Share(trip)
+	if onRoute.SegmentShare != 0.5 || onRoute.SegmentFare != 10 {
+		t.Errorf("half the route for 2 seats = %+v", onRoute)
+	}
+	if onRoute.DetourKm != 0 || onRoute.Total != onRoute.SegmentFare {
+		t.Errorf("a pickup on the route should add no detour: %+v", onRoute)
+	}
+
+	// A pickup off to the side costs the extra distance driven
+	trip.PickupLon = 31.1
+	detour := testModel.Share(trip)
+	if detour.DetourKm <= 0 || detour.Total <= onRoute.Total {
+		t.Errorf("an off-route pickup should add a detour: %+v", detour)
+	}
+	if math.Abs(detour.DetourFare-detour.DetourKm*testModel.CostPerKm()) > 0.01 {
+		t.Errorf("detour charged %v for %v km", detour.DetourFare, detour.DetourKm)
+	}
+}
+
+func TestShareFollowsWaypoints(t *testing.T) {
+	// A ride that swings east through a waypoint, with legs of equal length
+	trip := Trip{
+		OriginLat: 30, OriginLon: 31,
+		DestLat: 31, DestLon: 31,
+		Waypoints: []route.Point{{Lat: 30.5, Lon: 31.5}},
+		PickupLat: 30.5, PickupLon: 31.5,
+		DropoffLat: 31, DropoffLon: 31,
+		PickupStop:   1,
+		PricePerSeat: 10,
+		Seats:        1,
+	}
+
+	fromWaypoint := testModel.Share(trip)
+	if fromWaypoint.SegmentShare != 0.5 || fromWaypoint.DetourKm != 0 {
+		t.Errorf("second of two equal legs = %+v", fromWaypoint)
+	}
+	if want := testModel.PathKm(route.Point{Lat: 30, Lon: 31}, route.Point{Lat: 30.5, Lon: 31.5}, route.Point{Lat: 31, Lon: 31}); math.Abs(fromWaypoint.RouteKm-want) > 0.01 {
+		t.Errorf("route = %v km, want %v via the waypoint", fromWaypoint.RouteKm, want)
+	}
+
+	// Riding the whole way passes the waypoint rather than cutting across
+	trip.PickupLat, trip.PickupLon, trip.PickupStop = 30, 31, 0
+	whole := testModel.Share(trip)
+	if whole.SegmentShare != 1 || whole.DetourKm != 0 {
+		t.Errorf("whole route = %+v", whole)
+	}
+}
@@ -0,0 +1,254 @@
+package geocode
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+)
+
+// DefaultLimit is the number of results returned when no limit is given
+const DefaultLimit = 10
+
+// defaultGazetteer covers the campus and the landmarks around it, and is used
+// when no gazetteer file is configured
+//
+//go:embed gazetteer.json
+var defaultGazetteer []byte
+
+// gazetteerFile is the format of a gazetteer file
+type gazetteerFile struct {
+	Places []models.Place `json:"places"`
+}
+
+// Gazetteer is an offline Geocoder over a fixed list of places, such as
+// campus buildings, dorms and city landmarks
+type Gazetteer struct {
+	places []models.Place
+	byID   map[string]int
+	terms  [][]string          // Normalized name and aliases of each place
+	words  []map[string]bool   // Every word of each place's name, aliases and address
+	index  []autocompleteEntry // Sorted by key
+}
+
+// autocompleteEntry maps a name, an alias, or the part of one starting at a
+// word, to a place
+type autocompleteEntry struct {
+	key   string
+	place int
+	start bool // key is the whole name or alias
+}
+
+// LoadGazetteer reads a gazetteer file, or the bundled gazetteer when path is empty
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	data := defaultGazetteer
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("error reading gazetteer: %w", err)
+		}
+	}
+
+	var file gazetteerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing gazetteer: %w", err)
+	}
+	return NewGazetteer(file.Places)
+}
+
+// NewGazetteer indexes places. IDs must be unique and every place needs a
+// name and valid coordinates.
+func NewGazetteer(places []models.Place) (*Gazetteer, error) {
+	g := &Gazetteer{
+		places: places,
+		byID:   make(map[string]int, len(places)),
+		terms:  make([][]string, len(places)),
+		words:  make([]map[string]bool, len(places)),
+	}
+
+	for i, place := range places {
+		if place.ID == "" || place.Name == "" {
+			return nil, fmt.Errorf("gazetteer place %d has no ID or name", i)
+		}
+		if _, ok := g.byID[place.ID]; ok {
+			return nil, fmt.Errorf("duplicate gazetteer place %q", place.ID)
+		}
+		if place.Latitude < -90 || place.Latitude > 90 || place.Longitude < -180 || place.Longitude > 180 {
+			return nil, fmt.Errorf("gazetteer place %q has invalid coordinates", place.ID)
+		}
+		g.byID[place.ID] = i
+
+		g.words[i] = map[string]bool{}
+		for _, word := range tokens(place.Address) {
+			g.words[i][word] = true
+		}
+		for _, term := range append([]string{place.Name}, place.Aliases...) {
+			words := tokens(term)
+			if len(words) == 0 {
+				continue
+			}
+			g.terms[i] = append(g.terms[i], strings.Join(words, " "))
+			for j, word := range words {
+				g.words[i][word] = true
+				g.index = append(g.index, autocompleteEntry{
+					key:   strings.Join(words[j:], " "),
+					place: i,
+					start: j == 0,
+				})
+			}
+		}
+	}
+
+	sort.Slice(g.index, func(a, b int) bool {
+		return g.index[a].key < g.index[b].key
+	})
+	return g, nil
+}
+
+// Lookup returns the place with the given ID
+func (g *Gazetteer) Lookup(ctx context.Context, placeID string) (*models.Place, error) {
+	i, ok := g.byID[placeID]
+	if !ok {
+		return nil, models.ErrPlaceNotFound
+	}
+	place := g.places[i]
+	return &place, nil
+}
+
+// Forward ranks places against a query. In order of preference a place
+// matches when the query is its name, alias or address; when the query
+// starts with its name or alias, as in "Butler Library, New York"; when every
+// word of the query appears in its name, aliases or address; or when its
+// name or alias starts with the query.
+func (g *Gazetteer) Forward(ctx context.Context, query string, limit int) ([]models.Place, error) {
+	q := normalize(query)
+	if q == "" {
+		return []models.Place{}, nil
+	}
+	queryWords := tokens(q)
+
+	type scored struct {
+		place int
+		score int
+	}
+	var matches []scored
+	for i := range g.places {
+		score := 0
+		if normalize(g.places[i].Address) == q {
+			score = 4
+		}
+		for _, term := range g.terms[i] {
+			switch {
+			case term == q:
+				score = max(score, 4)
+			case strings.HasPrefix(q, term+" "):
+				score = max(score, 3)
+			case strings.HasPrefix(term, q):
+				score = max(score, 1)
+			}
+		}
+		if score < 2 && containsAll(g.words[i], queryWords) {
+			score = 2
+		}
+		if score > 0 {
+			matches = append(matches, scored{place: i, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score > matches[b].score
+		}
+		return g.places[matches[a].place].Name < g.places[matches[b].place].Name
+	})
+
+	places := []models.Place{}
+	for _, match := range matches {
+		if len(places) == resultLimit(limit) {
+			break
+		}
+		places = append(places, g.places[match.place])
+	}
+	return places, nil
+}
+
+// Reverse returns the places within radiusMeters of a point, nearest first
+func (g *Gazetteer) Reverse(ctx context.Context, lat, lon, radiusMeters float64, limit int) ([]models.PlaceMatch, error) {
+	matches := []models.PlaceMatch{}
+	for _, place := range g.places {
+		distance := DistanceMeters(lat, lon, place.Latitude, place.Longitude)
+		if distance <= radiusMeters {
+			matches = append(matches, models.PlaceMatch{Place: place, DistanceMeters: distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		return matches[a].DistanceMeters < matches[b].DistanceMeters
+	})
+	if len(matches) > resultLimit(limit) {
+		matches = matches[:resultLimit(limit)]
+	}
+	return matches, nil
+}
+
+// Autocomplete returns places with a name or alias, or a word in one, that
+// starts with prefix. Places whose whole name or alias matches come first.
+func (g *Gazetteer) Autocomplete(ctx context.Context, prefix string, limit int) ([]models.Place, error) {
+	p := normalize(prefix)
+	if p == "" {
+		return []models.Place{}, nil
+	}
+
+	// Keep the best entry of each place
+	best := map[int]autocompleteEntry{}
+	for i := sort.Search(len(g.index), func(i int) bool { return g.index[i].key >= p }); i < len(g.index); i++ {
+		entry := g.index[i]
+		if !strings.HasPrefix(entry.key, p) {
+			break
+		}
+		if current, ok := best[entry.place]; !ok || (entry.start && !current.start) {
+			best[entry.place] = entry
+		}
+	}
+
+	entries := make([]autocompleteEntry, 0, len(best))
+	for _, entry := range best {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].start != entries[b].start {
+			return entries[a].start
+		}
+		return g.places[entries[a].place].Name < g.places[entries[b].place].Name
+	})
+
+	places := []models.Place{}
+	for _, entry := range entries {
+		if len(places) == resultLimit(limit) {
+			break
+		}
+		places = append(places, g.places[entry.place])
+	}
+	return places, nil
+}
+
+func containsAll(words map[string]bool, wanted []string) bool {
+	for _, word := range wanted {
+		if !words[word] {
+			return false
+		}
+	}
+	return true
+}
+
+func resultLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	return limit
+}
@@ -0,0 +1,119 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+)
+
+func testGazetteer(t *testing.T) *Gazetteer {
+	t.Helper()
+	g, err := NewGazetteer([]models.Place{
+		{ID: "butler", Name: "Butler Library", Kind: "building", Address: "535 W 114th St, New York", Latitude: 40.8064, Longitude: -73.9632},
+		{ID: "john-jay", Name: "John Jay Hall", Kind: "dorm", Address: "519 W 114th St, New York", Latitude: 40.8061, Longitude: -73.9618, Aliases: []string{"John Jay"}},
+		{ID: "jfk", Name: "John F. Kennedy International Airport", Kind: "landmark", Address: "Queens, NY", Latitude: 40.6413, Longitude: -73.7781, Aliases: []string{"JFK"}},
+		{ID: "times-square", Name: "Times Square", Kind: "landmark", Address: "Broadway & 7th Ave, New York", Latitude: 40.7580, Longitude: -73.9855},
+	})
+	if err != nil {
+		t.Fatalf("NewGazetteer: %v", err)
+	}
+	return g
+}
+
+func placeIDs(places []models.Place) []string {
+	ids := make([]string, len(places))
+	for i, place := range places {
+		ids[i] = place.ID
+	}
+	return ids
+}
+
+func TestForwardRanksExactAndLeadingNamesFirst(t *testing.T) {
+	g := testGazetteer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		query string
+		first string
+	}{
+		{"JFK", "jfk"},
+		{"Butler Library, New York", "butler"},
+		{"535 W 114th St, New York", "butler"},
+		{"114th st", "butler"},
+		{"times", "times-square"},
+	}
+	for _, tt := range tests {
+		places, err := g.Forward(ctx, tt.query, 0)
+		if err != nil {
+			t.Fatalf("Forward(%q): %v", tt.query, err)
+		}
+		if len(places) == 0 || places[0].ID != tt.first {
+			t.Errorf("Forward(%q) = %v, want %s first", tt.query, placeIDs(places), tt.first)
+		}
+	}
+
+	places, _ := g.Forward(ctx, "nowhere at all", 0)
+	if len(places) != 0 {
+		t.Errorf("Forward of an unknown address = %v", placeIDs(places))
+	}
+}
+
+func TestReverseReturnsNearestWithinRadius(t *testing.T) {
+	g := testGazetteer(t)
+
+	matches, err := g.Reverse(context.Background(), 40.8063, -73.9630, 500, 0)
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Place.ID != "butler" || matches[1].Place.ID != "john-jay" {
+		t.Fatalf("Reverse = %+v, want butler then john-jay", matches)
+	}
+	if matches[0].DistanceMeters > 50 {
+		t.Errorf("distance to butler = %.0fm", matches[0].DistanceMeters)
+	}
+}
+
+func TestAutocompletePrefersWholeNames(t *testing.T) {
+	g := testGazetteer(t)
+
+	places, err := g.Autocomplete(context.Background(), "jo", 0)
+	if err != nil {
+		t.Fatalf("Autocomplete: %v", err)
+	}
+	if got := placeIDs(places); len(got) != 2 || got[0] != "jfk" || got[1] != "john-jay" {
+		t.Errorf("Autocomplete(jo) = %v", got)
+	}
+
+	// Later words of a name match too, after whole names
+	places, _ = g.Autocomplete(context.Background(), "hall", 1)
+	if got := placeIDs(places); len(got) != 1 || got[0] != "john-jay" {
+		t.Errorf("Autocomplete(hall) = %v", got)
+	}
+}
+
+func TestLookupAndBundledGazetteer(t *testing.T) {
+	g, err := LoadGazetteer("")
+	if err != nil {
+		t.Fatalf("LoadGazetteer: %v", err)
+	}
+
+	place, err := g.Lookup(context.Background(), "butler-library")
+	if err != nil || place.Name != "Butler Library" {
+		t.Errorf("Lookup(butler-library) = %+v, %v", place, err)
+	}
+	if _, err := g.Lookup(context.Background(), "missing"); !errors.Is(err, models.ErrPlaceNotFound) {
+		t.Errorf("Lookup of a missing place returned %v", err)
+	}
+}
+
+func TestNewGazetteerRejectsDuplicateIDs(t *testing.T) {
+	_, err := NewGazetteer([]models.Place{
+		{ID: "a", Name: "A", Latitude: 1, Longitude: 1},
+		{ID: "a", Name: "B", Latitude: 2, Longitude: 2},
+	})
+	if err == nil {
+		t.Error("expected duplicate IDs to be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+// Package geocode turns addresses and place IDs into coordinates and back
+package geocode
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+)
+
+// Geocoder resolves places by ID, by name or address, and by location
+type Geocoder interface {
+	// Lookup returns the place with the given ID, or models.ErrPlaceNotFound
+	Lookup(ctx context.Context, placeID string) (*models.Place, error)
+	// Forward returns the places best matching a free-text query, best first
+	Forward(ctx context.Context, query string, limit int) ([]models.Place, error)
+	// Reverse returns the places within radiusMeters of a point, nearest first
+	Reverse(ctx context.Context, lat, lon, radiusMeters float64, limit int) ([]models.PlaceMatch, error)
+	// Autocomplete returns places whose name, an alias, or a word in either
+	// starts with prefix
+	Autocomplete(ctx context.Context, prefix string, limit int) ([]models.Place, error)
+}
+
+const earthRadiusMeters = 6371000
+
+// DistanceMeters returns the great-circle distance between two points
+func DistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	deltaPhi := (lat2 - lat1) * math.Pi / 180
+	deltaLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return earthRadiusMeters * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// normalize lowercases s and reduces punctuation and runs of spaces to
+// single spaces, so "Butler Library, Columbia" matches "butler library columbia"
+func normalize(s string) string {
+	return strings.Join(tokens(s), " ")
+}
+
+func tokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,168 @@
+// Package ical writes iCalendar (RFC 5545) calendars of rides, for feeds
+// that calendar apps subscribe to and single-event downloads
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of an iCalendar document
+const ContentType = "text/calendar; charset=utf-8"
+
+// Event statuses
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// maxLineOctets is the longest a content line may be before it is folded
+const maxLineOctets = 75
+
+const timeFormat = "20060102T150405Z"
+
+// Calendar is a set of events. Name is shown by apps that subscribe to it,
+// which are asked to refresh it every RefreshInterval.
+type Calendar struct {
+	Name            string
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is a single ride. UID must stay the same across versions of the
+// event so that clients update or cancel the copy they already have.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Status       string
+	Start        time.Time
+	End          time.Time
+	Created      time.Time
+	LastModified time.Time
+}
+
+// Write encodes cal to w
+func Write(w io.Writer, cal Calendar) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", "-//Rideshare//Rides//EN")
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		e.text("X-WR-CALNAME", cal.Name)
+	}
+	if cal.RefreshInterval > 0 {
+		e.line("REFRESH-INTERVAL;VALUE=DURATION", duration(cal.RefreshInterval))
+		e.line("X-PUBLISHED-TTL", duration(cal.RefreshInterval))
+	}
+
+	for _, event := range cal.Events {
+		e.line("BEGIN", "VEVENT")
+		e.text("UID", event.UID)
+		// The stamp is when this version of the event was made, which for a
+		// published calendar is its last change
+		e.line("DTSTAMP", formatTime(event.LastModified))
+		e.line("DTSTART", formatTime(event.Start))
+		e.line("DTEND", formatTime(event.End))
+		if !event.Created.IsZero() {
+			e.line("CREATED", formatTime(event.Created))
+		}
+		e.line("LAST-MODIFIED", formatTime(event.LastModified))
+		e.text("SUMMARY", event.Summary)
+		if event.Location != "" {
+			e.text("LOCATION", event.Location)
+		}
+		if event.Description != "" {
+			e.text("DESCRIPTION", event.Description)
+		}
+		if event.URL != "" {
+			e.line("URL", event.URL)
+		}
+		if event.Status != "" {
+			e.line("STATUS", event.Status)
+		}
+		e.line("END", "VEVENT")
+	}
+
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// Escape escapes a TEXT value
+func Escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// Fold splits a content line into lines of at most 75 octets, each
+// continuation starting with a space. Multi-byte characters are not split.
+func Fold(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines lose an octet to the leading space
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.WriteString(Fold(name+":"+value) + "\r\n")
+}
+
+func (e *encoder) text(name, value string) {
+	e.line(name, Escape(value))
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// duration formats d as a whole number of minutes, which is all refresh
+// intervals need
+func duration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return "PT" + strconv.Itoa(minutes) + "M"
+}
@@ -0,0 +1,79 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2024, 3, 4, 8, 30, 0, 0, time.FixedZone("EET", 2*60*60))
+	cal := Calendar{
+		Name:            "Rides",
+		RefreshInterval: time.Hour,
+		Events: []Event{{
+			UID:          "ride-1@rideshare",
+			Summary:      "Ride to Campus, Gate 2",
+			Description:  "Host: Sara A.\nVehicle: Red Kia; ABC 123",
+			Location:     "Nasr City",
+			Status:       StatusCancelled,
+			Start:        start,
+			End:          start.Add(45 * time.Minute),
+			LastModified: start.Add(-time.Hour),
+		}},
+	}
+
+	var b strings.Builder
+	if err := Write(&b, cal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Rides\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT60M\r\n",
+		"UID:ride-1@rideshare\r\n",
+		"DTSTART:20240304T063000Z\r\n",
+		"DTEND:20240304T071500Z\r\n",
+		"DTSTAMP:20240304T053000Z\r\n",
+		`SUMMARY:Ride to Campus\, Gate 2` + "\r\n",
+		`DESCRIPTION:Host: Sara A.\nVehicle: Red Kia\; ABC 123` + "\r\n",
+		"STATUS:CANCELLED\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "CREATED:") {
+		t.Errorf("expected no CREATED without a creation time")
+	}
+}
+
+func TestFold(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("é", 100)
+	folded := Fold(line)
+
+	lines := strings.Split(folded, "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("expected the line to be folded, got %q", folded)
+	}
+	for i, l := range lines {
+		if len(l) > maxLineOctets {
+			t.Errorf("line %d is %d octets", i, len(l))
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("continuation line %d does not start with a space", i)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(folded, "\r\n ", "")
+	if unfolded != line {
+		t.Errorf("expected unfolding to restore the line, got %q", unfolded)
+	}
+
+	if short := "SUMMARY:Ride"; Fold(short) != short {
+		t.Errorf("expected a short line to be left alone")
+	}
+}
@@ -0,0 +1,66 @@
+// Package idempotency remembers the responses to requests sent with an
+// Idempotency-Key header so that client retries do not repeat side effects
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// PendingTTL bounds how long a claimed key blocks retries when the instance
+// handling the original request dies before recording its response
+const PendingTTL = time.Minute
+
+var (
+	// ErrInProgress is returned while the original request is still running
+	ErrInProgress = errors.New("idempotency: request in progress")
+	// ErrFingerprintMismatch is returned when a key is reused for a different request
+	ErrFingerprintMismatch = errors.New("idempotency: key reused with a different request")
+)
+
+// Response is a recorded response, replayed for retries of the same request
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+	// Withheld is set for responses marked Cache-Control: no-store, which
+	// are not kept; a retry learns the request was handled but not its body
+	Withheld bool `json:"withheld,omitempty"`
+}
+
+// Store records idempotency keys and the responses they produced.
+//
+// A request first claims its key with Begin. Exactly one of any concurrent
+// duplicates wins the claim and runs; it then records its response with
+// Complete, or gives the key up with Release if it failed in a way the
+// client should be able to retry. Keys expire after the store's TTL.
+type Store interface {
+	// Begin claims key for a request with the given fingerprint. It returns
+	// nil when the claim succeeded, the recorded response when the request
+	// already completed, ErrInProgress when a duplicate is still running and
+	// ErrFingerprintMismatch when the key belongs to a different request.
+	Begin(ctx context.Context, key, fingerprint string) (*Response, error)
+	// Complete records the response for a claimed key
+	Complete(ctx context.Context, key string, resp *Response) error
+	// Release gives up a claimed key so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// entry is the stored state of a key
+type entry struct {
+	Fingerprint string    `json:"fingerprint"`
+	Response    *Response `json:"response,omitempty"` // nil while the request is running
+}
+
+// check resolves a Begin call against an existing entry
+func (e *entry) check(fingerprint string) (*Response, error) {
+	if e.Fingerprint != fingerprint {
+		return nil, ErrFingerprintMismatch
+	}
+	if e.Response == nil {
+		return nil, ErrInProgress
+	}
+	return e.Response, nil
+}
@@ -0,0 +1,81 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	entry
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-process Store used in tests and for local runs
+// without Redis. It only deduplicates requests within a single instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]*memoryEntry
+}
+
+// NewMemoryStore creates an in-memory Store using the wall clock
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return NewMemoryStoreWithClock(ttl, time.Now)
+}
+
+// NewMemoryStoreWithClock creates an in-memory Store with a custom clock,
+// which lets tests move time forward
+func NewMemoryStoreWithClock(ttl time.Duration, now func() time.Time) *MemoryStore {
+	return &MemoryStore{
+		ttl:     ttl,
+		now:     now,
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		return e.check(fingerprint)
+	}
+
+	s.entries[key] = &memoryEntry{
+		entry:     entry{Fingerprint: fingerprint},
+		expiresAt: now.Add(min(PendingTTL, s.ttl)),
+	}
+	s.sweep(now)
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, resp *Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.Response = resp
+		e.expiresAt = s.now().Add(s.ttl)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep drops expired keys; the caller must hold the lock
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreLetsOneConcurrentDuplicateRun(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	var claimed, inProgress atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := store.Begin(ctx, "key", "fingerprint")
+			switch {
+			case err == nil && resp == nil:
+				claimed.Add(1)
+			case errors.Is(err, ErrInProgress):
+				inProgress.Add(1)
+			default:
+				t.Errorf("unexpected result %v, %v", resp, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if claimed.Load() != 1 || inProgress.Load() != 19 {
+		t.Errorf("expected 1 claim and 19 in progress, got %d and %d", claimed.Load(), inProgress.Load())
+	}
+}
+
+func TestMemoryStoreReplaysAndRejectsOtherPayloads(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStoreWithClock(time.Hour, func() time.Time { return now })
+	ctx := context.Background()
+
+	if _, err := store.Begin(ctx, "key", "a"); err != nil {
+		t.Fatalf("begin failed: %v", err)
+	}
+	want := &Response{Status: 201, Body: []byte(`{"id":"1"}`)}
+	if err := store.Complete(ctx, "key", want); err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+
+	got, err := store.Begin(ctx, "key", "a")
+	if err != nil || got != want {
+		t.Errorf("expected the recorded response, got %v, %v", got, err)
+	}
+	if _, err := store.Begin(ctx, "key", "b"); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Errorf("expected a fingerprint mismatch, got %v", err)
+	}
+
+	// The key can be reused once the window has passed
+	now = now.Add(2 * time.Hour)
+	if got, err := store.Begin(ctx, "key", "b"); got != nil || err != nil {
+		t.Errorf("expected a fresh claim after expiry, got %v, %v", got, err)
+	}
+}
+
+func TestMemoryStoreReleaseAllowsRetry(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStoreWithClock(time.Hour, func() time.Time { return now })
+	ctx := context.Background()
+
+	store.Begin(ctx, "key", "a")
+	if err := store.Release(ctx, "key"); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	if got, err := store.Begin(ctx, "key", "a"); got != nil || err != nil {
+		t.Errorf("expected a fresh claim after release, got %v, %v", got, err)
+	}
+
+	// An abandoned claim stops blocking retries after PendingTTL
+	now = now.Add(PendingTTL)
+	if got, err := store.Begin(ctx, "key", "a"); got != nil || err != nil {
+		t.Errorf("expected a fresh claim after the pending claim expired, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type redisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisStore creates a Store backed by TTL'd Redis keys. Claims use SET NX,
+// so duplicates are detected across every instance sharing the Redis.
+func NewRedisStore(client *redis.Client, ttl time.Duration) Store {
+	return &redisStore{client: client, ttl: ttl}
+}
+
+func redisKey(key string) string {
+	return "idempotency:" + key
+}
+
+func (s *redisStore) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	pending, err := json.Marshal(entry{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding idempotency key: %w", err)
+	}
+
+	claimed, err := s.client.SetNX(ctx, redisKey(key), pending, min(PendingTTL, s.ttl)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error claiming idempotency key: %w", err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	raw, err := s.client.Get(ctx, redisKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Expired between the two calls; the caller may retry the claim
+		return nil, ErrInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading idempotency key: %w", err)
+	}
+
+	var existing entry
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return nil, fmt.Errorf("error decoding idempotency key: %w", err)
+	}
+	return existing.check(fingerprint)
+}
+
+func (s *redisStore) Complete(ctx context.Context, key string, resp *Response) error {
+	raw, err := s.client.Get(ctx, redisKey(key)).Bytes()
+	if err != nil {
+		return fmt.Errorf("error reading idempotency key: %w", err)
+	}
+	var e entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return fmt.Errorf("error decoding idempotency key: %w", err)
+	}
+
+	e.Response = resp
+	done, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error encoding idempotency key: %w", err)
+	}
+	if err := s.client.Set(ctx, redisKey(key), done, s.ttl).Err(); err != nil {
+		return fmt.Errorf("error recording idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (s *redisStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, redisKey(key)).Err(); err != nil {
+		return fmt.Errorf("error releasing idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+// Package impact estimates the emissions and money that shared rides save.
+// Each passenger is assumed to have otherwise driven their part of the route
+// alone in a typical car; the detour the host drives to collect them counts
+// against the savings.
+package impact
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/fare"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+)
+
+// Fuel types a vehicle may declare
+const (
+	FuelPetrol   = "petrol"
+	FuelDiesel   = "diesel"
+	FuelHybrid   = "hybrid"
+	FuelElectric = "electric"
+	FuelLPG      = "lpg"
+)
+
+// kg of CO2 emitted burning a liter of fuel
+var emissionFactors = map[string]float64{
+	FuelPetrol: 2.31,
+	FuelDiesel: 2.68,
+	FuelHybrid: 2.31,
+	FuelLPG:    1.51,
+}
+
+// Consumption per 100 km assumed when a vehicle does not give its own, in
+// liters or, for electric vehicles, kWh
+var defaultEfficiency = map[string]float64{
+	FuelPetrol:   7.5,
+	FuelDiesel:   6.0,
+	FuelHybrid:   4.5,
+	FuelLPG:      9.5,
+	FuelElectric: 17,
+}
+
+// Roles a user takes in a ride
+const (
+	RoleHost      = "host"
+	RolePassenger = "passenger"
+)
+
+// Vehicle describes what a car burns. Vehicles without a fuel type are
+// treated as petrol cars.
+type Vehicle struct {
+	FuelType   string
+	Efficiency float64 // Per 100 km; zero uses the fuel type's default
+}
+
+// Model holds the assumptions impact is computed with
+type Model struct {
+	Fares        fare.Model // Cost of driving alone
+	Baseline     Vehicle    // The car a passenger would otherwise drive
+	GridKgPerKWh float64    // Emissions of the electricity charging electric cars
+}
+
+// KgPerKm is the CO2 a vehicle emits per kilometre
+func (m Model) KgPerKm(v Vehicle) float64 {
+	fuel := v.FuelType
+	if _, ok := defaultEfficiency[fuel]; !ok {
+		fuel = FuelPetrol
+	}
+	efficiency := v.Efficiency
+	if efficiency <= 0 {
+		efficiency = defaultEfficiency[fuel]
+	}
+
+	factor := emissionFactors[fuel]
+	if fuel == FuelElectric {
+		factor = m.GridKgPerKWh
+	}
+	return efficiency / 100 * factor
+}
+
+// Passenger is one accepted request on a completed ride
+type Passenger struct {
+	UserID     uuid.UUID
+	Seats      int
+	DistanceKm float64 // Pickup to dropoff
+	DetourKm   float64 // Extra distance the host drove for the passenger
+	FarePaid   float64
+}
+
+// Ride is a completed ride and its passengers
+type Ride struct {
+	HostID     uuid.UUID
+	RouteKm    float64
+	Vehicle    Vehicle
+	Passengers []Passenger
+}
+
+// Share is the impact credited to one participant of a ride
+type Share struct {
+	UserID       uuid.UUID
+	Role         string
+	DistanceKm   float64
+	Seats        int
+	Co2AvoidedKg float64
+	MoneySaved   float64
+}
+
+// Shares computes each participant's impact. Passengers avoid the solo trips
+// their seats would have taken, less the host's detour, and save what those
+// trips would have cost beyond their fare. Hosts are credited with what their
+// passengers avoided and with the fares that covered their costs. Savings
+// are never negative.
+func (m Model) Shares(ride Ride) []Share {
+	baseline := m.KgPerKm(m.Baseline)
+	vehicle := m.KgPerKm(ride.Vehicle)
+	costPerKm := m.Fares.CostPerKm()
+
+	host := Share{UserID: ride.HostID, Role: RoleHost, DistanceKm: round(ride.RouteKm)}
+	shares := []Share{}
+	for _, p := range ride.Passengers {
+		soloKm := float64(p.Seats) * p.DistanceKm
+		share := Share{
+			UserID:       p.UserID,
+			Role:         RolePassenger,
+			DistanceKm:   round(p.DistanceKm),
+			Seats:        p.Seats,
+			Co2AvoidedKg: round(math.Max(0, soloKm*baseline-p.DetourKm*vehicle)),
+			MoneySaved:   round(math.Max(0, soloKm*costPerKm-p.FarePaid)),
+		}
+		shares = append(shares, share)
+
+		host.Seats += p.Seats
+		host.Co2AvoidedKg += share.Co2AvoidedKg
+		host.MoneySaved += p.FarePaid
+	}
+	host.Co2AvoidedKg = round(host.Co2AvoidedKg)
+	host.MoneySaved = round(host.MoneySaved)
+
+	return append([]Share{host}, shares...)
+}
+
+// Periods impact can be totalled over, by how far back they reach
+var periods = map[string]time.Duration{
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+// Intervals a series of totals can be bucketed by
+var intervals = map[string]bool{"day": true, "week": true, "month": true}
+
+// Window selects the completed rides to total and how to bucket them
+type Window struct {
+	Period   string
+	Since    *time.Time // Nil covers all time
+	Interval string     // Empty for no series
+}
+
+// ParseWindow checks a period and interval, defaulting to all time with no
+// series
+func ParseWindow(period, interval string, now time.Time) (Window, error) {
+	if period == "" {
+		period = "all"
+	}
+	lookback, ok := periods[period]
+	if !ok {
+		return Window{}, apperror.Invalid("period", "unknown_period", fmt.Sprintf("unknown period %q", period))
+	}
+	if interval != "" && !intervals[interval] {
+		return Window{}, apperror.Invalid("interval", "unknown_interval", fmt.Sprintf("unknown interval %q", interval))
+	}
+
+	window := Window{Period: period, Interval: interval}
+	if lookback > 0 {
+		since := now.Add(-lookback)
+		window.Since = &since
+	}
+	return window, nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,104 @@
+package impact
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/fare"
+	"github.com/google/uuid"
+)
+
+var testModel = Model{
+	Fares:        fare.Model{FuelPricePerLiter: 1.5, LitersPer100Km: 8, WearCostPerKm: 0.08, RoadFactor: 1},
+	Baseline:     Vehicle{FuelType: FuelPetrol},
+	GridKgPerKWh: 0.4,
+}
+
+func TestKgPerKm(t *testing.T) {
+	tests := []struct {
+		vehicle Vehicle
+		want    float64
+	}{
+		{Vehicle{}, 0.17325},
+		{Vehicle{FuelType: FuelDiesel, Efficiency: 5}, 0.134},
+		{Vehicle{FuelType: FuelElectric}, 0.068},
+		{Vehicle{FuelType: "steam"}, 0.17325},
+	}
+	for _, tt := range tests {
+		if got := testModel.KgPerKm(tt.vehicle); round(got*1000) != round(tt.want*1000) {
+			t.Errorf("KgPerKm(%+v) = %v, want %v", tt.vehicle, got, tt.want)
+		}
+	}
+}
+
+func TestSharesCreditPassengersAndHost(t *testing.T) {
+	hostID, riderID := uuid.New(), uuid.New()
+	shares := testModel.Shares(Ride{
+		HostID:  hostID,
+		RouteKm: 120,
+		Vehicle: Vehicle{FuelType: FuelElectric},
+		Passengers: []Passenger{
+			{UserID: riderID, Seats: 2, DistanceKm: 100, DetourKm: 10, FarePaid: 15},
+		},
+	})
+
+	if len(shares) != 2 {
+		t.Fatalf("got %d shares, want 2", len(shares))
+	}
+	host, rider := shares[0], shares[1]
+
+	// Two solo petrol trips of 100 km, less a 10 km electric detour
+	if rider.Co2AvoidedKg != 33.97 {
+		t.Errorf("passenger avoided %v kg, want 33.97", rider.Co2AvoidedKg)
+	}
+	// Driving 200 km alone costs 40
+	if rider.MoneySaved != 25 {
+		t.Errorf("passenger saved %v, want 25", rider.MoneySaved)
+	}
+	if host.Role != RoleHost || host.UserID != hostID || host.Seats != 2 {
+		t.Errorf("host share = %+v", host)
+	}
+	if host.Co2AvoidedKg != rider.Co2AvoidedKg || host.MoneySaved != 15 {
+		t.Errorf("host credited %v kg and %v", host.Co2AvoidedKg, host.MoneySaved)
+	}
+}
+
+func TestSharesAreNeverNegative(t *testing.T) {
+	shares := testModel.Shares(Ride{
+		HostID:  uuid.New(),
+		RouteKm: 10,
+		Passengers: []Passenger{
+			{UserID: uuid.New(), Seats: 1, DistanceKm: 1, DetourKm: 20, FarePaid: 5},
+		},
+	})
+	if rider := shares[1]; rider.Co2AvoidedKg != 0 || rider.MoneySaved != 0 {
+		t.Errorf("passenger share = %+v, want no savings", rider)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+
+	window, err := ParseWindow("", "", now)
+	if err != nil || window.Period != "all" || window.Since != nil {
+		t.Errorf("default window = %+v, %v", window, err)
+	}
+
+	window, err = ParseWindow("week", "day", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.AddDate(0, 0, -7); window.Since == nil || !window.Since.Equal(want) {
+		t.Errorf("week starts %v, want %v", window.Since, want)
+	}
+	if window.Interval != "day" {
+		t.Errorf("interval = %q", window.Interval)
+	}
+
+	if _, err := ParseWindow("decade", "", now); err == nil {
+		t.Error("unknown period accepted")
+	}
+	if _, err := ParseWindow("month", "hour", now); err == nil {
+		t.Error("unknown interval accepted")
+	}
+}
@@ -0,0 +1,115 @@
+// Package metrics exposes the rideshare service's Prometheus metrics.
+// Requests are labelled with the route template rather than the raw path so
+// IDs in URLs don't create a series per resource.
+package metrics
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rideshare_http_requests_total",
+		Help: "HTTP requests handled, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	duration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rideshare_http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	ridesCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "rideshare_rides_created_total",
+		Help: "Rides posted by hosts.",
+	})
+
+	requestsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "rideshare_ride_requests_created_total",
+		Help: "Requests to join a ride sent by riders.",
+	})
+
+	requestsDecided = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rideshare_ride_requests_decided_total",
+		Help: "Requests to join a ride answered by hosts, by decision (accepted or rejected).",
+	}, []string{"status"})
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the rate, errors and duration of every request router
+// handles, including ones that match no route
+func Middleware(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.MatchErr == nil {
+			if tmpl, err := match.Route.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		router.ServeHTTP(rec, r)
+
+		requests.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// WatchDB exports the connection pool statistics of db, so saturation shows
+// up as connections in use nearing the maximum and a growing wait count
+func WatchDB(name string, db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RideCreated counts a posted ride
+func RideCreated() {
+	ridesCreated.Inc()
+}
+
+// RequestCreated counts a request to join a ride
+func RequestCreated() {
+	requestsCreated.Inc()
+}
+
+// RequestDecided counts a host's answer to a request to join
+func RequestDecided(status string) {
+	requestsDecided.WithLabelValues(status).Inc()
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Hijack lets WebSocket upgrades through the recorder
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
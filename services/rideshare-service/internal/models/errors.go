@@ -0,0 +1,13 @@
+package models
+
+import "platform/shared/apperror"
+
+// Errors returned by more than one service
+var (
+	ErrRideNotFound        = apperror.NotFound("ride_not_found", "ride not found")
+	ErrRideRequestNotFound = apperror.NotFound("ride_request_not_found", "ride request not found")
+	ErrUserNotFound        = apperror.NotFound("user_not_found", "user not found")
+	ErrVehicleNotFound     = apperror.NotFound("vehicle_not_found", "vehicle not found")
+	ErrPlaceNotFound       = apperror.NotFound("place_not_found", "place not found")
+	ErrNotEnoughSeats      = apperror.Conflict("not_enough_seats", "not enough seats available")
+)
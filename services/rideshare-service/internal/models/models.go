@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +16,9 @@ type RideStatus string
 // RequestStatus represents the status of a ride request
 type RequestStatus string
 
+// ReportStatus represents the review state of an abuse report
+type ReportStatus string
+
 const (
 	RoleRider UserRole = "rider"
 	RoleDriver UserRole = "driver"
@@ -29,8 +33,23 @@ const (
 	RequestAccepted  RequestStatus = "accepted"
 	RequestRejected  RequestStatus = "rejected"
 	RequestCancelled RequestStatus = "cancelled"
+
+	ReportPending   ReportStatus = "pending"
+	ReportReviewing ReportStatus = "reviewing"
+	ReportResolved  ReportStatus = "resolved"
+	ReportDismissed ReportStatus = "dismissed"
 )
 
+// ReportCategories lists the accepted abuse report categories
+var ReportCategories = []string{
+	"harassment",
+	"unsafe_driving",
+	"no_show",
+	"inappropriate_behavior",
+	"fraud",
+	"other",
+}
+
 // User represents a user in the system
 type User struct {
 	ID              uuid.UUID  `json:"id" db:"user_id"`
@@ -49,6 +68,7 @@ type User struct {
 	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
 	LastLoginAt     *time.Time `json:"lastLoginAt,omitempty" db:"last_login_at"`
+	TimeZone        string     `json:"timeZone" db:"time_zone"`
 }
 
 // Vehicle represents a vehicle in the system
@@ -61,6 +81,8 @@ type Vehicle struct {
 	Color       string    `json:"color" db:"color"`
 	LicensePlate string   `json:"licensePlate" db:"license_plate"`
 	Capacity    int       `json:"capacity" db:"capacity"`
+	FuelType    string    `json:"fuelType,omitempty" db:"fuel_type"`
+	Efficiency  float64   `json:"efficiency,omitempty" db:"efficiency"` // Liters, or kWh when electric, per 100 km
 	IsActive    bool      `json:"isActive" db:"is_active"`
 	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
@@ -79,8 +101,15 @@ type Ride struct {
 	DestinationLongitude float64   `json:"destinationLongitude" db:"destination_longitude"`
 	DepartureTime       time.Time  `json:"departureTime" db:"departure_time"`
 	EstimatedArrivalTime time.Time `json:"estimatedArrivalTime" db:"estimated_arrival_time"`
+	TimeZone            string     `json:"timeZone" db:"time_zone"`
+	DepartureTimeLocal  string     `json:"departureTimeLocal,omitempty" db:"-"`        // RFC 3339 in TimeZone
+	EstimatedArrivalTimeLocal string `json:"estimatedArrivalTimeLocal,omitempty" db:"-"` // RFC 3339 in TimeZone
 	MaxPassengers       int        `json:"maxPassengers" db:"max_passengers"`
-	AvailableSeats      int        `json:"availableSeats" db:"available_seats"`
+	AvailableSeats      int        `json:"availableSeats" db:"available_seats"` // Free along the whole route
+	Waypoints           []RideStop `json:"waypoints,omitempty" db:"-"`           // Stops between origin and destination, in order
+	LegSeats            []int      `json:"legSeats,omitempty" db:"-"`            // Free on each leg; leg i runs from stop i to stop i+1
+	PickupStop          *int       `json:"pickupStop,omitempty" db:"-"`          // In search results, the stop matching the searched origin
+	DropoffStop         *int       `json:"dropoffStop,omitempty" db:"-"`         // In search results, the stop matching the searched destination
 	PricePerSeat        float64    `json:"pricePerSeat" db:"price_per_seat"`
 	RoutePolyline       *string    `json:"routePolyline,omitempty" db:"route_polyline"`
 	Status              string     `json:"status" db:"status"`
@@ -88,27 +117,50 @@ type Ride struct {
 	LuggageCapacity     *string    `json:"luggageCapacity,omitempty" db:"luggage_capacity"`
 	IsPetsAllowed       bool       `json:"isPetsAllowed" db:"is_pets_allowed"`
 	IsSmokingAllowed    bool       `json:"isSmokingAllowed" db:"is_smoking_allowed"`
+	Audience            Audience   `json:"audience"`
 	CreatedAt           time.Time  `json:"createdAt" db:"created_at"`
 	UpdatedAt           time.Time  `json:"updatedAt" db:"updated_at"`
 }
 
+// RideStop is a stop a ride makes. Stop 0 is the origin and the last stop
+// the destination.
+type RideStop struct {
+	Stop             int       `json:"stop" db:"stop_index"`
+	Address          string    `json:"address" db:"address"`
+	Latitude         float64   `json:"latitude" db:"latitude"`
+	Longitude        float64   `json:"longitude" db:"longitude"`
+	PlannedTime      time.Time `json:"plannedTime" db:"planned_time"`
+	PlannedTimeLocal string    `json:"plannedTimeLocal,omitempty" db:"-"` // RFC 3339 in the ride's zone
+}
+
+// PresenceStatus reports whether a user is currently online
+type PresenceStatus struct {
+	UserID   uuid.UUID  `json:"userId"`
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"lastSeen,omitempty"`
+}
+
 // RideRequest represents a request to join a ride
 type RideRequest struct {
-	ID               uuid.UUID  `json:"requestId" db:"request_id"`
-	RideID           uuid.UUID  `json:"rideId" db:"ride_id"`
-	RiderID          uuid.UUID  `json:"riderId" db:"rider_id"`
-	PickupAddress    string     `json:"pickupAddress" db:"pickup_address"`
-	PickupLatitude   float64    `json:"pickupLatitude" db:"pickup_latitude"`
-	PickupLongitude  float64    `json:"pickupLongitude" db:"pickup_longitude"`
-	DropoffAddress   *string    `json:"dropoffAddress,omitempty" db:"dropoff_address"`
-	DropoffLatitude  *float64   `json:"dropoffLatitude,omitempty" db:"dropoff_latitude"`
-	DropoffLongitude *float64   `json:"dropoffLongitude,omitempty" db:"dropoff_longitude"`
-	Status           string     `json:"status" db:"status"`
-	SeatsRequested   int        `json:"seatsRequested" db:"seats_requested"`
-	DistanceAdded    *float64   `json:"distanceAdded,omitempty" db:"distance_added_meters"`
-	Message          *string    `json:"message,omitempty" db:"message"`
-	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
-	UpdatedAt        time.Time  `json:"updatedAt" db:"updated_at"`
+	ID               uuid.UUID       `json:"requestId" db:"request_id"`
+	RideID           uuid.UUID       `json:"rideId" db:"ride_id"`
+	RiderID          uuid.UUID       `json:"riderId" db:"rider_id"`
+	PickupAddress    string          `json:"pickupAddress" db:"pickup_address"`
+	PickupLatitude   float64         `json:"pickupLatitude" db:"pickup_latitude"`
+	PickupLongitude  float64         `json:"pickupLongitude" db:"pickup_longitude"`
+	DropoffAddress   *string         `json:"dropoffAddress,omitempty" db:"dropoff_address"`
+	DropoffLatitude  *float64        `json:"dropoffLatitude,omitempty" db:"dropoff_latitude"`
+	DropoffLongitude *float64        `json:"dropoffLongitude,omitempty" db:"dropoff_longitude"`
+	PickupStop       int             `json:"pickupStop" db:"pickup_stop"`
+	DropoffStop      int             `json:"dropoffStop" db:"dropoff_stop"`
+	Status           string          `json:"status" db:"status"`
+	SeatsRequested   int             `json:"seatsRequested" db:"seats_requested"`
+	DistanceAdded    *float64        `json:"distanceAdded,omitempty" db:"distance_added_meters"`
+	Fare             *FareBreakdown  `json:"fare,omitempty" db:"fare_breakdown"` // Set when accepted
+	Message          *string         `json:"message,omitempty" db:"message"`
+	CreatedAt        time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time       `json:"updatedAt" db:"updated_at"`
+	RiderPresence    *PresenceStatus `json:"riderPresence,omitempty" db:"-"`
 }
 
 // RidePassenger represents a confirmed passenger on a ride
@@ -134,6 +186,304 @@ type Rating struct {
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 }
 
+// Audience restricts a ride to riders who share verified community
+// attributes with the host. As a user's matching preferences it restricts
+// the rides they see and join in the same way.
+type Audience struct {
+	SameUniversity bool `json:"sameUniversity" db:"same_university"`
+	SameFaculty    bool `json:"sameFaculty" db:"same_faculty"`
+	SameBatch      bool `json:"sameBatch" db:"same_batch"`
+	SameGender     bool `json:"sameGender" db:"same_gender"`
+}
+
+// CommunityProfile holds a user's campus community attributes. Attributes
+// only count for matching once an admin has verified them, and changing
+// them clears the verification.
+type CommunityProfile struct {
+	UserID      uuid.UUID  `json:"userId" db:"user_id"`
+	University  string     `json:"university,omitempty" db:"university"`
+	Faculty     string     `json:"faculty,omitempty" db:"faculty"`
+	Batch       string     `json:"batch,omitempty" db:"batch"`
+	Gender      string     `json:"gender,omitempty" db:"gender"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty" db:"verified_at"`
+	VerifiedBy  *uuid.UUID `json:"verifiedBy,omitempty" db:"verified_by"`
+	Preferences Audience   `json:"preferences"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Verified reports whether the profile's attributes have been verified
+func (p *CommunityProfile) Verified() bool {
+	return p != nil && p.VerifiedAt != nil
+}
+
+// UpdateCommunityProfileRequest replaces a user's community attributes and
+// matching preferences. Omitted attributes are cleared.
+type UpdateCommunityProfileRequest struct {
+	University  string    `json:"university,omitempty" validate:"max=100"`
+	Faculty     string    `json:"faculty,omitempty" validate:"max=100"`
+	Batch       string    `json:"batch,omitempty" validate:"max=20"`
+	Gender      string    `json:"gender,omitempty" validate:"max=20"`
+	Preferences *Audience `json:"preferences,omitempty"`
+}
+
+// FareEstimate is what a trip costs to drive and what a host may charge
+type FareEstimate struct {
+	DistanceKm            float64 `json:"distanceKm"`
+	CostPerKm             float64 `json:"costPerKm"`
+	TripCost              float64 `json:"tripCost"`
+	Seats                 int     `json:"seats"`
+	SuggestedPricePerSeat float64 `json:"suggestedPricePerSeat"`
+	MaxPricePerSeat       float64 `json:"maxPricePerSeat"`
+	Currency              string  `json:"currency"`
+}
+
+// FareBreakdown is a passenger's share of the cost of a ride
+type FareBreakdown struct {
+	PricePerSeat float64 `json:"pricePerSeat"`
+	Seats        int     `json:"seats"`
+	RouteKm      float64 `json:"routeKm"`
+	SegmentKm    float64 `json:"segmentKm"`    // Pickup to dropoff
+	SegmentShare float64 `json:"segmentShare"` // Fraction of the route ridden
+	SegmentFare  float64 `json:"segmentFare"`
+	DetourKm     float64 `json:"detourKm"` // Extra distance driven for the passenger
+	DetourFare   float64 `json:"detourFare"`
+	Total        float64 `json:"total"`
+	Currency     string  `json:"currency"`
+}
+
+// ImpactTotals sums the impact of completed rides
+type ImpactTotals struct {
+	Rides        int     `json:"rides"`
+	DistanceKm   float64 `json:"distanceKm"`
+	Co2AvoidedKg float64 `json:"co2AvoidedKg"`
+	MoneySaved   float64 `json:"moneySaved"`
+	Currency     string  `json:"currency"`
+}
+
+// ImpactPoint is the impact of the rides completed in one interval
+type ImpactPoint struct {
+	PeriodStart time.Time `json:"periodStart"`
+	ImpactTotals
+}
+
+// ImpactSummary is the impact over a period, optionally broken down by interval
+type ImpactSummary struct {
+	ImpactTotals
+	Series []ImpactPoint `json:"series,omitempty"`
+}
+
+// UserImpact is a user's impact as a passenger and as a host
+type UserImpact struct {
+	UserID    uuid.UUID     `json:"userId"`
+	Period    string        `json:"period"`
+	Passenger ImpactSummary `json:"passenger"`
+	Host      ImpactSummary `json:"host"`
+}
+
+// CampusImpact is the impact of the verified members of a university
+type CampusImpact struct {
+	University string `json:"university"`
+	Period     string `json:"period"`
+	Members    int    `json:"members"` // Members with a completed ride in the period
+	ImpactSummary
+}
+
+// LeaderboardEntry ranks a campus member by the emissions they helped avoid
+type LeaderboardEntry struct {
+	Rank         int       `json:"rank"`
+	UserID       uuid.UUID `json:"userId"`
+	DisplayName  string    `json:"displayName"`
+	Rides        int       `json:"rides"`
+	Co2AvoidedKg float64   `json:"co2AvoidedKg"`
+	MoneySaved   float64   `json:"moneySaved"`
+}
+
+// SavedSearch is a ride search a rider is alerted about whenever a matching
+// ride is posted or updated
+type SavedSearch struct {
+	ID                   uuid.UUID     `json:"searchId" db:"search_id"`
+	UserID               uuid.UUID     `json:"userId" db:"user_id"`
+	Name                 string        `json:"name,omitempty" db:"name"`
+	OriginLatitude       float64       `json:"originLatitude" db:"origin_latitude"`
+	OriginLongitude      float64       `json:"originLongitude" db:"origin_longitude"`
+	DestinationLatitude  *float64      `json:"destinationLatitude,omitempty" db:"destination_latitude"`
+	DestinationLongitude *float64      `json:"destinationLongitude,omitempty" db:"destination_longitude"`
+	RadiusMeters         float64       `json:"radiusMeters" db:"radius_meters"`
+	DepartureAfter       *time.Time    `json:"departureAfter,omitempty" db:"departure_after"`
+	DepartureBefore      *time.Time    `json:"departureBefore,omitempty" db:"departure_before"`
+	Days                 []int         `json:"days,omitempty" db:"days"`                   // Weekdays, 0 is Sunday; empty means every day
+	EarliestTime         string        `json:"earliestTime,omitempty" db:"earliest_time"` // HH:MM in TimeZone
+	LatestTime           string        `json:"latestTime,omitempty" db:"latest_time"`     // HH:MM in TimeZone
+	TimeZone             string        `json:"timeZone" db:"time_zone"`
+	Filters              SearchFilters `json:"filters"`
+	CreatedAt            time.Time     `json:"createdAt" db:"created_at"`
+	UpdatedAt            time.Time     `json:"updatedAt" db:"updated_at"`
+}
+
+// SearchFilters narrows a saved search beyond place and time
+type SearchFilters struct {
+	MaxPricePerSeat *float64 `json:"maxPricePerSeat,omitempty" validate:"omitempty,gte=0"`
+	MinSeats        int      `json:"minSeats,omitempty" validate:"min=0,max=8"`
+	PetsAllowed     *bool    `json:"petsAllowed,omitempty"`
+	SmokingAllowed  *bool    `json:"smokingAllowed,omitempty"`
+}
+
+// Document is a driver's license, vehicle registration or insurance a host
+// uploads for an admin to check. VehicleID is set for vehicle documents.
+type Document struct {
+	ID              uuid.UUID  `json:"documentId" db:"document_id"`
+	UserID          uuid.UUID  `json:"userId" db:"user_id"`
+	VehicleID       *uuid.UUID `json:"vehicleId,omitempty" db:"vehicle_id"`
+	Type            string     `json:"type" db:"document_type"`
+	Status          string     `json:"status" db:"status"`
+	RejectionReason *string    `json:"rejectionReason,omitempty" db:"rejection_reason"`
+	FileName        string     `json:"fileName" db:"file_name"`
+	ContentType     string     `json:"contentType" db:"content_type"`
+	SizeBytes       int64      `json:"sizeBytes" db:"size_bytes"`
+	ExpiresOn       string     `json:"expiresOn" db:"expires_on"` // YYYY-MM-DD
+	ReviewedBy      *uuid.UUID `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewedAt,omitempty" db:"reviewed_at"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// CalendarFeed is a user's secret iCalendar feed of their rides. Anyone with
+// the URL can read the feed, so rotating it issues a new token and stops the
+// old URL working.
+type CalendarFeed struct {
+	URL       string     `json:"url"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+}
+
+// Leaderboard ranks the members of a campus
+type Leaderboard struct {
+	University string             `json:"university"`
+	Period     string             `json:"period"`
+	Entries    []LeaderboardEntry `json:"entries"`
+}
+
+// UserBlock records that one user has blocked another
+type UserBlock struct {
+	BlockerID uuid.UUID `json:"blockerId" db:"blocker_id"`
+	BlockedID uuid.UUID `json:"blockedId" db:"blocked_id"`
+	Reason    *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// AbuseReport represents a report filed by one user against another for a ride
+type AbuseReport struct {
+	ID              uuid.UUID  `json:"reportId" db:"report_id"`
+	ReporterID      uuid.UUID  `json:"reporterId" db:"reporter_id"`
+	ReportedUserID  uuid.UUID  `json:"reportedUserId" db:"reported_user_id"`
+	RideID          uuid.UUID  `json:"rideId" db:"ride_id"`
+	Category        string     `json:"category" db:"category"`
+	Description     string     `json:"description" db:"description"`
+	Status          string     `json:"status" db:"status"`
+	ReviewedBy      *uuid.UUID `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	ResolutionNotes *string    `json:"resolutionNotes,omitempty" db:"resolution_notes"`
+	ReviewedAt      *time.Time `json:"reviewedAt,omitempty" db:"reviewed_at"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// ChatMessage represents a message in a ride's conversation
+type ChatMessage struct {
+	ID        uuid.UUID `json:"messageId" db:"message_id"`
+	Cursor    string    `json:"cursor" db:"-"`
+	RideID    uuid.UUID `json:"rideId" db:"ride_id"`
+	SenderID  uuid.UUID `json:"senderId" db:"sender_id"`
+	Body      string    `json:"body" db:"body"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// ChatReadReceipt records how far a participant has read in a ride's conversation
+type ChatReadReceipt struct {
+	RideID            uuid.UUID `json:"rideId" db:"ride_id"`
+	UserID            uuid.UUID `json:"userId" db:"user_id"`
+	LastReadMessageID uuid.UUID `json:"lastReadMessageId" db:"last_read_message_id"`
+	ReadAt            time.Time `json:"readAt" db:"read_at"`
+}
+
+// ChatMessagePage is one page of a conversation, oldest message first
+type ChatMessagePage struct {
+	Messages   []*ChatMessage `json:"messages"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+	ReadOnly   bool           `json:"readOnly"`
+}
+
+// Notification is an entry in a user's in-app inbox
+type Notification struct {
+	ID        uuid.UUID  `json:"notificationId" db:"notification_id"`
+	UserID    uuid.UUID  `json:"userId" db:"user_id"`
+	Type      string     `json:"type" db:"notification_type"`
+	Title     string     `json:"title" db:"title"`
+	Message   string     `json:"message" db:"message"`
+	RideID    *uuid.UUID `json:"rideId,omitempty" db:"related_ride_id"`
+	IsRead    bool       `json:"isRead" db:"is_read"`
+	ReadAt    *time.Time `json:"readAt,omitempty" db:"read_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// NotificationPage is a page of a user's inbox, newest first
+type NotificationPage struct {
+	Notifications []*Notification `json:"notifications"`
+	UnreadCount   int             `json:"unreadCount"`
+}
+
+// NotificationPreferences controls which channels a user is notified on
+type NotificationPreferences struct {
+	UserID         uuid.UUID `json:"userId" db:"user_id"`
+	InboxEnabled   bool      `json:"inboxEnabled" db:"inbox_enabled"`
+	EmailEnabled   bool      `json:"emailEnabled" db:"email_enabled"`
+	WebhookEnabled bool      `json:"webhookEnabled" db:"webhook_enabled"`
+	WebhookURL     *string   `json:"webhookUrl,omitempty" db:"webhook_url"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// AuditEntry is one change to a ride, ride request, vehicle or user. Before
+// and After hold only the fields that changed.
+type AuditEntry struct {
+	ID         uuid.UUID       `json:"auditId" db:"log_id"`
+	ActorID    *uuid.UUID      `json:"actorId,omitempty" db:"user_id"`
+	OwnerID    *uuid.UUID      `json:"ownerId,omitempty" db:"owner_id"`
+	Action     string          `json:"action" db:"action"`
+	EntityType string          `json:"entityType" db:"table_name"`
+	EntityID   string          `json:"entityId" db:"record_id"`
+	Before     json.RawMessage `json:"before,omitempty" db:"old_values"`
+	After      json.RawMessage `json:"after,omitempty" db:"new_values"`
+	RequestID  *string         `json:"requestId,omitempty" db:"request_id"`
+	IPAddress  *string         `json:"ipAddress,omitempty" db:"ip_address"`
+	UserAgent  *string         `json:"userAgent,omitempty" db:"user_agent"`
+	CreatedAt  time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// AuditFilter narrows an audit log query. Zero fields match everything.
+type AuditFilter struct {
+	OwnerID    uuid.UUID
+	ActorID    uuid.UUID
+	EntityType string
+	EntityID   string
+}
+
+// Place is a named location from the gazetteer, such as a campus building,
+// a dorm or a city landmark
+type Place struct {
+	ID        string   `json:"placeId"`
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	Address   string   `json:"address"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Aliases   []string `json:"aliases,omitempty"`
+}
+
+// PlaceMatch is a place found near a point
+type PlaceMatch struct {
+	Place          Place   `json:"place"`
+	DistanceMeters float64 `json:"distanceMeters"`
+}
+
 // NearbyRideResult represents a ride that is near a location
 type NearbyRideResult struct {
 	Ride                 Ride    `json:"ride"`
@@ -149,30 +499,158 @@ type UserAuthResponse struct {
 
 // CreateVehicleRequest represents a request to create a vehicle
 type CreateVehicleRequest struct {
-	Make         string `json:"make"`
-	Model        string `json:"model"`
-	Year         int    `json:"year"`
-	Color        string `json:"color"`
-	LicensePlate string `json:"licensePlate"`
-	Capacity     int    `json:"capacity"`
+	Make         string  `json:"make" validate:"notblank,max=100"`
+	Model        string  `json:"model" validate:"notblank,max=100"`
+	Year         int     `json:"year" validate:"vehicleyear"`
+	Color        string  `json:"color" validate:"notblank,max=50"`
+	LicensePlate string  `json:"licensePlate" validate:"licenseplate"`
+	Capacity     int     `json:"capacity" validate:"min=1,max=15"`
+	// Optional; used to estimate the emissions a shared ride avoids
+	FuelType     string  `json:"fuelType,omitempty" validate:"omitempty,oneof=petrol diesel hybrid electric lpg"`
+	Efficiency   float64 `json:"efficiency,omitempty" validate:"omitempty,gt=0,max=50"`
 }
 
-// CreateRideRequest represents a request to create a ride
+// CreateRideRequest represents a request to create a ride. Each end of the
+// ride is given as a gazetteer place ID, an address, or an address with
+// coordinates; missing coordinates are resolved from the place or address.
 type CreateRideRequest struct {
-	VehicleID           uuid.UUID `json:"vehicleId"`
-	OriginAddress       string    `json:"originAddress"`
-	OriginLatitude      float64   `json:"originLatitude"`
-	OriginLongitude     float64   `json:"originLongitude"`
-	DestinationAddress  string    `json:"destinationAddress"`
-	DestinationLatitude float64   `json:"destinationLatitude"`
-	DestinationLongitude float64  `json:"destinationLongitude"`
-	DepartureTime       string    `json:"departureTime"` // ISO8601 string
-	EstimatedArrivalTime string   `json:"estimatedArrivalTime"` // ISO8601 string
-	MaxPassengers       int       `json:"maxPassengers"`
-	AvailableSeats      int       `json:"availableSeats"`
-	PricePerSeat        float64   `json:"pricePerSeat"`
-	Description         string    `json:"description,omitempty"`
-	LuggageCapacity     string    `json:"luggageCapacity,omitempty"`
+	VehicleID           uuid.UUID `json:"vehicleId" validate:"required"`
+	OriginPlaceID       string    `json:"originPlaceId,omitempty" validate:"max=100"`
+	OriginAddress       string    `json:"originAddress" validate:"omitempty,notblank,max=500"`
+	OriginLatitude      *float64  `json:"originLatitude,omitempty" validate:"omitempty,latitude"`
+	OriginLongitude     *float64  `json:"originLongitude,omitempty" validate:"omitempty,longitude"`
+	DestinationPlaceID  string    `json:"destinationPlaceId,omitempty" validate:"max=100"`
+	DestinationAddress  string    `json:"destinationAddress" validate:"omitempty,notblank,max=500"`
+	DestinationLatitude *float64  `json:"destinationLatitude,omitempty" validate:"omitempty,latitude"`
+	DestinationLongitude *float64 `json:"destinationLongitude,omitempty" validate:"omitempty,longitude"`
+	// Stops on the way, in order
+	Waypoints           []WaypointRequest `json:"waypoints,omitempty" validate:"max=5,dive"`
+	// Times are RFC 3339, or local times such as 2006-01-02T15:04 in TimeZone
+	DepartureTime       string    `json:"departureTime" validate:"localtime"`
+	EstimatedArrivalTime string   `json:"estimatedArrivalTime" validate:"localtime"`
+	// IANA zone of the ride; defaults to the host's time zone
+	TimeZone            string    `json:"timeZone,omitempty" validate:"omitempty,timezone"`
+	MaxPassengers       int       `json:"maxPassengers" validate:"min=1,max=8"`
+	AvailableSeats      int       `json:"availableSeats" validate:"min=0,ltefield=MaxPassengers"`
+	PricePerSeat        float64   `json:"pricePerSeat" validate:"gte=0"`
+	Description         string    `json:"description,omitempty" validate:"max=1000"`
+	LuggageCapacity     string    `json:"luggageCapacity,omitempty" validate:"max=100"`
 	IsPetsAllowed       bool      `json:"isPetsAllowed,omitempty"`
 	IsSmokingAllowed    bool      `json:"isSmokingAllowed,omitempty"`
-}
\ No newline at end of file
+	// Who may ride along; defaults to the host's matching preferences
+	Audience            *Audience `json:"audience,omitempty"`
+}
+
+// WaypointRequest is a stop between a ride's origin and destination. Like
+// the ends of a ride it is located by place ID, address or coordinates.
+type WaypointRequest struct {
+	PlaceID     string   `json:"placeId,omitempty" validate:"max=100"`
+	Address     string   `json:"address,omitempty" validate:"omitempty,notblank,max=500"`
+	Latitude    *float64 `json:"latitude,omitempty" validate:"omitempty,latitude"`
+	Longitude   *float64 `json:"longitude,omitempty" validate:"omitempty,longitude"`
+	PlannedTime string   `json:"plannedTime" validate:"localtime"` // Local times are in the ride's zone
+}
+
+// UpdateRideRequest represents a host's changes to a scheduled ride.
+// Omitted fields are left unchanged.
+type UpdateRideRequest struct {
+	DepartureTime        *string  `json:"departureTime,omitempty" validate:"omitempty,localtime"`        // Local times are in the ride's zone
+	EstimatedArrivalTime *string  `json:"estimatedArrivalTime,omitempty" validate:"omitempty,localtime"` // Local times are in the ride's zone
+	PricePerSeat         *float64 `json:"pricePerSeat,omitempty" validate:"omitempty,gte=0"`
+	Description          *string  `json:"description,omitempty" validate:"omitempty,max=1000"`
+}
+
+// DepartureWindow limits a ride search to a day, or part of one, on the
+// searcher's local calendar
+type DepartureWindow struct {
+	Day       string // "today", "tomorrow" or YYYY-MM-DD
+	PartOfDay string // "morning", "afternoon", "evening", "night" or empty
+	TimeZone  string // Defaults to the viewer's time zone
+}
+
+// SavedSearchRequest creates or replaces a saved search. Rides match when
+// they leave within RadiusMeters of the origin and, if given, arrive within
+// it of the destination.
+type SavedSearchRequest struct {
+	Name                 string        `json:"name,omitempty" validate:"max=100"`
+	OriginLatitude       float64       `json:"originLatitude" validate:"latitude"`
+	OriginLongitude      float64       `json:"originLongitude" validate:"longitude"`
+	DestinationLatitude  *float64      `json:"destinationLatitude,omitempty" validate:"required_with=DestinationLongitude,omitempty,latitude"`
+	DestinationLongitude *float64      `json:"destinationLongitude,omitempty" validate:"required_with=DestinationLatitude,omitempty,longitude"`
+	RadiusMeters         float64       `json:"radiusMeters" validate:"gte=100,lte=10000"`
+	// Times are RFC 3339, or local times such as 2006-01-02T15:04 in TimeZone
+	DepartureAfter       string        `json:"departureAfter,omitempty" validate:"omitempty,localtime"`
+	DepartureBefore      string        `json:"departureBefore,omitempty" validate:"omitempty,localtime"`
+	Days                 []int         `json:"days,omitempty" validate:"max=7,dive,min=0,max=6"`
+	EarliestTime         string        `json:"earliestTime,omitempty" validate:"omitempty,datetime=15:04"`
+	LatestTime           string        `json:"latestTime,omitempty" validate:"omitempty,datetime=15:04"`
+	TimeZone             string        `json:"timeZone,omitempty" validate:"omitempty,timezone"` // Defaults to the user's time zone
+	Filters              SearchFilters `json:"filters"`
+}
+
+// JoinRideRequest represents a rider's request to join a ride
+type JoinRideRequest struct {
+	// Stops default to the ones nearest the pickup and dropoff, or the
+	// destination when no dropoff is sent. A stop supplies the address and
+	// coordinates when they are left out.
+	PickupStop       *int     `json:"pickupStop,omitempty" validate:"omitempty,min=0"`
+	DropoffStop      *int     `json:"dropoffStop,omitempty" validate:"omitempty,min=1"`
+	PickupAddress    string   `json:"pickupAddress,omitempty" validate:"required_without=PickupStop,omitempty,notblank,max=500"`
+	PickupLatitude   float64  `json:"pickupLatitude" validate:"latitude"`
+	PickupLongitude  float64  `json:"pickupLongitude" validate:"longitude"`
+	DropoffAddress   string   `json:"dropoffAddress,omitempty" validate:"max=500"`
+	DropoffLatitude  *float64 `json:"dropoffLatitude,omitempty" validate:"omitempty,latitude"`
+	DropoffLongitude *float64 `json:"dropoffLongitude,omitempty" validate:"omitempty,longitude"`
+	SeatsRequested   int      `json:"seatsRequested" validate:"min=0,max=8"` // 0 means one seat
+	Message          string   `json:"message,omitempty" validate:"max=500"`
+}
+
+// BlockUserRequest represents a request to block another user
+type BlockUserRequest struct {
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// CreateReportRequest represents a request to file an abuse report
+type CreateReportRequest struct {
+	RideID         uuid.UUID `json:"rideId" validate:"required"`
+	ReportedUserID uuid.UUID `json:"reportedUserId" validate:"required"`
+	Category       string    `json:"category" validate:"required"`
+	Description    string    `json:"description" validate:"notblank,max=2000"`
+}
+
+// ReviewReportRequest represents an admin decision on an abuse report
+type ReviewReportRequest struct {
+	Status          string `json:"status" validate:"required"`
+	ResolutionNotes string `json:"resolutionNotes,omitempty" validate:"max=2000"`
+}
+
+// ReviewDocumentRequest represents an admin decision on a document.
+// Rejections must give a reason.
+type ReviewDocumentRequest struct {
+	Status string `json:"status" validate:"oneof=approved rejected"`
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// DecideRideRequest represents a host's decision on a join request
+type DecideRideRequest struct {
+	Status string `json:"status" validate:"oneof=accepted rejected"`
+}
+
+// SendChatMessageRequest represents a request to post a chat message
+type SendChatMessageRequest struct {
+	Body string `json:"body"`
+}
+
+// MarkChatReadRequest marks a conversation as read up to a message
+type MarkChatReadRequest struct {
+	MessageID uuid.UUID `json:"messageId" validate:"required"`
+}
+
+// UpdateNotificationPreferencesRequest changes a user's notification channels.
+// Omitted fields are left unchanged.
+type UpdateNotificationPreferencesRequest struct {
+	InboxEnabled   *bool   `json:"inboxEnabled,omitempty"`
+	EmailEnabled   *bool   `json:"emailEnabled,omitempty"`
+	WebhookEnabled *bool   `json:"webhookEnabled,omitempty"`
+	WebhookURL     *string `json:"webhookUrl,omitempty" validate:"omitempty,max=2048"`
+}
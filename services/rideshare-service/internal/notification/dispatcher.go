@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Dispatcher fans a message out to a recipient's enabled channels, retrying
+// each channel independently
+type Dispatcher struct {
+	channels map[string]Channel
+	backoff  Backoff
+}
+
+// NewDispatcher creates a Dispatcher over the given channels
+func NewDispatcher(backoff Backoff, channels ...Channel) *Dispatcher {
+	d := &Dispatcher{
+		channels: make(map[string]Channel, len(channels)),
+		backoff:  backoff,
+	}
+	for _, ch := range channels {
+		d.channels[ch.Name()] = ch
+	}
+	return d
+}
+
+// Has reports whether a channel is configured
+func (d *Dispatcher) Has(name string) bool {
+	_, ok := d.channels[name]
+	return ok
+}
+
+// Deliver sends msg over each named channel. Channels that are not
+// configured are skipped. It blocks until every channel has succeeded or
+// given up, and returns the failures.
+func (d *Dispatcher) Deliver(ctx context.Context, to Recipient, channelNames []string, msg Message) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, name := range channelNames {
+		ch, ok := d.channels[name]
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(ch Channel) {
+			defer wg.Done()
+			err := Retry(ctx, d.backoff, func() error {
+				return ch.Send(ctx, to, msg)
+			})
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s delivery to user %s failed: %w", ch.Name(), to.UserID, err))
+				mu.Unlock()
+			}
+		}(ch)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Send makes a single attempt to deliver msg over the named channel, for
+// callers that queue deliveries and retry them on their own schedule
+func (d *Dispatcher) Send(ctx context.Context, to Recipient, channelName string, msg Message) error {
+	ch, ok := d.channels[channelName]
+	if !ok {
+		return Permanent(fmt.Errorf("channel %s is not configured", channelName))
+	}
+	return ch.Send(ctx, to, msg)
+}
+
+// RetryAfter returns how long a queued delivery should wait after its
+// attempt'th failure with err, or false once it should be given up
+func (d *Dispatcher) RetryAfter(attempt int, err error) (time.Duration, bool) {
+	if IsPermanent(err) || attempt >= max(d.backoff.MaxAttempts, 1) {
+		return 0, false
+	}
+	return d.backoff.Delay(attempt), true
+}
@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeChannel fails a fixed number of times before succeeding
+type fakeChannel struct {
+	name     string
+	failures int
+
+	mu    sync.Mutex
+	calls int
+	sent  []Message
+}
+
+func (c *fakeChannel) Name() string { return c.name }
+
+func (c *fakeChannel) Send(ctx context.Context, to Recipient, msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	if c.calls <= c.failures {
+		return errors.New("unavailable")
+	}
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func TestDispatcherDeliversToEnabledChannels(t *testing.T) {
+	inbox := &fakeChannel{name: ChannelInbox}
+	email := &fakeChannel{name: ChannelEmail, failures: 2}
+	webhook := &fakeChannel{name: ChannelWebhook}
+
+	backoff := Backoff{MaxAttempts: 3, InitialDelay: time.Millisecond, Multiplier: 1}
+	d := NewDispatcher(backoff, inbox, email, webhook)
+
+	msg := Message{EventID: uuid.New(), Title: "Ride cancelled"}
+	to := Recipient{UserID: uuid.New()}
+
+	// Webhook is registered but not enabled; sms is enabled but not registered
+	err := d.Deliver(context.Background(), to, []string{ChannelInbox, ChannelEmail, "sms"}, msg)
+	if err != nil {
+		t.Fatalf("expected delivery to succeed, got %v", err)
+	}
+
+	if len(inbox.sent) != 1 {
+		t.Errorf("expected 1 inbox message, got %d", len(inbox.sent))
+	}
+	if len(email.sent) != 1 || email.calls != 3 {
+		t.Errorf("expected email to succeed on the third attempt, got %d calls", email.calls)
+	}
+	if webhook.calls != 0 {
+		t.Errorf("expected webhook to be skipped, got %d calls", webhook.calls)
+	}
+}
+
+func TestDispatcherReportsFailures(t *testing.T) {
+	email := &fakeChannel{name: ChannelEmail, failures: 10}
+
+	backoff := Backoff{MaxAttempts: 2, InitialDelay: time.Millisecond, Multiplier: 1}
+	d := NewDispatcher(backoff, email)
+
+	err := d.Deliver(context.Background(), Recipient{UserID: uuid.New()}, []string{ChannelEmail}, Message{})
+	if err == nil {
+		t.Fatal("expected an error when a channel gives up")
+	}
+	if email.calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", email.calls)
+	}
+}
+
+func TestDispatcherRetryAfter(t *testing.T) {
+	d := NewDispatcher(Backoff{MaxAttempts: 3, InitialDelay: time.Second, Multiplier: 2})
+	failure := errors.New("unavailable")
+
+	if delay, ok := d.RetryAfter(2, failure); !ok || delay != 2*time.Second {
+		t.Errorf("expected a retry after 2s, got %s %v", delay, ok)
+	}
+	if _, ok := d.RetryAfter(3, failure); ok {
+		t.Error("expected no retry once attempts run out")
+	}
+	if _, ok := d.RetryAfter(1, Permanent(failure)); ok {
+		t.Error("expected no retry after a permanent error")
+	}
+	if err := d.Send(context.Background(), Recipient{}, ChannelEmail, Message{}); !IsPermanent(err) {
+		t.Errorf("expected a permanent error for an unconfigured channel, got %v", err)
+	}
+}
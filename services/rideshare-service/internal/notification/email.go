@@ -0,0 +1,143 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// defaultSMTPTimeout bounds a whole SMTP exchange when SMTPConfig.Timeout is unset
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig holds the settings for the email channel
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// Timeout bounds connecting and the whole exchange with the relay
+	Timeout time.Duration
+}
+
+// EmailChannel sends messages through an SMTP relay
+type EmailChannel struct {
+	cfg SMTPConfig
+}
+
+// NewEmailChannel creates a new EmailChannel
+func NewEmailChannel(cfg SMTPConfig) *EmailChannel {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	return &EmailChannel{cfg: cfg}
+}
+
+func (c *EmailChannel) Name() string { return ChannelEmail }
+
+func (c *EmailChannel) Send(ctx context.Context, to Recipient, msg Message) error {
+	if to.Email == "" {
+		return Permanent(errors.New("recipient has no email address"))
+	}
+
+	var auth smtp.Auth
+	if c.cfg.Username != "" {
+		auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	}
+
+	if err := c.sendMail(ctx, auth, to.Email, c.compose(to, msg)); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+	return nil
+}
+
+// sendMail does what smtp.SendMail does, but gives up when the timeout or
+// ctx runs out instead of waiting on a relay that stopped answering
+func (c *EmailChannel) sendMail(ctx context.Context, auth smtp.Auth, to string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.cfg.Host, c.cfg.Port))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	// Cancelling ctx unblocks whatever the exchange is waiting on
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose builds a plain-text RFC 5322 message
+func (c *EmailChannel) compose(to Recipient, msg Message) []byte {
+	greeting := "Hi,"
+	if to.FirstName != "" {
+		greeting = fmt.Sprintf("Hi %s,", to.FirstName)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to.Email)
+	fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", msg.CreatedAt.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\n%s\r\n", greeting, msg.Body)
+	if msg.Link != "" {
+		fmt.Fprintf(&b, "\r\n%s\r\n", msg.Link)
+	}
+	return []byte(b.String())
+}
+
+// sanitizeHeader stops user-controlled text from injecting extra headers
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,41 @@
+package notification
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEmailChannelGivesUpOnSilentRelay(t *testing.T) {
+	// The relay accepts connections but never sends its greeting
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		<-done
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	ch := NewEmailChannel(SMTPConfig{Host: host, Port: port, From: "no-reply@rideshare.local", Timeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	err = ch.Send(context.Background(), Recipient{UserID: uuid.New(), Email: "rider@uni.edu"}, Message{Title: "Ride cancelled"})
+	if err == nil {
+		t.Fatal("expected the send to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the send to give up after the timeout, took %s", elapsed)
+	}
+}
@@ -0,0 +1,38 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+)
+
+// InboxChannel stores messages in the notifications table, where the inbox
+// API reads them from
+type InboxChannel struct {
+	dbManager *db.DBManager
+}
+
+// NewInboxChannel creates a new InboxChannel
+func NewInboxChannel(dbManager *db.DBManager) *InboxChannel {
+	return &InboxChannel{dbManager: dbManager}
+}
+
+func (c *InboxChannel) Name() string { return ChannelInbox }
+
+// Send inserts the message. Redelivered events are ignored, so each event
+// shows up in a user's inbox at most once.
+func (c *InboxChannel) Send(ctx context.Context, to Recipient, msg Message) error {
+	query := `
+		INSERT INTO notifications (user_id, event_id, notification_type, title, message, related_ride_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, event_id) DO NOTHING
+	`
+
+	_, err := c.dbManager.GetPrimary().ExecContext(ctx, query,
+		to.UserID, msg.EventID, msg.Type, msg.Title, msg.Body, msg.RideID, msg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error storing notification: %w", err)
+	}
+	return nil
+}
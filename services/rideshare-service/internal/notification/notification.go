@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Channel names, as used in preferences and logs
+const (
+	ChannelInbox   = "inbox"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Message is a notification addressed to a single user
+type Message struct {
+	EventID   uuid.UUID  `json:"eventId"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	RideID    *uuid.UUID `json:"rideId,omitempty"`
+	Link      string     `json:"link,omitempty"` // Where to view the ride
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Recipient holds the contact details a channel needs to reach a user
+type Recipient struct {
+	UserID     uuid.UUID
+	Email      string
+	FirstName  string
+	WebhookURL string
+}
+
+// Channel delivers messages over one medium
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, to Recipient, msg Message) error
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Retry gives up immediately
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
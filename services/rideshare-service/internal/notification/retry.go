@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff describes an exponential retry schedule
+type Backoff struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	// Jitter spreads each delay by up to this fraction in either direction
+	Jitter float64
+}
+
+// DefaultBackoff tries five times over roughly a minute
+var DefaultBackoff = Backoff{
+	MaxAttempts:  5,
+	InitialDelay: 2 * time.Second,
+	MaxDelay:     30 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based)
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.InitialDelay) * math.Pow(b.Multiplier, float64(attempt-1))
+	if max := float64(b.MaxDelay); b.MaxDelay > 0 && delay > max {
+		delay = max
+	}
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay)
+}
+
+// Retry calls fn until it succeeds, returns a permanent error, runs out of
+// attempts or ctx is done. The last error is returned.
+func Retry(ctx context.Context, b Backoff, fn func() error) error {
+	attempts := b.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil || IsPermanent(err) {
+			return err
+		}
+		if attempt == attempts {
+			break
+		}
+
+		timer := time.NewTimer(b.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
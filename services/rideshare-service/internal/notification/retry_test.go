@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{InitialDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, expected := range want {
+		if got := b.Delay(i + 1); got != expected {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, expected, got)
+		}
+	}
+}
+
+func TestRetryStopsOnSuccess(t *testing.T) {
+	b := Backoff{MaxAttempts: 5, InitialDelay: time.Millisecond, Multiplier: 1}
+
+	calls := 0
+	err := Retry(context.Background(), b, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	b := Backoff{MaxAttempts: 3, InitialDelay: time.Millisecond, Multiplier: 1}
+
+	calls := 0
+	err := Retry(context.Background(), b, func() error {
+		calls++
+		return errors.New("still failing")
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("expected 3 failed calls, got %d calls and error %v", calls, err)
+	}
+}
+
+func TestRetryPermanentError(t *testing.T) {
+	b := Backoff{MaxAttempts: 5, InitialDelay: time.Millisecond, Multiplier: 1}
+
+	calls := 0
+	err := Retry(context.Background(), b, func() error {
+		calls++
+		return Permanent(errors.New("bad address"))
+	})
+	if !IsPermanent(err) {
+		t.Errorf("expected a permanent error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected permanent errors not to be retried, got %d calls", calls)
+	}
+}
@@ -0,0 +1,92 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+)
+
+// TemplateData is what a message about a ride can refer to
+type TemplateData struct {
+	Origin        string
+	Destination   string
+	DepartureTime time.Time
+	// Location is the zone times are shown in, UTC when nil
+	Location *time.Location
+	// ActorName is the user who caused the event, e.g. the requesting rider
+	ActorName string
+	Seats     int
+	Changes   []string
+	// SearchName is the saved search a ride matched, if it has a name
+	SearchName string
+	// Document is the name of a verification document, such as "driver's
+	// license", with its expiry date and any rejection reason
+	Document          string
+	DocumentExpiresOn string
+	Reason            string
+}
+
+// Render returns the title and body for an event
+func Render(eventType events.Type, data TemplateData) (string, string, error) {
+	route := fmt.Sprintf("%s to %s", data.Origin, data.Destination)
+	loc := data.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	departure := data.DepartureTime.In(loc).Format("Mon 2 Jan 15:04 MST")
+
+	switch eventType {
+	case events.RequestCreated:
+		seats := "1 seat"
+		if data.Seats > 1 {
+			seats = fmt.Sprintf("%d seats", data.Seats)
+		}
+		return "New ride request",
+			fmt.Sprintf("%s requested %s on your ride from %s on %s.", nameOr(data.ActorName, "A rider"), seats, route, departure), nil
+	case events.RequestAccepted:
+		return "Ride request accepted",
+			fmt.Sprintf("You're confirmed on the ride from %s on %s.", route, departure), nil
+	case events.RequestRejected:
+		return "Ride request declined",
+			fmt.Sprintf("Your request for the ride from %s on %s was declined.", route, departure), nil
+	case events.RideUpdated:
+		body := fmt.Sprintf("The ride from %s on %s has been updated", route, departure)
+		if len(data.Changes) > 0 {
+			body += ": " + strings.Join(data.Changes, ", ")
+		}
+		return "Ride updated", body + ".", nil
+	case events.RideCancelled:
+		return "Ride cancelled",
+			fmt.Sprintf("The ride from %s on %s has been cancelled by the host.", route, departure), nil
+	case events.RideStartingSoon:
+		return "Ride starting soon",
+			fmt.Sprintf("The ride from %s departs at %s.", route, departure), nil
+	case events.SavedSearchMatched:
+		search := "one of your saved searches"
+		if data.SearchName != "" {
+			search = fmt.Sprintf("your saved search \"%s\"", data.SearchName)
+		}
+		return "New ride for your search",
+			fmt.Sprintf("A ride from %s on %s matches %s.", route, departure, search), nil
+	case events.DocumentApproved:
+		return "Document approved",
+			fmt.Sprintf("Your %s has been approved.", data.Document), nil
+	case events.DocumentRejected:
+		return "Document rejected",
+			fmt.Sprintf("Your %s was rejected: %s. Please upload a new one.", data.Document, data.Reason), nil
+	case events.DocumentExpiring:
+		return "Document expiring soon",
+			fmt.Sprintf("Your %s expires on %s. Upload a renewed one to keep hosting rides.", data.Document, data.DocumentExpiresOn), nil
+	default:
+		return "", "", fmt.Errorf("no template for event type: %s", eventType)
+	}
+}
+
+func nameOr(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
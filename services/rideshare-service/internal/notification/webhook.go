@@ -0,0 +1,181 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body
+const SignatureHeader = "X-Rideshare-Signature"
+
+// WebhookChannel POSTs messages as JSON to a URL chosen by each user
+type WebhookChannel struct {
+	client *http.Client
+	secret []byte
+}
+
+// webhookPayload is the body sent to webhook endpoints
+type webhookPayload struct {
+	UserID uuid.UUID `json:"userId"`
+	Message
+}
+
+// NewWebhookChannel creates a new WebhookChannel. When secret is set each
+// request is signed so receivers can verify it came from us.
+func NewWebhookChannel(secret string, timeout time.Duration) *WebhookChannel {
+	return &WebhookChannel{
+		client: newWebhookClient(timeout, denyInternalAddress),
+		secret: []byte(secret),
+	}
+}
+
+// errBlockedAddress is returned for webhooks that point inside our network
+var errBlockedAddress = errors.New("webhook address is not publicly routable")
+
+// cgnat is the shared address space carriers use, which is not public either
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// newWebhookClient returns a client for webhook URLs chosen by users. The
+// address is checked as each connection is made, after DNS resolution, so
+// a name that later resolves to an internal address is still refused.
+// Redirects are not followed, as they could lead anywhere.
+func newWebhookClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// No proxy, so the dialer sees the webhook's own address
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// denyInternalAddress refuses connections to addresses that are not
+// publicly routable
+func denyInternalAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBlockedAddress, address)
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// isPublicAddr reports whether addr may be reached by webhooks. Loopback,
+// private, link-local (including cloud metadata services), multicast and
+// unspecified addresses may not.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!cgnat.Contains(addr)
+}
+
+// ValidateWebhookURL checks that raw is an https URL that does not name an
+// internal host. Names are only resolved when the webhook is called.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return errors.New("invalid webhook URL")
+	}
+	if u.Scheme != "https" {
+		return errors.New("webhook URL must use https")
+	}
+	if u.User != nil {
+		return errors.New("webhook URL must not contain credentials")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errBlockedAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublicAddr(addr) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+func (c *WebhookChannel) Name() string { return ChannelWebhook }
+
+func (c *WebhookChannel) Send(ctx context.Context, to Recipient, msg Message) error {
+	if to.WebhookURL == "" {
+		return Permanent(errors.New("recipient has no webhook URL"))
+	}
+	// URLs saved before they were validated this strictly are checked again
+	if err := ValidateWebhookURL(to.WebhookURL); err != nil {
+		return Permanent(err)
+	}
+
+	body, err := json.Marshal(webhookPayload{UserID: to.UserID, Message: msg})
+	if err != nil {
+		return Permanent(fmt.Errorf("error encoding webhook payload: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("error creating webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Rideshare-Event", msg.Type)
+	if len(c.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(c.secret, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if errors.Is(err, errBlockedAddress) {
+		return Permanent(fmt.Errorf("error calling webhook: %w", err))
+	} else if err != nil {
+		return fmt.Errorf("error calling webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		// Other client errors, and redirects we do not follow, will not go
+		// away by retrying
+		return Permanent(fmt.Errorf("webhook returned status %d", resp.StatusCode))
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of body using secret
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
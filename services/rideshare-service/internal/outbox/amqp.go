@@ -0,0 +1,159 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+	"github.com/streadway/amqp"
+)
+
+// confirmTimeout bounds how long to wait for the broker to confirm a publish
+const confirmTimeout = 10 * time.Second
+
+// AMQPPublisher publishes events to a durable RabbitMQ topic exchange, using
+// the event type as routing key. Each publish waits for a broker confirm, so
+// an event is only marked as sent once RabbitMQ has taken responsibility for it.
+type AMQPPublisher struct {
+	uri      string
+	exchange string
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	confirms chan amqp.Confirmation
+}
+
+// NewAMQPPublisher creates a publisher for the given exchange. It connects
+// on first use, so events wait in the outbox while RabbitMQ is unreachable.
+func NewAMQPPublisher(uri, exchange string) *AMQPPublisher {
+	return &AMQPPublisher{uri: uri, exchange: exchange}
+}
+
+// connect opens the connection and declares the exchange; callers must hold mu
+func (p *AMQPPublisher) connect() error {
+	conn, err := amqp.Dial(p.uri)
+	if err != nil {
+		return fmt.Errorf("error connecting to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error opening channel: %w", err)
+	}
+
+	if err := ch.ExchangeDeclare(p.exchange, "topic", true, false, false, false, nil); err != nil {
+		conn.Close()
+		return fmt.Errorf("error declaring exchange: %w", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return fmt.Errorf("error enabling publisher confirms: %w", err)
+	}
+
+	p.conn = conn
+	p.channel = ch
+	p.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	return nil
+}
+
+// Publish sends the event and waits for the broker to confirm it
+func (p *AMQPPublisher) Publish(ctx context.Context, event events.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil || p.conn.IsClosed() {
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+
+	err = p.channel.Publish(p.exchange, string(event.Type), false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID.String(),
+		Type:         string(event.Type),
+		Timestamp:    event.OccurredAt,
+		Headers: amqp.Table{
+			"event-version":  int32(event.Version),
+			"aggregate-type": event.AggregateType,
+			"aggregate-id":   event.AggregateID.String(),
+		},
+		Body: body,
+	})
+	if err != nil {
+		p.reset()
+		return fmt.Errorf("error publishing event: %w", err)
+	}
+
+	timer := time.NewTimer(confirmTimeout)
+	defer timer.Stop()
+
+	select {
+	case confirm, ok := <-p.confirms:
+		if !ok {
+			p.reset()
+			return errors.New("channel closed before publish was confirmed")
+		}
+		if !confirm.Ack {
+			return errors.New("broker rejected event")
+		}
+		return nil
+	case <-timer.C:
+		// A late confirm would be mistaken for the next publish's, so start over
+		p.reset()
+		return errors.New("timed out waiting for publish confirm")
+	case <-ctx.Done():
+		p.reset()
+		return ctx.Err()
+	}
+}
+
+// Ping connects to RabbitMQ unless a connection is already open. It waits
+// for any publish in progress, so callers should bound ctx.
+func (p *AMQPPublisher) Ping(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.conn != nil && !p.conn.IsClosed() {
+		return nil
+	}
+	return p.connect()
+}
+
+// reset drops the connection so the next publish reconnects; callers must hold mu
+func (p *AMQPPublisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = nil
+	p.channel = nil
+	p.confirms = nil
+}
+
+// Close closes the connection
+func (p *AMQPPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
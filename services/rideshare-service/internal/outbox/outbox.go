@@ -0,0 +1,40 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+)
+
+// Append records an event in the outbox. It must be called with the same
+// transaction as the change the event describes, so the event is stored if
+// and only if the change commits.
+//
+// Events for one aggregate are relayed in the order they were appended. That
+// matches commit order as long as the transaction locks the aggregate's row
+// before appending, which every writer in this service does.
+func Append(ctx context.Context, tx *sql.Tx, event events.Event) error {
+	query := `
+		INSERT INTO outbox_events (
+			event_id, aggregate_type, aggregate_id, event_type, event_version,
+			actor_id, payload, occurred_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := tx.ExecContext(ctx, query,
+		event.ID,
+		event.AggregateType,
+		event.AggregateID,
+		string(event.Type),
+		event.Version,
+		event.ActorID,
+		[]byte(event.Data),
+		event.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error writing %s event to outbox: %w", event.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"platform/shared/logging"
+)
+
+// relayLockKey is the advisory lock that keeps a single relay active at a time
+const relayLockKey = 727001
+
+// maxRetryDelay caps how long a failing event waits between attempts
+const maxRetryDelay = 5 * time.Minute
+
+// claimLease is how long claimed events are left to the relay that claimed
+// them. Publishing is cut off when it runs out.
+const claimLease = 2 * time.Minute
+
+// Relay publishes outbox events in order. Events are claimed in a short
+// transaction and published after it commits, so no transaction is held
+// while subscribers run. Delivery is at-least-once: events claimed by a
+// relay that crashed are published again once their lease runs out.
+type Relay struct {
+	dbManager *db.DBManager
+	publisher events.Publisher
+	batchSize int
+}
+
+// NewRelay creates a relay that sends outbox events to publisher
+func NewRelay(dbManager *db.DBManager, publisher events.Publisher, batchSize int) *Relay {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Relay{
+		dbManager: dbManager,
+		publisher: publisher,
+		batchSize: batchSize,
+	}
+}
+
+// pendingEvent is an unpublished outbox row
+type pendingEvent struct {
+	event         events.Event
+	attempts      int
+	nextAttemptAt sql.NullTime
+}
+
+// Run relays events every interval until ctx is done
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			// Keep draining while full batches are coming back
+			n, err := r.RelayBatch(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error relaying outbox events", "error", err)
+			}
+			if err != nil || n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayBatch publishes up to one batch of pending events and returns how
+// many rows it looked at
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	pending, claimed, err := r.claim(ctx)
+	if err != nil || len(claimed) == 0 {
+		return len(pending), err
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, claimLease)
+	defer cancel()
+	published, failed := publishInOrder(publishCtx, claimed, time.Now(), r.publisher.Publish)
+
+	db := r.dbManager.GetPrimary()
+	if _, err := db.ExecContext(ctx,
+		"UPDATE outbox_events SET published_at = NOW(), last_error = NULL, next_attempt_at = NULL WHERE event_seq = ANY($1)",
+		pq.Array(published)); err != nil {
+		return 0, fmt.Errorf("error marking events published: %w", err)
+	}
+
+	var heldBack []int64
+	for _, p := range claimed {
+		publishErr, ok := failed[p.event.Sequence]
+		if !ok {
+			if !slices.Contains(published, p.event.Sequence) {
+				heldBack = append(heldBack, p.event.Sequence)
+			}
+			continue
+		}
+		logging.FromContext(ctx).Error("Error publishing event", "type", p.event.Type, "eventID", p.event.ID, "error", publishErr)
+
+		delay := retryDelay(p.attempts + 1)
+		if _, err := db.ExecContext(ctx,
+			`UPDATE outbox_events
+			SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 second'
+			WHERE event_seq = $1`,
+			p.event.Sequence, publishErr.Error(), delay.Seconds()); err != nil {
+			return 0, fmt.Errorf("error recording publish failure: %w", err)
+		}
+	}
+
+	// Events queued behind a failure go back to waiting on it
+	if _, err := db.ExecContext(ctx,
+		"UPDATE outbox_events SET next_attempt_at = NULL WHERE event_seq = ANY($1)",
+		pq.Array(heldBack)); err != nil {
+		return 0, fmt.Errorf("error releasing held back events: %w", err)
+	}
+
+	return len(pending), nil
+}
+
+// claim leases the events that are due by pushing their next attempt past
+// the lease, which holds back the rest of their aggregate on every relay
+func (r *Relay) claim(ctx context.Context) ([]pendingEvent, []pendingEvent, error) {
+	tx, err := r.dbManager.GetPrimary().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Another instance is already claiming; its order must not be interleaved
+	var locked bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLockKey).Scan(&locked); err != nil {
+		return nil, nil, fmt.Errorf("error taking relay lock: %w", err)
+	}
+	if !locked {
+		return nil, nil, nil
+	}
+
+	pending, err := loadPending(ctx, tx, r.batchSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	claimed := dueInOrder(pending, time.Now())
+	seqs := make([]int64, len(claimed))
+	for i, p := range claimed {
+		seqs[i] = p.event.Sequence
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE outbox_events SET next_attempt_at = NOW() + $2 * INTERVAL '1 second' WHERE event_seq = ANY($1)",
+		pq.Array(seqs), claimLease.Seconds()); err != nil {
+		return nil, nil, fmt.Errorf("error claiming events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("error committing relay claim: %w", err)
+	}
+
+	return pending, claimed, nil
+}
+
+func loadPending(ctx context.Context, tx *sql.Tx, limit int) ([]pendingEvent, error) {
+	query := `
+		SELECT event_seq, event_id, aggregate_type, aggregate_id, event_type, event_version,
+			actor_id, payload, occurred_at, attempts, next_attempt_at
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY event_seq
+		LIMIT $1
+	`
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []pendingEvent
+	for rows.Next() {
+		var p pendingEvent
+		var eventType string
+		var actorID uuid.NullUUID
+		var payload []byte
+		err := rows.Scan(
+			&p.event.Sequence,
+			&p.event.ID,
+			&p.event.AggregateType,
+			&p.event.AggregateID,
+			&eventType,
+			&p.event.Version,
+			&actorID,
+			&payload,
+			&p.event.OccurredAt,
+			&p.attempts,
+			&p.nextAttemptAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning outbox event: %w", err)
+		}
+		p.event.Type = events.Type(eventType)
+		p.event.Data = payload
+		if actorID.Valid {
+			p.event.ActorID = &actorID.UUID
+		}
+		pending = append(pending, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through outbox events: %w", err)
+	}
+
+	return pending, nil
+}
+
+// dueInOrder returns the events that may be published now, in sequence
+// order. Events behind one that is still waiting out its retry delay, or
+// claimed by a relay, are held back with it.
+func dueInOrder(pending []pendingEvent, now time.Time) []pendingEvent {
+	var due []pendingEvent
+	blocked := make(map[string]bool)
+
+	for _, p := range pending {
+		key := p.event.AggregateType + ":" + p.event.AggregateID.String()
+		if blocked[key] {
+			continue
+		}
+		if p.nextAttemptAt.Valid && p.nextAttemptAt.Time.After(now) {
+			blocked[key] = true
+			continue
+		}
+		due = append(due, p)
+	}
+
+	return due
+}
+
+// publishInOrder publishes events in sequence order. Once an event of an
+// aggregate fails, or is still waiting out its retry delay, later events of
+// the same aggregate are held back so consumers never see them out of order.
+// Other aggregates carry on.
+func publishInOrder(ctx context.Context, pending []pendingEvent, now time.Time, publish func(context.Context, events.Event) error) ([]int64, map[int64]error) {
+	var published []int64
+	failed := make(map[int64]error)
+	blocked := make(map[string]bool)
+
+	for _, p := range pending {
+		key := p.event.AggregateType + ":" + p.event.AggregateID.String()
+		if blocked[key] {
+			continue
+		}
+		if p.nextAttemptAt.Valid && p.nextAttemptAt.Time.After(now) {
+			blocked[key] = true
+			continue
+		}
+
+		if err := publish(ctx, p.event); err != nil {
+			failed[p.event.Sequence] = err
+			blocked[key] = true
+			continue
+		}
+		published = append(published, p.event.Sequence)
+	}
+
+	return published, failed
+}
+
+// retryDelay doubles with each failed attempt up to maxRetryDelay
+func retryDelay(attempts int) time.Duration {
+	seconds := math.Pow(2, float64(attempts))
+	if seconds >= maxRetryDelay.Seconds() {
+		return maxRetryDelay
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,102 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+	"github.com/google/uuid"
+)
+
+func pendingFor(seq int64, aggregateID uuid.UUID) pendingEvent {
+	return pendingEvent{event: events.Event{
+		ID:            uuid.New(),
+		Sequence:      seq,
+		AggregateType: events.AggregateRide,
+		AggregateID:   aggregateID,
+	}}
+}
+
+func TestPublishInOrderHoldsBackFailedAggregate(t *testing.T) {
+	rideA, rideB := uuid.New(), uuid.New()
+	pending := []pendingEvent{
+		pendingFor(1, rideA),
+		pendingFor(2, rideB),
+		pendingFor(3, rideA),
+		pendingFor(4, rideB),
+	}
+
+	var attempted []int64
+	publish := func(ctx context.Context, e events.Event) error {
+		attempted = append(attempted, e.Sequence)
+		if e.Sequence == 1 {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}
+
+	published, failed := publishInOrder(context.Background(), pending, time.Now(), publish)
+
+	if len(attempted) != 3 || attempted[0] != 1 || attempted[1] != 2 || attempted[2] != 4 {
+		t.Errorf("expected events 1, 2 and 4 to be attempted, got %v", attempted)
+	}
+	if len(published) != 2 || published[0] != 2 || published[1] != 4 {
+		t.Errorf("expected events 2 and 4 to be published, got %v", published)
+	}
+	if _, ok := failed[1]; !ok || len(failed) != 1 {
+		t.Errorf("expected only event 1 to fail, got %v", failed)
+	}
+}
+
+func TestPublishInOrderWaitsForRetryDelay(t *testing.T) {
+	ride := uuid.New()
+	now := time.Now()
+
+	waiting := pendingFor(1, ride)
+	waiting.nextAttemptAt = sql.NullTime{Time: now.Add(time.Minute), Valid: true}
+	pending := []pendingEvent{waiting, pendingFor(2, ride)}
+
+	calls := 0
+	publish := func(ctx context.Context, e events.Event) error {
+		calls++
+		return nil
+	}
+
+	published, failed := publishInOrder(context.Background(), pending, now, publish)
+	if calls != 0 || len(published) != 0 || len(failed) != 0 {
+		t.Errorf("expected nothing to be published before the retry time, got %d calls", calls)
+	}
+
+	// Once the delay has passed both go out, in order
+	published, _ = publishInOrder(context.Background(), pending, now.Add(2*time.Minute), publish)
+	if len(published) != 2 || published[0] != 1 || published[1] != 2 {
+		t.Errorf("expected events 1 and 2 in order, got %v", published)
+	}
+}
+
+func TestDueInOrderSkipsClaimedAggregates(t *testing.T) {
+	rideA, rideB := uuid.New(), uuid.New()
+	now := time.Now()
+
+	// Event 1 is leased to another relay, so event 3 must wait for it
+	claimed := pendingFor(1, rideA)
+	claimed.nextAttemptAt = sql.NullTime{Time: now.Add(claimLease), Valid: true}
+	pending := []pendingEvent{claimed, pendingFor(2, rideB), pendingFor(3, rideA), pendingFor(4, rideB)}
+
+	due := dueInOrder(pending, now)
+	if len(due) != 2 || due[0].event.Sequence != 2 || due[1].event.Sequence != 4 {
+		t.Errorf("expected events 2 and 4 to be due, got %v", due)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if d := retryDelay(1); d != 2*time.Second {
+		t.Errorf("expected 2s after the first failure, got %v", d)
+	}
+	if d := retryDelay(100); d != maxRetryDelay {
+		t.Errorf("expected delay to be capped at %v, got %v", maxRetryDelay, d)
+	}
+}
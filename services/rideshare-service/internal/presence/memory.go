@@ -0,0 +1,83 @@
+package presence
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+)
+
+type memoryEntry struct {
+	onlineUntil time.Time
+	lastSeen    time.Time
+}
+
+// MemoryStore is an in-process Store used in tests and for local runs
+// without Redis. It is only accurate for a single instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[uuid.UUID]memoryEntry
+}
+
+// NewMemoryStore creates an in-memory Store using the wall clock
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return NewMemoryStoreWithClock(ttl, time.Now)
+}
+
+// NewMemoryStoreWithClock creates an in-memory Store with a custom clock,
+// which lets tests move time forward
+func NewMemoryStoreWithClock(ttl time.Duration, now func() time.Time) *MemoryStore {
+	return &MemoryStore{
+		ttl:     ttl,
+		now:     now,
+		entries: make(map[uuid.UUID]memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Heartbeat(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[userID] = memoryEntry{
+		onlineUntil: at.Add(s.ttl),
+		lastSeen:    at,
+	}
+	return nil
+}
+
+func (s *MemoryStore) Disconnect(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[userID]; ok {
+		entry.onlineUntil = time.Time{}
+		s.entries[userID] = entry
+	}
+	return nil
+}
+
+func (s *MemoryStore) Lookup(ctx context.Context, userIDs []uuid.UUID) ([]models.PresenceStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	statuses := make([]models.PresenceStatus, len(userIDs))
+	for i, id := range userIDs {
+		statuses[i] = models.PresenceStatus{UserID: id}
+
+		entry, ok := s.entries[id]
+		if !ok {
+			continue
+		}
+
+		lastSeen := entry.lastSeen.UTC()
+		statuses[i].LastSeen = &lastSeen
+		statuses[i].Online = now.Before(entry.onlineUntil)
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,69 @@
+package presence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryStoreExpiresAfterTTL(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStoreWithClock(90*time.Second, func() time.Time { return now })
+	ctx := context.Background()
+
+	active := uuid.New()
+	unknown := uuid.New()
+
+	if err := store.Heartbeat(ctx, active, now); err != nil {
+		t.Fatalf("heartbeat failed: %v", err)
+	}
+
+	statuses, err := store.Lookup(ctx, []uuid.UUID{active, unknown})
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 statuses, got %d", len(statuses))
+	}
+	if statuses[0].UserID != active || !statuses[0].Online {
+		t.Errorf("expected %s to be online, got %+v", active, statuses[0])
+	}
+	if statuses[1].UserID != unknown || statuses[1].Online || statuses[1].LastSeen != nil {
+		t.Errorf("expected %s to be offline with no last-seen time, got %+v", unknown, statuses[1])
+	}
+
+	// Move past the TTL without another heartbeat
+	now = now.Add(2 * time.Minute)
+
+	statuses, err = store.Lookup(ctx, []uuid.UUID{active})
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if statuses[0].Online {
+		t.Errorf("expected %s to be offline after the TTL", active)
+	}
+	if statuses[0].LastSeen == nil || !statuses[0].LastSeen.Equal(now.Add(-2*time.Minute)) {
+		t.Errorf("expected last-seen time to be kept, got %v", statuses[0].LastSeen)
+	}
+}
+
+func TestMemoryStoreDisconnect(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	store.Heartbeat(ctx, userID, time.Now())
+	if err := store.Disconnect(ctx, userID); err != nil {
+		t.Fatalf("disconnect failed: %v", err)
+	}
+
+	statuses, _ := store.Lookup(ctx, []uuid.UUID{userID})
+	if statuses[0].Online {
+		t.Error("expected user to be offline after disconnect")
+	}
+	if statuses[0].LastSeen == nil {
+		t.Error("expected last-seen time to survive disconnect")
+	}
+}
@@ -0,0 +1,23 @@
+package presence
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// Store tracks which users are online.
+//
+// A heartbeat marks a user online for the store's TTL and records the time as
+// their last-seen time. Users who stop sending heartbeats drop offline once
+// the TTL expires; their last-seen time is kept for longer.
+type Store interface {
+	// Heartbeat marks the user online as of at
+	Heartbeat(ctx context.Context, userID uuid.UUID, at time.Time) error
+	// Disconnect marks the user offline immediately, keeping their last-seen time
+	Disconnect(ctx context.Context, userID uuid.UUID) error
+	// Lookup returns the status of every requested user, in request order
+	Lookup(ctx context.Context, userIDs []uuid.UUID) ([]models.PresenceStatus, error)
+}
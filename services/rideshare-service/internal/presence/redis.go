@@ -0,0 +1,93 @@
+package presence
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisStore struct {
+	client      *redis.Client
+	ttl         time.Duration
+	lastSeenTTL time.Duration
+}
+
+// NewRedisStore creates a Store backed by TTL'd Redis keys
+func NewRedisStore(client *redis.Client, ttl, lastSeenTTL time.Duration) Store {
+	return &redisStore{
+		client:      client,
+		ttl:         ttl,
+		lastSeenTTL: lastSeenTTL,
+	}
+}
+
+func onlineKey(userID uuid.UUID) string {
+	return fmt.Sprintf("presence:online:%s", userID)
+}
+
+func lastSeenKey(userID uuid.UUID) string {
+	return fmt.Sprintf("presence:last_seen:%s", userID)
+}
+
+func (s *redisStore) Heartbeat(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	ts := strconv.FormatInt(at.Unix(), 10)
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, onlineKey(userID), ts, s.ttl)
+	pipe.Set(ctx, lastSeenKey(userID), ts, s.lastSeenTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error recording heartbeat: %w", err)
+	}
+	return nil
+}
+
+func (s *redisStore) Disconnect(ctx context.Context, userID uuid.UUID) error {
+	if err := s.client.Del(ctx, onlineKey(userID)).Err(); err != nil {
+		return fmt.Errorf("error clearing presence: %w", err)
+	}
+	return nil
+}
+
+func (s *redisStore) Lookup(ctx context.Context, userIDs []uuid.UUID) ([]models.PresenceStatus, error) {
+	statuses := make([]models.PresenceStatus, len(userIDs))
+	if len(userIDs) == 0 {
+		return statuses, nil
+	}
+
+	// Online keys first, then last-seen keys, in a single round trip
+	keys := make([]string, 0, len(userIDs)*2)
+	for _, id := range userIDs {
+		keys = append(keys, onlineKey(id))
+	}
+	for _, id := range userIDs {
+		keys = append(keys, lastSeenKey(id))
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error looking up presence: %w", err)
+	}
+
+	for i, id := range userIDs {
+		statuses[i] = models.PresenceStatus{
+			UserID: id,
+			Online: values[i] != nil,
+		}
+
+		raw, ok := values[len(userIDs)+i].(string)
+		if !ok {
+			continue
+		}
+		if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			lastSeen := time.Unix(unix, 0).UTC()
+			statuses[i].LastSeen = &lastSeen
+		}
+	}
+
+	return statuses, nil
+}
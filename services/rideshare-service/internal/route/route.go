@@ -0,0 +1,42 @@
+// Package route describes the stops a ride makes. Stop 0 is the origin, the
+// last stop the destination and the stops between them waypoints. Leg i runs
+// from stop i to stop i+1, and seats are counted per leg so a seat freed at a
+// stop can be taken again for the rest of the route.
+package route
+
+import (
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/geocode"
+)
+
+// Point is the location of a stop
+type Point struct {
+	Lat, Lon float64
+}
+
+// FreeSeats returns the seats free on every leg a passenger riding from stop
+// pickup to stop dropoff needs, given the seats free on each leg
+func FreeSeats(legSeats []int, pickup, dropoff int) int {
+	if pickup < 0 || dropoff > len(legSeats) || pickup >= dropoff {
+		return 0
+	}
+	free := legSeats[pickup]
+	for _, seats := range legSeats[pickup+1 : dropoff] {
+		if seats < free {
+			free = seats
+		}
+	}
+	return free
+}
+
+// Nearest returns the stop between first and last, inclusive, closest to p
+func Nearest(stops []Point, p Point, first, last int) int {
+	nearest := first
+	best := -1.0
+	for i := first; i <= last && i < len(stops); i++ {
+		d := geocode.DistanceMeters(p.Lat, p.Lon, stops[i].Lat, stops[i].Lon)
+		if best < 0 || d < best {
+			nearest, best = i, d
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,36 @@
+package route
+
+import "testing"
+
+func TestFreeSeats(t *testing.T) {
+	// Three legs: the second is full, and the third has a seat freed by a
+	// passenger leaving at stop 2
+	legs := []int{2, 0, 1}
+
+	for name, tc := range map[string]struct {
+		pickup, dropoff, want int
+	}{
+		"first leg":         {0, 1, 2},
+		"across a full leg": {0, 3, 0},
+		"freed seat":        {2, 3, 1},
+		"whole route":       {0, 3, 0},
+		"backwards":         {2, 1, 0},
+		"past destination":  {2, 4, 0},
+	} {
+		if got := FreeSeats(legs, tc.pickup, tc.dropoff); got != tc.want {
+			t.Errorf("%s: FreeSeats(%d, %d) = %d, want %d", name, tc.pickup, tc.dropoff, got, tc.want)
+		}
+	}
+}
+
+func TestNearest(t *testing.T) {
+	stops := []Point{{30, 31}, {30.1, 31}, {30.2, 31}, {30.3, 31}}
+
+	if got := Nearest(stops, Point{30.12, 31}, 0, 2); got != 1 {
+		t.Errorf("expected stop 1, got %d", got)
+	}
+	// Only stops in range are considered
+	if got := Nearest(stops, Point{30, 31}, 2, 3); got != 2 {
+		t.Errorf("expected stop 2, got %d", got)
+	}
+}
@@ -0,0 +1,202 @@
+// Package savedsearch matches rides against the searches riders have saved.
+// Searches are indexed by the geohash cells their origin radius overlaps and
+// by the UTC hours of the week they want to leave in, so a new ride is only
+// checked against the searches sharing its origin cell and departure hour.
+package savedsearch
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/geocode"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/mmcloughlin/geohash"
+)
+
+// Precision of indexed geohash cells, about 4.9 by 4.9 km
+const Precision = 5
+
+// MaxRadiusMeters bounds how many cells a search can be indexed under
+const MaxRadiusMeters = 10000
+
+const metersPerDegree = 111320
+
+// Cell is the indexed geohash cell containing a point
+func Cell(lat, lon float64) string {
+	return geohash.EncodeWithPrecision(lat, lon, Precision)
+}
+
+// Cells returns every cell that a circle of radiusMeters around a point may
+// overlap
+func Cells(lat, lon, radiusMeters float64) []string {
+	radiusMeters = math.Min(radiusMeters, MaxRadiusMeters)
+	dLat := radiusMeters / metersPerDegree
+	dLon := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.01 {
+		dLon = math.Min(180, radiusMeters/(metersPerDegree*cos))
+	}
+	minLat, maxLat := math.Max(-90, lat-dLat), math.Min(90, lat+dLat)
+	minLon, maxLon := math.Max(-180, lon-dLon), math.Min(180, lon+dLon)
+
+	// Sampling at half a cell apart visits every cell the box overlaps
+	box := geohash.BoundingBox(Cell(lat, lon))
+	stepLat := (box.MaxLat - box.MinLat) / 2
+	stepLon := (box.MaxLng - box.MinLng) / 2
+
+	seen := map[string]bool{}
+	for la := minLat; ; la += stepLat {
+		la = math.Min(la, maxLat)
+		for lo := minLon; ; lo += stepLon {
+			lo = math.Min(lo, maxLon)
+			seen[Cell(la, lo)] = true
+			if lo >= maxLon {
+				break
+			}
+		}
+		if la >= maxLat {
+			break
+		}
+	}
+
+	cells := make([]string, 0, len(seen))
+	for cell := range seen {
+		cells = append(cells, cell)
+	}
+	sort.Strings(cells)
+	return cells
+}
+
+// HourOfWeek is the time bucket a departure is indexed under: the hour of
+// the week in UTC, from 0 at midnight starting Sunday
+func HourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// Reference weeks in winter and summer, both starting on a Sunday, so that
+// buckets cover local times on either side of daylight saving changes
+var referenceWeeks = []time.Time{
+	time.Date(2024, time.January, 7, 0, 0, 0, 0, time.UTC),
+	time.Date(2024, time.July, 7, 0, 0, 0, 0, time.UTC),
+}
+
+// Buckets returns the UTC hours of the week a search's days and local times
+// of day can fall in
+func Buckets(search *models.SavedSearch, loc *time.Location) []int {
+	days := search.Days
+	if len(days) == 0 {
+		days = []int{0, 1, 2, 3, 4, 5, 6}
+	}
+	earliest, latest := dayWindow(search)
+
+	var hours []int
+	if earliest <= latest {
+		hours = hourRange(earliest/60, latest/60)
+	} else {
+		// The window runs past midnight
+		hours = append(hourRange(earliest/60, 23), hourRange(0, latest/60)...)
+	}
+
+	seen := map[int]bool{}
+	for _, week := range referenceWeeks {
+		for _, day := range days {
+			for _, hour := range hours {
+				t := time.Date(week.Year(), week.Month(), week.Day()+day, hour, 0, 0, 0, loc)
+				// Zones with part-hour offsets straddle two UTC hours
+				seen[HourOfWeek(t)] = true
+				seen[HourOfWeek(t.Add(59*time.Minute))] = true
+			}
+		}
+	}
+
+	buckets := make([]int, 0, len(seen))
+	for bucket := range seen {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+	return buckets
+}
+
+// Matches reports whether a ride satisfies every part of a search. loc is the
+// search's time zone.
+func Matches(search *models.SavedSearch, ride *models.Ride, loc *time.Location) bool {
+	if ride.Status != string(models.StatusScheduled) {
+		return false
+	}
+	if ride.AvailableSeats < 1 || ride.AvailableSeats < search.Filters.MinSeats {
+		return false
+	}
+
+	if geocode.DistanceMeters(search.OriginLatitude, search.OriginLongitude, ride.OriginLatitude, ride.OriginLongitude) > search.RadiusMeters {
+		return false
+	}
+	if search.DestinationLatitude != nil && search.DestinationLongitude != nil &&
+		geocode.DistanceMeters(*search.DestinationLatitude, *search.DestinationLongitude, ride.DestinationLatitude, ride.DestinationLongitude) > search.RadiusMeters {
+		return false
+	}
+
+	departure := ride.DepartureTime
+	if search.DepartureAfter != nil && departure.Before(*search.DepartureAfter) {
+		return false
+	}
+	if search.DepartureBefore != nil && departure.After(*search.DepartureBefore) {
+		return false
+	}
+	local := departure.In(loc)
+	if len(search.Days) > 0 && !containsDay(search.Days, int(local.Weekday())) {
+		return false
+	}
+	earliest, latest := dayWindow(search)
+	minute := local.Hour()*60 + local.Minute()
+	if earliest <= latest {
+		if minute < earliest || minute > latest {
+			return false
+		}
+	} else if minute < earliest && minute > latest {
+		return false
+	}
+
+	filters := search.Filters
+	if filters.MaxPricePerSeat != nil && ride.PricePerSeat > *filters.MaxPricePerSeat {
+		return false
+	}
+	if filters.PetsAllowed != nil && ride.IsPetsAllowed != *filters.PetsAllowed {
+		return false
+	}
+	if filters.SmokingAllowed != nil && ride.IsSmokingAllowed != *filters.SmokingAllowed {
+		return false
+	}
+	return true
+}
+
+// dayWindow returns a search's local times of day in minutes after
+// midnight, defaulting to the whole day
+func dayWindow(search *models.SavedSearch) (int, int) {
+	return minuteOfDay(search.EarliestTime, 0), minuteOfDay(search.LatestTime, 24*60-1)
+}
+
+func minuteOfDay(value string, fallback int) int {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return fallback
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+func hourRange(from, to int) []int {
+	hours := make([]int, 0, to-from+1)
+	for h := from; h <= to; h++ {
+		hours = append(hours, h)
+	}
+	return hours
+}
+
+func containsDay(days []int, day int) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
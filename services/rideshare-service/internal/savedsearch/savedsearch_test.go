@@ -0,0 +1,122 @@
+package savedsearch
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+)
+
+func TestCellsCoverTheRadius(t *testing.T) {
+	lat, lon, radius := 30.0444, 31.2357, 5000.0
+	cells := map[string]bool{}
+	for _, cell := range Cells(lat, lon, radius) {
+		cells[cell] = true
+	}
+
+	// Points on the edge of the circle all fall in an indexed cell
+	for bearing := 0.0; bearing < 360; bearing += 15 {
+		rad := bearing * math.Pi / 180
+		pLat := lat + radius/metersPerDegree*math.Cos(rad)
+		pLon := lon + radius/(metersPerDegree*math.Cos(lat*math.Pi/180))*math.Sin(rad)
+		if !cells[Cell(pLat, pLon)] {
+			t.Errorf("point at bearing %v (%v, %v) is not covered", bearing, pLat, pLon)
+		}
+	}
+	if len(cells) > 16 {
+		t.Errorf("a 5 km radius is indexed under %d cells", len(cells))
+	}
+}
+
+func TestHourOfWeek(t *testing.T) {
+	// Monday 08:30 in Cairo is 06:30 UTC in winter
+	cairo, _ := time.LoadLocation("Africa/Cairo")
+	departure := time.Date(2024, time.January, 8, 8, 30, 0, 0, cairo)
+	if got := HourOfWeek(departure); got != 24+6 {
+		t.Errorf("HourOfWeek = %d, want 30", got)
+	}
+}
+
+func TestBucketsCoverLocalWindow(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	search := &models.SavedSearch{Days: []int{1}, EarliestTime: "08:00", LatestTime: "09:30"}
+	buckets := map[int]bool{}
+	for _, b := range Buckets(search, loc) {
+		buckets[b] = true
+	}
+
+	// Monday morning departures in winter and summer
+	for _, departure := range []time.Time{
+		time.Date(2024, time.February, 5, 8, 15, 0, 0, loc),
+		time.Date(2024, time.August, 5, 9, 30, 0, 0, loc),
+	} {
+		if !buckets[HourOfWeek(departure)] {
+			t.Errorf("departure %v is not in the buckets %v", departure, Buckets(search, loc))
+		}
+	}
+	if buckets[HourOfWeek(time.Date(2024, time.February, 6, 8, 15, 0, 0, loc))] {
+		t.Error("a Tuesday departure is in a Monday-only search's buckets")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	maxPrice := 20.0
+	noPets := false
+	search := &models.SavedSearch{
+		OriginLatitude:  30.0444,
+		OriginLongitude: 31.2357,
+		RadiusMeters:    2000,
+		Days:            []int{1, 3},
+		EarliestTime:    "07:00",
+		LatestTime:      "10:00",
+		Filters:         models.SearchFilters{MaxPricePerSeat: &maxPrice, MinSeats: 2, PetsAllowed: &noPets},
+	}
+	ride := func(change func(*models.Ride)) *models.Ride {
+		r := &models.Ride{
+			Status:              string(models.StatusScheduled),
+			OriginLatitude:      30.05,
+			OriginLongitude:     31.24,
+			DestinationLatitude: 30.1,
+			DepartureTime:       time.Date(2024, time.January, 8, 8, 0, 0, 0, time.UTC), // Monday
+			AvailableSeats:      3,
+			PricePerSeat:        15,
+		}
+		if change != nil {
+			change(r)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name string
+		ride *models.Ride
+		want bool
+	}{
+		{"match", ride(nil), true},
+		{"too far", ride(func(r *models.Ride) { r.OriginLatitude = 30.2 }), false},
+		{"wrong day", ride(func(r *models.Ride) { r.DepartureTime = r.DepartureTime.AddDate(0, 0, 1) }), false},
+		{"too late", ride(func(r *models.Ride) { r.DepartureTime = r.DepartureTime.Add(3 * time.Hour) }), false},
+		{"too expensive", ride(func(r *models.Ride) { r.PricePerSeat = 25 }), false},
+		{"too few seats", ride(func(r *models.Ride) { r.AvailableSeats = 1 }), false},
+		{"pets", ride(func(r *models.Ride) { r.IsPetsAllowed = true }), false},
+		{"cancelled", ride(func(r *models.Ride) { r.Status = string(models.StatusCancelled) }), false},
+	}
+	for _, tt := range tests {
+		if got := Matches(search, tt.ride, time.UTC); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesOvernightWindow(t *testing.T) {
+	search := &models.SavedSearch{RadiusMeters: 1000, EarliestTime: "22:00", LatestTime: "02:00"}
+	ride := &models.Ride{Status: string(models.StatusScheduled), AvailableSeats: 1}
+
+	for hour, want := range map[int]bool{23: true, 1: true, 12: false} {
+		ride.DepartureTime = time.Date(2024, time.January, 8, hour, 0, 0, 0, time.UTC)
+		if got := Matches(search, ride, time.UTC); got != want {
+			t.Errorf("departure at %02d:00: Matches = %v, want %v", hour, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// AuditService reads the audit log. Entries are written by the services that
+// make the changes, in the same transaction.
+type AuditService struct {
+	dbManager *db.DBManager
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(dbManager *db.DBManager) *AuditService {
+	return &AuditService{dbManager: dbManager}
+}
+
+// ListOwnHistory returns changes to the user's own rides, ride requests,
+// vehicles and account, newest first. Where someone else made the change,
+// such as a host deciding on a request, their IP address and user agent are
+// left out.
+func (s *AuditService) ListOwnHistory(ctx context.Context, userID uuid.UUID, filter models.AuditFilter, limit, offset int) ([]*models.AuditEntry, error) {
+	filter.OwnerID = userID
+	entries, err := s.ListHistory(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.ActorID == nil || *entry.ActorID != userID {
+			entry.IPAddress = nil
+			entry.UserAgent = nil
+		}
+	}
+	return entries, nil
+}
+
+// ListHistory returns the audit entries matching filter, newest first
+func (s *AuditService) ListHistory(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]*models.AuditEntry, error) {
+	query := `
+		SELECT log_id, user_id, owner_id, action, table_name, record_id,
+			old_values, new_values, request_id, ip_address, user_agent, created_at
+		FROM audit_log
+		WHERE ($1::uuid IS NULL OR owner_id = $1)
+			AND ($2::uuid IS NULL OR user_id = $2)
+			AND ($3 = '' OR table_name = $3)
+			AND ($4 = '' OR record_id = $4)
+		ORDER BY created_at DESC
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query,
+		uuid.NullUUID{UUID: filter.OwnerID, Valid: filter.OwnerID != uuid.Nil},
+		uuid.NullUUID{UUID: filter.ActorID, Valid: filter.ActorID != uuid.Nil},
+		filter.EntityType,
+		filter.EntityID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var actorID, ownerID uuid.NullUUID
+		var before, after []byte
+
+		err := rows.Scan(
+			&entry.ID,
+			&actorID,
+			&ownerID,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&before,
+			&after,
+			&entry.RequestID,
+			&entry.IPAddress,
+			&entry.UserAgent,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning audit entry: %w", err)
+		}
+
+		if actorID.Valid {
+			entry.ActorID = &actorID.UUID
+		}
+		if ownerID.Valid {
+			entry.OwnerID = &ownerID.UUID
+		}
+		entry.Before = before
+		entry.After = after
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through audit log: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+)
+
+type BlockService struct {
+	dbManager *db.DBManager
+}
+
+// NewBlockService creates a new BlockService
+func NewBlockService(dbManager *db.DBManager) *BlockService {
+	return &BlockService{dbManager: dbManager}
+}
+
+// BlockUser records that blockerID no longer wants to see or ride with blockedID.
+// Blocking an already blocked user is a no-op.
+func (s *BlockService) BlockUser(ctx context.Context, blockerID, blockedID uuid.UUID, reason string) (*models.UserBlock, error) {
+	if blockerID == blockedID {
+		return nil, apperror.Invalid("userId", "self_block", "users cannot block themselves")
+	}
+
+	var exists bool
+	err := s.dbManager.GetReplica().QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE user_id = $1)",
+		blockedID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("error checking user existence: %w", err)
+	}
+	if !exists {
+		return nil, models.ErrUserNotFound
+	}
+
+	var reasonValue sql.NullString
+	if reason != "" {
+		reasonValue.String = reason
+		reasonValue.Valid = true
+	}
+
+	query := `
+		INSERT INTO user_blocks (blocker_id, blocked_id, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (blocker_id, blocked_id) DO UPDATE SET reason = COALESCE(EXCLUDED.reason, user_blocks.reason)
+		RETURNING blocker_id, blocked_id, reason, created_at
+	`
+
+	var block models.UserBlock
+	err = s.dbManager.GetPrimary().QueryRowContext(ctx, query, blockerID, blockedID, reasonValue).Scan(
+		&block.BlockerID,
+		&block.BlockedID,
+		&block.Reason,
+		&block.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error blocking user: %w", err)
+	}
+
+	return &block, nil
+}
+
+// UnblockUser removes a block previously created by blockerID
+func (s *BlockService) UnblockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	result, err := s.dbManager.GetPrimary().ExecContext(ctx,
+		"DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2",
+		blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("error unblocking user: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error unblocking user: %w", err)
+	}
+	if affected == 0 {
+		return apperror.NotFound("block_not_found", "block not found")
+	}
+
+	return nil
+}
+
+// ListBlockedUsers returns the users blocked by blockerID, most recent first
+func (s *BlockService) ListBlockedUsers(ctx context.Context, blockerID uuid.UUID) ([]*models.UserBlock, error) {
+	query := `
+		SELECT blocker_id, blocked_id, reason, created_at
+		FROM user_blocks
+		WHERE blocker_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, blockerID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching blocked users: %w", err)
+	}
+	defer rows.Close()
+
+	blocks := []*models.UserBlock{}
+	for rows.Next() {
+		var block models.UserBlock
+		if err := rows.Scan(&block.BlockerID, &block.BlockedID, &block.Reason, &block.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning block: %w", err)
+		}
+		blocks = append(blocks, &block)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through blocks: %w", err)
+	}
+
+	return blocks, nil
+}
+
+// IsBlockedBetween reports whether either user has blocked the other.
+// Blocks are enforced in both directions.
+func (s *BlockService) IsBlockedBetween(ctx context.Context, userA, userB uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2)
+			   OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`
+
+	var blocked bool
+	err := s.dbManager.GetReplica().QueryRowContext(ctx, query, userA, userB).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("error checking block status: %w", err)
+	}
+
+	return blocked, nil
+}
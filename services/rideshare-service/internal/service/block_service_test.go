@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+)
+
+// newMockDB returns a DBManager whose primary and replica are both mock
+func newMockDB(t *testing.T) (*db.DBManager, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	manager, err := db.NewDBManagerFromDB(conn)
+	if err != nil {
+		t.Fatalf("NewDBManagerFromDB: %v", err)
+	}
+	return manager, mock
+}
+
+// wantCode fails the test unless err is an *apperror.Error with code, or
+// nil when code is empty
+func wantCode(t *testing.T, err error, code string) {
+	t.Helper()
+	if code == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Code != code {
+		t.Fatalf("error = %v, want code %q", err, code)
+	}
+}
+
+func TestBlockUser(t *testing.T) {
+	blocker, blocked := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		blockedID uuid.UUID
+		expect    func(mock sqlmock.Sqlmock)
+		wantCode  string
+	}{
+		{
+			name:      "blocks an existing user",
+			blockedID: blocked,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(blocked).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectQuery("INSERT INTO user_blocks").WithArgs(blocker, blocked, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"blocker_id", "blocked_id", "reason", "created_at"}).
+						AddRow(blocker, blocked, "spam", time.Now()))
+			},
+		},
+		{
+			name:      "rejects blocking yourself",
+			blockedID: blocker,
+			expect:    func(sqlmock.Sqlmock) {},
+			wantCode:  "self_block",
+		},
+		{
+			name:      "rejects an unknown user",
+			blockedID: blocked,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(blocked).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			wantCode: "user_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, mock := newMockDB(t)
+			tt.expect(mock)
+
+			block, err := NewBlockService(manager).BlockUser(context.Background(), blocker, tt.blockedID, "spam")
+			wantCode(t, err, tt.wantCode)
+			if tt.wantCode == "" && (block.BlockerID != blocker || block.BlockedID != blocked) {
+				t.Errorf("block = %+v, want %s blocking %s", block, blocker, blocked)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestUnblockUser(t *testing.T) {
+	blocker, blocked := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		affected int64
+		wantCode string
+	}{
+		{name: "removes a block", affected: 1},
+		{name: "reports a missing block", affected: 0, wantCode: "block_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, mock := newMockDB(t)
+			mock.ExpectExec("DELETE FROM user_blocks").WithArgs(blocker, blocked).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			err := NewBlockService(manager).UnblockUser(context.Background(), blocker, blocked)
+			wantCode(t, err, tt.wantCode)
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
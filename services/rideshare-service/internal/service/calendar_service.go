@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/ical"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+)
+
+// calendarFeedHistory is how long rides stay in feeds after they leave, so
+// late changes such as cancellations still reach subscribers
+const calendarFeedHistory = 30 * 24 * time.Hour
+
+// calendarTokenBytes is the amount of randomness in a feed token
+const calendarTokenBytes = 32
+
+var errCalendarFeedNotFound = apperror.NotFound("calendar_feed_not_found", "calendar feed not found")
+
+const calendarRideQuery = `
+	SELECT r.ride_id, r.host_id, r.origin_address, r.destination_address,
+		r.departure_time, r.estimated_arrival_time, r.status, r.available_seats,
+		COALESCE(r.price_per_seat, 0), COALESCE(r.description, ''), r.created_at, r.updated_at,
+		u.first_name, u.last_name, u.phone_number,
+		COALESCE(v.make, ''), COALESCE(v.model, ''), COALESCE(v.year, 0),
+		COALESCE(v.color, ''), COALESCE(v.license_plate, '')
+	FROM rides r
+	JOIN users u ON u.user_id = r.host_id
+	LEFT JOIN vehicles v ON v.vehicle_id = r.vehicle_id
+`
+
+type CalendarService struct {
+	dbManager       *db.DBManager
+	feedBase        string
+	rideLinkBase    string
+	currency        string
+	refreshInterval time.Duration
+}
+
+// NewCalendarService creates a new CalendarService. Feed URLs are feedBase
+// followed by the token and .ics, and events link to rideLinkBase followed by
+// the ride ID.
+func NewCalendarService(dbManager *db.DBManager, feedBase, rideLinkBase, currency string, refreshInterval time.Duration) *CalendarService {
+	return &CalendarService{
+		dbManager:       dbManager,
+		feedBase:        feedBase,
+		rideLinkBase:    rideLinkBase,
+		currency:        currency,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// GetFeed returns a user's feed, issuing a token the first time
+func (s *CalendarService) GetFeed(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error) {
+	// The no-op update makes RETURNING yield the existing row on conflict
+	return s.upsertFeed(ctx, userID, `
+		INSERT INTO calendar_feeds (user_id, token) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING token, created_at, rotated_at
+	`)
+}
+
+// RotateFeed replaces a user's feed token. The old feed URL stops working.
+func (s *CalendarService) RotateFeed(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error) {
+	return s.upsertFeed(ctx, userID, `
+		INSERT INTO calendar_feeds (user_id, token) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, rotated_at = NOW()
+		RETURNING token, created_at, rotated_at
+	`)
+}
+
+func (s *CalendarService) upsertFeed(ctx context.Context, userID uuid.UUID, query string) (*models.CalendarFeed, error) {
+	token, err := newCalendarToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var feed models.CalendarFeed
+	var rotatedAt sql.NullTime
+	err = s.dbManager.GetPrimary().QueryRowContext(ctx, query, userID, token).
+		Scan(&token, &feed.CreatedAt, &rotatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error saving calendar feed: %w", err)
+	}
+
+	feed.URL = s.feedBase + token + ".ics"
+	if rotatedAt.Valid {
+		feed.RotatedAt = &rotatedAt.Time
+	}
+	return &feed, nil
+}
+
+// Feed returns the calendar behind a feed token: the rides its owner hosts
+// or is an accepted passenger of, from calendarFeedHistory ago onwards.
+// Cancelled rides are kept so subscribers remove them.
+func (s *CalendarService) Feed(ctx context.Context, token string) (*ical.Calendar, error) {
+	var userID uuid.UUID
+	err := s.dbManager.GetReplica().QueryRowContext(ctx,
+		"SELECT user_id FROM calendar_feeds WHERE token = $1", token).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, errCalendarFeedNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error fetching calendar feed: %w", err)
+	}
+
+	events, err := s.rideEvents(ctx, userID, `
+		WHERE (r.host_id = $1 OR EXISTS(
+			SELECT 1 FROM ride_passengers p WHERE p.ride_id = r.ride_id AND p.user_id = $1
+		))
+		AND r.departure_time >= $2
+		ORDER BY r.departure_time
+	`, userID, time.Now().Add(-calendarFeedHistory))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ical.Calendar{
+		Name:            "Rideshare",
+		RefreshInterval: s.refreshInterval,
+		Events:          events,
+	}, nil
+}
+
+// RideCalendar returns a single ride as a calendar. Only the host and
+// accepted passengers can download it.
+func (s *CalendarService) RideCalendar(ctx context.Context, userID, rideID uuid.UUID) (*ical.Calendar, error) {
+	var participant bool
+	err := s.dbManager.GetReplica().QueryRowContext(ctx, `
+		SELECT r.host_id = $2 OR EXISTS(
+			SELECT 1 FROM ride_passengers p WHERE p.ride_id = r.ride_id AND p.user_id = $2
+		)
+		FROM rides r
+		WHERE r.ride_id = $1
+	`, rideID, userID).Scan(&participant)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrRideNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error checking ride participant: %w", err)
+	}
+	if !participant {
+		return nil, apperror.Forbidden("not_ride_participant", "only the host and accepted passengers can download this ride")
+	}
+
+	events, err := s.rideEvents(ctx, userID, "WHERE r.ride_id = $1", rideID)
+	if err != nil {
+		return nil, err
+	}
+	return &ical.Calendar{Events: events}, nil
+}
+
+// rideEvents runs calendarRideQuery with a WHERE clause and turns each ride
+// into an event from viewerID's point of view
+func (s *CalendarService) rideEvents(ctx context.Context, viewerID uuid.UUID, where string, args ...interface{}) ([]ical.Event, error) {
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, calendarRideQuery+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching calendar rides: %w", err)
+	}
+	defer rows.Close()
+
+	events := []ical.Event{}
+	for rows.Next() {
+		var r calendarRide
+		if err := rows.Scan(
+			&r.id, &r.hostID, &r.origin, &r.destination,
+			&r.departure, &r.arrival, &r.status, &r.availableSeats,
+			&r.pricePerSeat, &r.description, &r.createdAt, &r.updatedAt,
+			&r.hostFirstName, &r.hostLastName, &r.hostPhone,
+			&r.vehicleMake, &r.vehicleModel, &r.vehicleYear,
+			&r.vehicleColor, &r.licensePlate,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning calendar ride: %w", err)
+		}
+		events = append(events, s.rideEvent(&r, r.hostID == viewerID))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through calendar rides: %w", err)
+	}
+
+	return events, nil
+}
+
+// calendarRide is a ride with the host and vehicle details shown in events
+type calendarRide struct {
+	id, hostID                             uuid.UUID
+	origin, destination                    string
+	departure, arrival                     time.Time
+	status                                 string
+	availableSeats                         int
+	pricePerSeat                           float64
+	description                            string
+	createdAt, updatedAt                   time.Time
+	hostFirstName, hostLastName, hostPhone string
+	vehicleMake, vehicleModel              string
+	vehicleYear                            int
+	vehicleColor, licensePlate             string
+}
+
+func (s *CalendarService) rideEvent(r *calendarRide, hosting bool) ical.Event {
+	summary := "Ride to " + r.destination + " with " + r.hostFirstName
+	if hosting {
+		summary = "Driving to " + r.destination
+	}
+
+	lines := []string{
+		"From: " + r.origin,
+		"To: " + r.destination,
+		"Host: " + strings.TrimSpace(r.hostFirstName+" "+r.hostLastName) + " (" + r.hostPhone + ")",
+	}
+	if r.vehicleMake != "" {
+		lines = append(lines, fmt.Sprintf("Vehicle: %s %s %s (%d), plate %s",
+			r.vehicleColor, r.vehicleMake, r.vehicleModel, r.vehicleYear, r.licensePlate))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Seats left: %d", r.availableSeats),
+		fmt.Sprintf("Price per seat: %.2f %s", r.pricePerSeat, s.currency))
+	if r.description != "" {
+		lines = append(lines, "", r.description)
+	}
+
+	status := ical.StatusConfirmed
+	if r.status == string(models.StatusCancelled) {
+		status = ical.StatusCancelled
+	}
+
+	return ical.Event{
+		UID:          "ride-" + r.id.String() + "@rideshare",
+		Summary:      summary,
+		Description:  strings.Join(lines, "\n"),
+		Location:     r.origin + " → " + r.destination,
+		URL:          s.rideLinkBase + r.id.String(),
+		Status:       status,
+		Start:        r.departure,
+		End:          r.arrival,
+		Created:      r.createdAt,
+		LastModified: r.updatedAt,
+	}
+}
+
+// newCalendarToken returns a random URL-safe feed token
+func newCalendarToken() (string, error) {
+	b := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating calendar token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/chat"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+)
+
+const (
+	maxChatMessageLength = 2000
+	defaultChatPageSize  = 50
+	maxChatPageSize      = 200
+)
+
+type ChatService struct {
+	dbManager *db.DBManager
+	hub       *chat.Hub
+}
+
+// NewChatService creates a new ChatService that pushes new messages to hub
+func NewChatService(dbManager *db.DBManager, hub *chat.Hub) *ChatService {
+	return &ChatService{dbManager: dbManager, hub: hub}
+}
+
+// Hub returns the hub used for real-time delivery
+func (s *ChatService) Hub() *chat.Hub {
+	return s.hub
+}
+
+// CheckAccess verifies that userID is the host or an accepted passenger of the
+// ride. It reports whether the conversation is read-only because the ride has
+// completed or been cancelled.
+func (s *ChatService) CheckAccess(ctx context.Context, rideID, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT r.status,
+			r.host_id = $2 OR EXISTS(
+				SELECT 1 FROM ride_passengers p WHERE p.ride_id = r.ride_id AND p.user_id = $2
+			)
+		FROM rides r
+		WHERE r.ride_id = $1
+	`
+
+	var status string
+	var participant bool
+	err := s.dbManager.GetReplica().QueryRowContext(ctx, query, rideID, userID).Scan(&status, &participant)
+	if err == sql.ErrNoRows {
+		return false, models.ErrRideNotFound
+	} else if err != nil {
+		return false, fmt.Errorf("error checking chat access: %w", err)
+	}
+
+	if !participant {
+		return false, apperror.Forbidden("not_ride_participant", "only the host and accepted passengers can access this chat")
+	}
+
+	readOnly := status == string(models.StatusCompleted) || status == string(models.StatusCancelled)
+	return readOnly, nil
+}
+
+// SendMessage stores a message and pushes it to live subscribers
+func (s *ChatService) SendMessage(ctx context.Context, rideID, senderID uuid.UUID, body string) (*models.ChatMessage, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, apperror.Invalid("body", "required", "message body is required")
+	}
+	if len(body) > maxChatMessageLength {
+		return nil, apperror.Invalid("body", "too_long", fmt.Sprintf("message cannot exceed %d characters", maxChatMessageLength))
+	}
+
+	readOnly, err := s.CheckAccess(ctx, rideID, senderID)
+	if err != nil {
+		return nil, err
+	}
+	if readOnly {
+		return nil, apperror.Conflict("chat_read_only", "chat is read-only")
+	}
+
+	query := `
+		INSERT INTO chat_messages (ride_id, sender_id, body)
+		VALUES ($1, $2, $3)
+		RETURNING message_id, message_seq, ride_id, sender_id, body, created_at
+	`
+
+	message, err := scanChatMessage(s.dbManager.GetPrimary().QueryRowContext(ctx, query, rideID, senderID, body))
+	if err != nil {
+		return nil, fmt.Errorf("error sending message: %w", err)
+	}
+
+	s.hub.Publish(rideID, chat.Event{Type: chat.EventMessage, Message: message})
+
+	return message, nil
+}
+
+// ListMessages returns a page of a ride's conversation.
+//
+// With after set, it returns messages newer than that cursor; this is what
+// polling clients use, passing NextCursor back on the next poll. Otherwise it
+// returns the newest messages older than before (or the newest overall), and
+// NextCursor points at the next older page when there is one.
+func (s *ChatService) ListMessages(ctx context.Context, rideID, userID uuid.UUID, before, after string, limit int) (*models.ChatMessagePage, error) {
+	readOnly, err := s.CheckAccess(ctx, rideID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultChatPageSize
+	}
+	if limit > maxChatPageSize {
+		limit = maxChatPageSize
+	}
+
+	page := &models.ChatMessagePage{Messages: []*models.ChatMessage{}, ReadOnly: readOnly}
+
+	if after != "" {
+		afterSeq, err := parseChatCursor(after)
+		if err != nil {
+			return nil, err
+		}
+
+		messages, err := s.queryMessages(ctx, `
+			SELECT message_id, message_seq, ride_id, sender_id, body, created_at
+			FROM chat_messages
+			WHERE ride_id = $1 AND message_seq > $2
+			ORDER BY message_seq ASC
+			LIMIT $3
+		`, rideID, afterSeq, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Messages = messages
+		page.NextCursor = after
+		if len(messages) > 0 {
+			page.NextCursor = messages[len(messages)-1].Cursor
+		}
+		return page, nil
+	}
+
+	beforeSeq := int64(0)
+	if before != "" {
+		beforeSeq, err = parseChatCursor(before)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	messages, err := s.queryMessages(ctx, `
+		SELECT message_id, message_seq, ride_id, sender_id, body, created_at
+		FROM chat_messages
+		WHERE ride_id = $1 AND ($2 = 0 OR message_seq < $2)
+		ORDER BY message_seq DESC
+		LIMIT $3
+	`, rideID, beforeSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the page oldest first
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	page.Messages = messages
+	if len(messages) == limit {
+		page.NextCursor = messages[0].Cursor
+	}
+	return page, nil
+}
+
+// MarkRead moves the user's read receipt forward to messageID. Receipts never
+// move backwards, so marking an older message returns the current receipt.
+func (s *ChatService) MarkRead(ctx context.Context, rideID, userID, messageID uuid.UUID) (*models.ChatReadReceipt, error) {
+	if _, err := s.CheckAccess(ctx, rideID, userID); err != nil {
+		return nil, err
+	}
+
+	var messageSeq int64
+	err := s.dbManager.GetPrimary().QueryRowContext(ctx,
+		"SELECT message_seq FROM chat_messages WHERE message_id = $1 AND ride_id = $2",
+		messageID, rideID).Scan(&messageSeq)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("message_not_found", "message not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("error fetching message: %w", err)
+	}
+
+	query := `
+		INSERT INTO chat_read_receipts (ride_id, user_id, last_read_message_id, last_read_seq)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (ride_id, user_id) DO UPDATE
+			SET last_read_message_id = EXCLUDED.last_read_message_id,
+				last_read_seq = EXCLUDED.last_read_seq,
+				read_at = NOW()
+			WHERE chat_read_receipts.last_read_seq < EXCLUDED.last_read_seq
+		RETURNING ride_id, user_id, last_read_message_id, read_at
+	`
+
+	var receipt models.ChatReadReceipt
+	err = s.dbManager.GetPrimary().QueryRowContext(ctx, query, rideID, userID, messageID, messageSeq).Scan(
+		&receipt.RideID,
+		&receipt.UserID,
+		&receipt.LastReadMessageID,
+		&receipt.ReadAt,
+	)
+	if err == sql.ErrNoRows {
+		// The existing receipt is already further along
+		err = s.dbManager.GetPrimary().QueryRowContext(ctx,
+			"SELECT ride_id, user_id, last_read_message_id, read_at FROM chat_read_receipts WHERE ride_id = $1 AND user_id = $2",
+			rideID, userID).Scan(&receipt.RideID, &receipt.UserID, &receipt.LastReadMessageID, &receipt.ReadAt)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching read receipt: %w", err)
+		}
+		return &receipt, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error updating read receipt: %w", err)
+	}
+
+	s.hub.Publish(rideID, chat.Event{Type: chat.EventRead, Receipt: &receipt})
+
+	return &receipt, nil
+}
+
+// ListReadReceipts returns every participant's read position in a ride's conversation
+func (s *ChatService) ListReadReceipts(ctx context.Context, rideID, userID uuid.UUID) ([]*models.ChatReadReceipt, error) {
+	if _, err := s.CheckAccess(ctx, rideID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx,
+		"SELECT ride_id, user_id, last_read_message_id, read_at FROM chat_read_receipts WHERE ride_id = $1",
+		rideID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching read receipts: %w", err)
+	}
+	defer rows.Close()
+
+	receipts := []*models.ChatReadReceipt{}
+	for rows.Next() {
+		var receipt models.ChatReadReceipt
+		if err := rows.Scan(&receipt.RideID, &receipt.UserID, &receipt.LastReadMessageID, &receipt.ReadAt); err != nil {
+			return nil, fmt.Errorf("error scanning read receipt: %w", err)
+		}
+		receipts = append(receipts, &receipt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through read receipts: %w", err)
+	}
+
+	return receipts, nil
+}
+
+func (s *ChatService) queryMessages(ctx context.Context, query string, args ...interface{}) ([]*models.ChatMessage, error) {
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []*models.ChatMessage{}
+	for rows.Next() {
+		message, err := scanChatMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func scanChatMessage(row rowScanner) (*models.ChatMessage, error) {
+	var message models.ChatMessage
+	var seq int64
+	err := row.Scan(
+		&message.ID,
+		&seq,
+		&message.RideID,
+		&message.SenderID,
+		&message.Body,
+		&message.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	message.Cursor = strconv.FormatInt(seq, 10)
+	return &message, nil
+}
+
+// parseChatCursor decodes a cursor previously returned by ListMessages
+func parseChatCursor(cursor string) (int64, error) {
+	seq, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, apperror.Invalid("cursor", "invalid_cursor", "invalid cursor")
+	}
+	return seq, nil
+}
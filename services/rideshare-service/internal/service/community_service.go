@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/audit"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"platform/shared/apperror"
+)
+
+const communityProfileColumns = `
+	user_id, COALESCE(university, ''), COALESCE(faculty, ''), COALESCE(batch, ''),
+	COALESCE(gender, ''), verified_at, verified_by, prefer_same_university,
+	prefer_same_faculty, prefer_same_batch, prefer_same_gender, updated_at
+`
+
+type CommunityService struct {
+	dbManager *db.DBManager
+}
+
+// NewCommunityService creates a new CommunityService
+func NewCommunityService(dbManager *db.DBManager) *CommunityService {
+	return &CommunityService{dbManager: dbManager}
+}
+
+// GetProfile returns a user's community profile. Users who never set one
+// get an empty, unverified profile.
+func (s *CommunityService) GetProfile(ctx context.Context, userID uuid.UUID) (*models.CommunityProfile, error) {
+	profiles, err := s.GetProfiles(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+	return profiles[userID], nil
+}
+
+// GetProfiles returns the community profiles of several users by user ID.
+// Every requested user has an entry.
+func (s *CommunityService) GetProfiles(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.CommunityProfile, error) {
+	profiles := make(map[uuid.UUID]*models.CommunityProfile, len(userIDs))
+	for _, id := range userIDs {
+		profiles[id] = &models.CommunityProfile{UserID: id}
+	}
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx,
+		"SELECT "+communityProfileColumns+" FROM community_profiles WHERE user_id = ANY($1)",
+		pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching community profiles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		profile, err := scanCommunityProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning community profile: %w", err)
+		}
+		profiles[profile.UserID] = profile
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through community profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// UpdateProfile replaces a user's community attributes and preferences.
+// Changing any attribute clears the verification, so only attributes an
+// admin has checked are used for matching.
+func (s *CommunityService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UpdateCommunityProfileRequest) (*models.CommunityProfile, error) {
+	before, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := before.Preferences
+	if req.Preferences != nil {
+		preferences = *req.Preferences
+	}
+
+	query := `
+		INSERT INTO community_profiles (
+			user_id, university, faculty, batch, gender, prefer_same_university,
+			prefer_same_faculty, prefer_same_batch, prefer_same_gender
+		) VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9)
+		ON CONFLICT (user_id) DO UPDATE SET
+			university = EXCLUDED.university,
+			faculty = EXCLUDED.faculty,
+			batch = EXCLUDED.batch,
+			gender = EXCLUDED.gender,
+			prefer_same_university = EXCLUDED.prefer_same_university,
+			prefer_same_faculty = EXCLUDED.prefer_same_faculty,
+			prefer_same_batch = EXCLUDED.prefer_same_batch,
+			prefer_same_gender = EXCLUDED.prefer_same_gender,
+			verified_at = CASE WHEN community_profiles.university IS NOT DISTINCT FROM EXCLUDED.university
+				AND community_profiles.faculty IS NOT DISTINCT FROM EXCLUDED.faculty
+				AND community_profiles.batch IS NOT DISTINCT FROM EXCLUDED.batch
+				AND community_profiles.gender IS NOT DISTINCT FROM EXCLUDED.gender
+				THEN community_profiles.verified_at END,
+			verified_by = CASE WHEN community_profiles.university IS NOT DISTINCT FROM EXCLUDED.university
+				AND community_profiles.faculty IS NOT DISTINCT FROM EXCLUDED.faculty
+				AND community_profiles.batch IS NOT DISTINCT FROM EXCLUDED.batch
+				AND community_profiles.gender IS NOT DISTINCT FROM EXCLUDED.gender
+				THEN community_profiles.verified_by END,
+			updated_at = NOW()
+		RETURNING ` + communityProfileColumns
+
+	var profile *models.CommunityProfile
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		profile, err = scanCommunityProfile(tx.QueryRowContext(ctx, query,
+			userID,
+			strings.TrimSpace(req.University),
+			strings.TrimSpace(req.Faculty),
+			strings.TrimSpace(req.Batch),
+			strings.TrimSpace(req.Gender),
+			preferences.SameUniversity,
+			preferences.SameFaculty,
+			preferences.SameBatch,
+			preferences.SameGender,
+		))
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityCommunityProfile,
+			EntityID:   userID,
+			OwnerID:    userID,
+			ActorID:    &userID,
+			Before:     before,
+			After:      profile,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating community profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// VerifyProfile marks a user's current community attributes as verified
+func (s *CommunityService) VerifyProfile(ctx context.Context, adminID, userID uuid.UUID) (*models.CommunityProfile, error) {
+	before, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if before.University == "" && before.Faculty == "" && before.Batch == "" && before.Gender == "" {
+		return nil, apperror.Conflict("no_community_attributes", "user has no community attributes to verify")
+	}
+
+	query := `
+		UPDATE community_profiles
+		SET verified_at = NOW(), verified_by = $2, updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING ` + communityProfileColumns
+
+	var profile *models.CommunityProfile
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		profile, err = scanCommunityProfile(tx.QueryRowContext(ctx, query, userID, adminID))
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionVerify,
+			EntityType: audit.EntityCommunityProfile,
+			EntityID:   userID,
+			OwnerID:    userID,
+			ActorID:    &adminID,
+			Before:     before,
+			After:      profile,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error verifying community profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+func scanCommunityProfile(row rowScanner) (*models.CommunityProfile, error) {
+	var profile models.CommunityProfile
+	err := row.Scan(
+		&profile.UserID,
+		&profile.University,
+		&profile.Faculty,
+		&profile.Batch,
+		&profile.Gender,
+		&profile.VerifiedAt,
+		&profile.VerifiedBy,
+		&profile.Preferences.SameUniversity,
+		&profile.Preferences.SameFaculty,
+		&profile.Preferences.SameBatch,
+		&profile.Preferences.SameGender,
+		&profile.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
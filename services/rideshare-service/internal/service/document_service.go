@@ -0,0 +1,457 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/audit"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/blob"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/document"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+)
+
+// maxDocumentFileName caps the stored name of an uploaded file
+const maxDocumentFileName = 255
+
+const documentColumns = `
+	document_id, user_id, vehicle_id, document_type, status, rejection_reason,
+	file_name, content_type, size_bytes, expires_on, reviewed_by, reviewed_at,
+	created_at, updated_at
+`
+
+var errDocumentNotFound = apperror.NotFound("document_not_found", "document not found")
+
+// DocumentUpload is a file a user submits for review
+type DocumentUpload struct {
+	Type      string
+	ExpiresOn string // YYYY-MM-DD
+	FileName  string
+	File      io.Reader
+}
+
+type DocumentService struct {
+	dbManager          *db.DBManager
+	store              blob.Store
+	maxSize            int64
+	requiredForHosting bool
+}
+
+// NewDocumentService creates a new DocumentService. Files larger than maxSize
+// bytes are refused. When requiredForHosting is false, hosts can offer rides
+// without documents.
+func NewDocumentService(dbManager *db.DBManager, store blob.Store, maxSize int64, requiredForHosting bool) *DocumentService {
+	return &DocumentService{
+		dbManager:          dbManager,
+		store:              store,
+		maxSize:            maxSize,
+		requiredForHosting: requiredForHosting,
+	}
+}
+
+// UploadDocument stores a document for review. Licenses are uploaded without
+// a vehicle, registrations and insurance for one of the user's vehicles.
+func (s *DocumentService) UploadDocument(ctx context.Context, userID uuid.UUID, vehicleID *uuid.UUID, upload *DocumentUpload) (*models.Document, error) {
+	if !document.ValidType(upload.Type) {
+		return nil, apperror.Invalid("type", "invalid_type", fmt.Sprintf("invalid document type: %s", upload.Type))
+	}
+	if document.IsVehicleType(upload.Type) != (vehicleID != nil) {
+		return nil, apperror.Invalid("type", "wrong_subject", "licenses are uploaded for the driver and registrations and insurance for a vehicle")
+	}
+
+	if vehicleID != nil {
+		var ownerID uuid.UUID
+		err := s.dbManager.GetReplica().QueryRowContext(ctx,
+			"SELECT user_id FROM vehicles WHERE vehicle_id = $1", *vehicleID).Scan(&ownerID)
+		if err == sql.ErrNoRows {
+			return nil, models.ErrVehicleNotFound
+		} else if err != nil {
+			return nil, fmt.Errorf("error fetching vehicle: %w", err)
+		}
+		if ownerID != userID {
+			return nil, errVehicleNotOwned
+		}
+	}
+
+	expiresOn, err := time.Parse(document.DateFormat, upload.ExpiresOn)
+	if err != nil {
+		return nil, apperror.Invalid("expiresOn", "invalid_format", "expiry date must be formatted as YYYY-MM-DD")
+	}
+	if !(document.Summary{Status: document.StatusApproved, ExpiresOn: expiresOn}).Valid(time.Now()) {
+		return nil, apperror.Invalid("expiresOn", "expired", "document has already expired")
+	}
+
+	// Read one byte past the limit to tell a full-size file from a larger one
+	data, err := io.ReadAll(io.LimitReader(upload.File, s.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading upload: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, apperror.Invalid("file", "required", "file is required")
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, apperror.Invalid("file", "too_large", fmt.Sprintf("file cannot exceed %d bytes", s.maxSize))
+	}
+	contentType, ok := document.ContentType(data)
+	if !ok {
+		return nil, apperror.Invalid("file", "unsupported_type", "file must be a PDF, JPEG or PNG")
+	}
+
+	fileName := strings.TrimSpace(filepath.Base(upload.FileName))
+	if fileName == "" || fileName == "." || fileName == string(filepath.Separator) {
+		fileName = upload.Type
+	}
+	if len(fileName) > maxDocumentFileName {
+		fileName = fileName[:maxDocumentFileName]
+	}
+
+	// The file is stored first; a failed insert deletes it again
+	documentID := uuid.New()
+	key := "documents/" + documentID.String()
+	if err := s.store.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("error storing document: %w", err)
+	}
+
+	query := `
+		INSERT INTO documents (
+			document_id, user_id, vehicle_id, document_type, blob_key, file_name,
+			content_type, size_bytes, expires_on
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + documentColumns
+
+	var doc *models.Document
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		doc, err = scanDocument(tx.QueryRowContext(ctx, query,
+			documentID, userID, vehicleID, upload.Type, key, fileName,
+			contentType, len(data), upload.ExpiresOn))
+		if err != nil {
+			return fmt.Errorf("error saving document: %w", err)
+		}
+
+		if err := appendEvent(ctx, tx, events.DocumentUploaded, events.AggregateDocument, doc.ID, &userID, documentEventData(doc)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityDocument,
+			EntityID:   doc.ID,
+			OwnerID:    userID,
+			ActorID:    &userID,
+			After:      doc,
+		})
+	})
+	if err != nil {
+		if deleteErr := s.store.Delete(ctx, key); deleteErr != nil {
+			logging.FromContext(ctx).Error("Error deleting orphaned document", "key", key, "error", deleteErr)
+		}
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// ListOwnDocuments returns the documents a user uploaded for themselves and
+// their vehicles, newest first
+func (s *DocumentService) ListOwnDocuments(ctx context.Context, userID uuid.UUID) ([]*models.Document, error) {
+	return s.listDocuments(ctx,
+		"SELECT "+documentColumns+" FROM documents WHERE user_id = $1 ORDER BY created_at DESC",
+		userID)
+}
+
+// ListDocuments returns the admin review queue, oldest documents first.
+// An empty status returns documents waiting for review.
+func (s *DocumentService) ListDocuments(ctx context.Context, status string, limit, offset int) ([]*models.Document, error) {
+	switch status {
+	case "":
+		status = document.StatusPending
+	case document.StatusPending, document.StatusApproved, document.StatusRejected:
+	default:
+		return nil, apperror.Invalid("status", "invalid_status", fmt.Sprintf("invalid document status: %s", status))
+	}
+
+	return s.listDocuments(ctx,
+		"SELECT "+documentColumns+" FROM documents WHERE status = $1 ORDER BY created_at ASC LIMIT $2 OFFSET $3",
+		status, limit, offset)
+}
+
+func (s *DocumentService) listDocuments(ctx context.Context, query string, args ...interface{}) ([]*models.Document, error) {
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching documents: %w", err)
+	}
+	defer rows.Close()
+
+	docs := []*models.Document{}
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// OpenDocument returns a document and its file, which the caller must close.
+// When ownerID is set, documents of other users are reported as not found.
+func (s *DocumentService) OpenDocument(ctx context.Context, ownerID *uuid.UUID, documentID uuid.UUID) (*models.Document, io.ReadCloser, error) {
+	var key string
+	row := s.dbManager.GetReplica().QueryRowContext(ctx,
+		"SELECT blob_key, "+documentColumns+" FROM documents WHERE document_id = $1", documentID)
+	doc, err := scanDocument(row, &key)
+	if err == sql.ErrNoRows {
+		return nil, nil, errDocumentNotFound
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("error fetching document: %w", err)
+	}
+	if ownerID != nil && doc.UserID != *ownerID {
+		return nil, nil, errDocumentNotFound
+	}
+
+	file, err := s.store.Open(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, errDocumentNotFound
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("error opening document: %w", err)
+	}
+	return doc, file, nil
+}
+
+// ReviewDocument records an admin's decision on a pending document
+func (s *DocumentService) ReviewDocument(ctx context.Context, adminID, documentID uuid.UUID, req *models.ReviewDocumentRequest) (*models.Document, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if req.Status == document.StatusRejected && reason == "" {
+		return nil, apperror.Invalid("reason", "required", "a reason is required to reject a document")
+	}
+	if req.Status == document.StatusApproved {
+		reason = ""
+	}
+
+	var after *models.Document
+	err := s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		before, err := scanDocument(tx.QueryRowContext(ctx,
+			"SELECT "+documentColumns+" FROM documents WHERE document_id = $1 FOR UPDATE", documentID))
+		if err == sql.ErrNoRows {
+			return errDocumentNotFound
+		} else if err != nil {
+			return fmt.Errorf("error fetching document: %w", err)
+		}
+		if before.Status != document.StatusPending {
+			return apperror.Conflict("document_already_reviewed", fmt.Sprintf("document has already been %s", before.Status))
+		}
+
+		after, err = scanDocument(tx.QueryRowContext(ctx, `
+			UPDATE documents
+			SET status = $1, rejection_reason = NULLIF($2, ''), reviewed_by = $3,
+				reviewed_at = NOW(), updated_at = NOW()
+			WHERE document_id = $4
+			RETURNING `+documentColumns,
+			req.Status, reason, adminID, documentID))
+		if err != nil {
+			return fmt.Errorf("error reviewing document: %w", err)
+		}
+
+		eventType, action := events.DocumentApproved, audit.ActionVerify
+		if after.Status == document.StatusRejected {
+			eventType, action = events.DocumentRejected, audit.ActionReject
+		}
+		if err := appendEvent(ctx, tx, eventType, events.AggregateDocument, after.ID, &adminID, documentEventData(after)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     action,
+			EntityType: audit.EntityDocument,
+			EntityID:   after.ID,
+			OwnerID:    after.UserID,
+			ActorID:    &adminID,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// CheckHostingEligibility returns a Forbidden error unless the host has an
+// approved, unexpired license and the vehicle approved, unexpired
+// registration and insurance
+func (s *DocumentService) CheckHostingEligibility(ctx context.Context, hostID, vehicleID uuid.UUID) error {
+	if !s.requiredForHosting {
+		return nil
+	}
+
+	query := `
+		SELECT document_type, status, expires_on
+		FROM documents
+		WHERE user_id = $1 AND status = 'approved' AND (vehicle_id IS NULL OR vehicle_id = $2)
+	`
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, hostID, vehicleID)
+	if err != nil {
+		return fmt.Errorf("error fetching host documents: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []document.Summary
+	for rows.Next() {
+		var doc document.Summary
+		if err := rows.Scan(&doc.Type, &doc.Status, &doc.ExpiresOn); err != nil {
+			return fmt.Errorf("error scanning host document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating through host documents: %w", err)
+	}
+
+	required := append(append([]string{}, document.DriverTypes...), document.VehicleTypes...)
+	if missing := document.Missing(required, docs, time.Now()); len(missing) > 0 {
+		return apperror.Forbidden("documents_required",
+			"hosting requires approved, unexpired documents: missing "+strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// PublishExpiring emits an expiring event for every approved document that
+// expires within leadDays and has not been replaced by one expiring later.
+// Like PublishStartingSoon, each document is claimed in the same transaction
+// as its event so owners are only reminded once.
+func (s *DocumentService) PublishExpiring(ctx context.Context, leadDays int) (int, error) {
+	query := `
+		UPDATE documents d
+		SET expiry_reminder_sent_at = NOW()
+		WHERE d.status = 'approved'
+			AND d.expiry_reminder_sent_at IS NULL
+			AND d.expires_on >= CURRENT_DATE
+			AND d.expires_on <= CURRENT_DATE + $1::int
+			AND NOT EXISTS (
+				SELECT 1 FROM documents n
+				WHERE n.user_id = d.user_id
+					AND n.vehicle_id IS NOT DISTINCT FROM d.vehicle_id
+					AND n.document_type = d.document_type
+					AND n.status = 'approved'
+					AND n.expires_on > d.expires_on
+			)
+		RETURNING ` + documentColumns
+
+	var claimed []*models.Document
+	err := s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, leadDays)
+		if err != nil {
+			return fmt.Errorf("error claiming documents for reminders: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			doc, err := scanDocument(rows)
+			if err != nil {
+				return fmt.Errorf("error scanning document: %w", err)
+			}
+			claimed = append(claimed, doc)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating through documents: %w", err)
+		}
+
+		for _, doc := range claimed {
+			if err := appendEvent(ctx, tx, events.DocumentExpiring, events.AggregateDocument, doc.ID, nil, documentEventData(doc)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(claimed), nil
+}
+
+// RunExpiryReminders calls PublishExpiring every interval until ctx is done
+func (s *DocumentService) RunExpiryReminders(ctx context.Context, leadDays int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PublishExpiring(ctx, leadDays); err != nil {
+			logging.FromContext(ctx).Error("Error sending document expiry reminders", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scanDocument scans documentColumns, after any extra leading columns
+func scanDocument(row rowScanner, extra ...interface{}) (*models.Document, error) {
+	var doc models.Document
+	var vehicleID, reviewedBy uuid.NullUUID
+	var expiresOn time.Time
+
+	dest := append(extra,
+		&doc.ID,
+		&doc.UserID,
+		&vehicleID,
+		&doc.Type,
+		&doc.Status,
+		&doc.RejectionReason,
+		&doc.FileName,
+		&doc.ContentType,
+		&doc.SizeBytes,
+		&expiresOn,
+		&reviewedBy,
+		&doc.ReviewedAt,
+		&doc.CreatedAt,
+		&doc.UpdatedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if vehicleID.Valid {
+		doc.VehicleID = &vehicleID.UUID
+	}
+	if reviewedBy.Valid {
+		doc.ReviewedBy = &reviewedBy.UUID
+	}
+	doc.ExpiresOn = expiresOn.Format(document.DateFormat)
+
+	return &doc, nil
+}
+
+func documentEventData(doc *models.Document) events.DocumentV1 {
+	data := events.DocumentV1{
+		DocumentID: doc.ID,
+		UserID:     doc.UserID,
+		VehicleID:  doc.VehicleID,
+		Type:       doc.Type,
+		Status:     doc.Status,
+		ExpiresOn:  doc.ExpiresOn,
+	}
+	if doc.RejectionReason != nil {
+		data.Reason = *doc.RejectionReason
+	}
+	return data
+}
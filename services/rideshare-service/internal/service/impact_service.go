@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/impact"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// Aggregates over ride_impacts rows. A user's rows are summed as they are;
+// across a campus, distance and CO2 only count the passengers' rows so a
+// ride's savings are not also counted again for its host.
+const (
+	userAggregates = `COUNT(DISTINCT i.ride_id), COALESCE(SUM(i.distance_km), 0),
+		COALESCE(SUM(i.co2_avoided_kg), 0), COALESCE(SUM(i.money_saved), 0)`
+	campusAggregates = `COUNT(DISTINCT i.ride_id),
+		COALESCE(SUM(i.distance_km) FILTER (WHERE i.role = 'passenger'), 0),
+		COALESCE(SUM(i.co2_avoided_kg) FILTER (WHERE i.role = 'passenger'), 0),
+		COALESCE(SUM(i.money_saved), 0)`
+)
+
+// Campus members are users whose community profile names the university
+// and has been verified
+const campusMembers = `
+	JOIN community_profiles c ON c.user_id = i.user_id
+	WHERE LOWER(c.university) = LOWER($1) AND c.verified_at IS NOT NULL`
+
+type ImpactService struct {
+	dbManager *db.DBManager
+	model     impact.Model
+}
+
+// NewImpactService creates a new ImpactService
+func NewImpactService(dbManager *db.DBManager, model impact.Model) *ImpactService {
+	return &ImpactService{dbManager: dbManager, model: model}
+}
+
+// RecordRide stores the impact of a ride as it is completed
+func (s *ImpactService) RecordRide(ctx context.Context, tx *sql.Tx, ride *models.Ride, completedAt time.Time) error {
+	var vehicle impact.Vehicle
+	err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(fuel_type, ''), COALESCE(efficiency, 0) FROM vehicles WHERE vehicle_id = $1",
+		ride.VehicleID).Scan(&vehicle.FuelType, &vehicle.Efficiency)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error fetching vehicle: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT p.user_id, p.seats_taken, r.pickup_latitude, r.pickup_longitude,
+			r.dropoff_latitude, r.dropoff_longitude, r.fare_breakdown
+		FROM ride_passengers p
+		JOIN ride_requests r ON r.request_id = p.request_id
+		WHERE p.ride_id = $1 AND r.status = 'accepted'`, ride.ID)
+	if err != nil {
+		return fmt.Errorf("error fetching passengers: %w", err)
+	}
+	defer rows.Close()
+
+	fares := s.model.Fares
+	record := impact.Ride{
+		HostID:  ride.HostID,
+		RouteKm: fares.PathKm(stopPoints(rideStops(ride))...),
+		Vehicle: vehicle,
+	}
+	for rows.Next() {
+		var p impact.Passenger
+		var pickupLat, pickupLon float64
+		var dropoffLat, dropoffLon sql.NullFloat64
+		var fareBreakdown sql.NullString
+		if err := rows.Scan(&p.UserID, &p.Seats, &pickupLat, &pickupLon, &dropoffLat, &dropoffLon, &fareBreakdown); err != nil {
+			return fmt.Errorf("error scanning passenger: %w", err)
+		}
+
+		// Requests accepted before fares were recorded paid the listed
+		// price and are assumed to ride to the destination
+		if fareBreakdown.Valid {
+			var breakdown models.FareBreakdown
+			if err := json.Unmarshal([]byte(fareBreakdown.String), &breakdown); err != nil {
+				return fmt.Errorf("error decoding fare: %w", err)
+			}
+			p.DistanceKm, p.DetourKm, p.FarePaid = breakdown.SegmentKm, breakdown.DetourKm, breakdown.Total
+		} else {
+			lat, lon := ride.DestinationLatitude, ride.DestinationLongitude
+			if dropoffLat.Valid && dropoffLon.Valid {
+				lat, lon = dropoffLat.Float64, dropoffLon.Float64
+			}
+			p.DistanceKm = fares.RoadKm(pickupLat, pickupLon, lat, lon)
+			p.FarePaid = float64(p.Seats) * ride.PricePerSeat
+		}
+		record.Passengers = append(record.Passengers, p)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating through passengers: %w", err)
+	}
+
+	for _, share := range s.model.Shares(record) {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO ride_impacts (
+				ride_id, user_id, role, distance_km, seats, co2_avoided_kg, money_saved, currency, completed_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (ride_id, user_id) DO NOTHING`,
+			ride.ID, share.UserID, share.Role, share.DistanceKm, share.Seats,
+			share.Co2AvoidedKg, share.MoneySaved, fares.Currency, completedAt)
+		if err != nil {
+			return fmt.Errorf("error recording impact: %w", err)
+		}
+	}
+	return nil
+}
+
+// UserImpact returns a user's impact as a passenger and as a host, for the
+// passenger and host dashboards
+func (s *ImpactService) UserImpact(ctx context.Context, userID uuid.UUID, window impact.Window) (*models.UserImpact, error) {
+	result := &models.UserImpact{UserID: userID, Period: window.Period}
+
+	var err error
+	result.Passenger, err = s.summarize(ctx, userAggregates, "WHERE i.user_id = $1 AND i.role = $2",
+		[]interface{}{userID, impact.RolePassenger}, window)
+	if err != nil {
+		return nil, err
+	}
+	result.Host, err = s.summarize(ctx, userAggregates, "WHERE i.user_id = $1 AND i.role = $2",
+		[]interface{}{userID, impact.RoleHost}, window)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CampusImpact totals the impact of a university's verified members
+func (s *ImpactService) CampusImpact(ctx context.Context, university string, window impact.Window) (*models.CampusImpact, error) {
+	summary, err := s.summarize(ctx, campusAggregates, campusMembers, []interface{}{university}, window)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CampusImpact{University: university, Period: window.Period, ImpactSummary: summary}
+	where, args := windowFilter(campusMembers, []interface{}{university}, window)
+	err = s.dbManager.GetReplica().QueryRowContext(ctx,
+		"SELECT COUNT(DISTINCT i.user_id) FROM ride_impacts i "+where, args...).Scan(&result.Members)
+	if err != nil {
+		return nil, fmt.Errorf("error counting campus members: %w", err)
+	}
+	return result, nil
+}
+
+// Leaderboard ranks a university's verified members by the emissions they
+// helped avoid, as passengers and as hosts. Names are shortened to a first
+// name and initial.
+func (s *ImpactService) Leaderboard(ctx context.Context, university string, window impact.Window, limit int) (*models.Leaderboard, error) {
+	where, args := windowFilter(campusMembers, []interface{}{university}, window)
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT i.user_id, u.first_name, u.last_name, COUNT(DISTINCT i.ride_id),
+			SUM(i.co2_avoided_kg), SUM(i.money_saved)
+		FROM ride_impacts i
+		JOIN users u ON u.user_id = i.user_id
+		%s
+		GROUP BY i.user_id, u.first_name, u.last_name
+		ORDER BY SUM(i.co2_avoided_kg) DESC, COUNT(DISTINCT i.ride_id) DESC, i.user_id
+		LIMIT $%d`, where, len(args))
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	board := &models.Leaderboard{University: university, Period: window.Period, Entries: []models.LeaderboardEntry{}}
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		var firstName, lastName string
+		if err := rows.Scan(&entry.UserID, &firstName, &lastName, &entry.Rides, &entry.Co2AvoidedKg, &entry.MoneySaved); err != nil {
+			return nil, fmt.Errorf("error scanning leaderboard entry: %w", err)
+		}
+		entry.Rank = len(board.Entries) + 1
+		entry.DisplayName = displayName(firstName, lastName)
+		board.Entries = append(board.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through leaderboard: %w", err)
+	}
+	return board, nil
+}
+
+// summarize totals the ride_impacts rows matching where over the window,
+// and buckets them by the window's interval when it has one
+func (s *ImpactService) summarize(ctx context.Context, aggregates, where string, args []interface{}, window impact.Window) (models.ImpactSummary, error) {
+	where, args = windowFilter(where, args, window)
+	summary := models.ImpactSummary{ImpactTotals: models.ImpactTotals{Currency: s.model.Fares.Currency}}
+
+	replica := s.dbManager.GetReplica()
+	err := replica.QueryRowContext(ctx, "SELECT "+aggregates+" FROM ride_impacts i "+where, args...).Scan(
+		&summary.Rides, &summary.DistanceKm, &summary.Co2AvoidedKg, &summary.MoneySaved)
+	if err != nil {
+		return summary, fmt.Errorf("error totalling impact: %w", err)
+	}
+	if window.Interval == "" {
+		return summary, nil
+	}
+
+	// The interval is one of impact's fixed names, so it is safe to inline
+	query := fmt.Sprintf(`
+		SELECT date_trunc('%s', i.completed_at AT TIME ZONE 'UTC'), %s
+		FROM ride_impacts i %s
+		GROUP BY 1 ORDER BY 1`, window.Interval, aggregates, where)
+	rows, err := replica.QueryContext(ctx, query, args...)
+	if err != nil {
+		return summary, fmt.Errorf("error fetching impact series: %w", err)
+	}
+	defer rows.Close()
+
+	summary.Series = []models.ImpactPoint{}
+	for rows.Next() {
+		point := models.ImpactPoint{ImpactTotals: models.ImpactTotals{Currency: s.model.Fares.Currency}}
+		if err := rows.Scan(&point.PeriodStart, &point.Rides, &point.DistanceKm, &point.Co2AvoidedKg, &point.MoneySaved); err != nil {
+			return summary, fmt.Errorf("error scanning impact series: %w", err)
+		}
+		point.PeriodStart = point.PeriodStart.UTC()
+		summary.Series = append(summary.Series, point)
+	}
+	if err := rows.Err(); err != nil {
+		return summary, fmt.Errorf("error iterating through impact series: %w", err)
+	}
+	return summary, nil
+}
+
+// windowFilter restricts a WHERE clause to rides completed in the window
+func windowFilter(where string, args []interface{}, window impact.Window) (string, []interface{}) {
+	if window.Since == nil {
+		return where, args
+	}
+	args = append(args, *window.Since)
+	return fmt.Sprintf("%s AND i.completed_at >= $%d", where, len(args)), args
+}
+
+// displayName shortens a name to the first name and last initial
+func displayName(firstName, lastName string) string {
+	lastName = strings.TrimSpace(lastName)
+	if lastName == "" {
+		return firstName
+	}
+	return firstName + " " + strings.ToUpper(string([]rune(lastName)[:1])) + "."
+}
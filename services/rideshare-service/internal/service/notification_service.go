@@ -0,0 +1,620 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/document"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/notification"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/timezone"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+)
+
+const (
+	defaultNotificationLimit = 20
+	maxNotificationLimit     = 100
+
+	// deliveryLease is how long a claimed email or webhook delivery is left
+	// to the worker that claimed it before another may pick it up
+	deliveryLease = 5 * time.Minute
+)
+
+type NotificationService struct {
+	dbManager    *db.DBManager
+	dispatcher   *notification.Dispatcher
+	rideLinkBase string
+}
+
+// NewNotificationService creates a new NotificationService. Messages link to
+// rideLinkBase followed by the ride ID.
+func NewNotificationService(dbManager *db.DBManager, dispatcher *notification.Dispatcher, rideLinkBase string) *NotificationService {
+	return &NotificationService{
+		dbManager:    dbManager,
+		dispatcher:   dispatcher,
+		rideLinkBase: rideLinkBase,
+	}
+}
+
+// recipientPrefs pairs a recipient's contact details with their channels
+type recipientPrefs struct {
+	recipient notification.Recipient
+	channels  []string
+}
+
+// HandleEvent notifies everyone affected by a ride, request or document event.
+// Reads go to the primary because events fire right after the write.
+func (s *NotificationService) HandleEvent(ctx context.Context, event events.Event) error {
+	switch event.Type {
+	case events.DocumentApproved, events.DocumentRejected, events.DocumentExpiring:
+		return s.handleDocumentEvent(ctx, event)
+	}
+
+	var rideID uuid.UUID
+	var request events.RideRequestV1
+	var match events.SavedSearchMatchV1
+	data := notification.TemplateData{}
+
+	switch event.Type {
+	case events.RequestCreated, events.RequestAccepted, events.RequestRejected:
+		if err := event.Decode(&request); err != nil {
+			return err
+		}
+		rideID = request.RideID
+		data.Seats = request.SeatsRequested
+	case events.RideUpdated, events.RideCancelled, events.RideStartingSoon:
+		var ride events.RideV1
+		if err := event.Decode(&ride); err != nil {
+			return err
+		}
+		rideID = ride.RideID
+		data.Changes = ride.Changes
+	case events.SavedSearchMatched:
+		if err := event.Decode(&match); err != nil {
+			return err
+		}
+		rideID = match.RideID
+		data.SearchName = match.Name
+	default:
+		return nil
+	}
+
+	var hostID uuid.UUID
+	var zone string
+	err := s.dbManager.GetPrimary().QueryRowContext(ctx,
+		"SELECT host_id, origin_address, destination_address, departure_time, time_zone FROM rides WHERE ride_id = $1",
+		rideID).Scan(&hostID, &data.Origin, &data.Destination, &data.DepartureTime, &zone)
+	if err == sql.ErrNoRows {
+		return models.ErrRideNotFound
+	} else if err != nil {
+		return fmt.Errorf("error fetching ride: %w", err)
+	}
+	// Times are shown on the ride's local clock
+	if loc, err := timezone.Load(zone); err == nil {
+		data.Location = loc
+	}
+
+	var recipientIDs []uuid.UUID
+	switch event.Type {
+	case events.RequestCreated:
+		err = s.dbManager.GetPrimary().QueryRowContext(ctx,
+			"SELECT first_name FROM users WHERE user_id = $1",
+			request.RiderID).Scan(&data.ActorName)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("error fetching rider: %w", err)
+		}
+		recipientIDs = []uuid.UUID{hostID}
+	case events.RequestAccepted, events.RequestRejected:
+		recipientIDs = []uuid.UUID{request.RiderID}
+	case events.RideUpdated:
+		recipientIDs, err = s.rideParticipants(ctx, rideID, false)
+	case events.RideCancelled:
+		recipientIDs, err = s.rideParticipants(ctx, rideID, true)
+	case events.RideStartingSoon:
+		recipientIDs, err = s.rideParticipants(ctx, rideID, false)
+		recipientIDs = append(recipientIDs, hostID)
+	case events.SavedSearchMatched:
+		recipientIDs = []uuid.UUID{match.UserID}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Nobody needs to hear about their own actions
+	if event.ActorID != nil {
+		filtered := recipientIDs[:0]
+		for _, id := range recipientIDs {
+			if id != *event.ActorID {
+				filtered = append(filtered, id)
+			}
+		}
+		recipientIDs = filtered
+	}
+	if len(recipientIDs) == 0 {
+		return nil
+	}
+
+	title, body, err := notification.Render(event.Type, data)
+	if err != nil {
+		return err
+	}
+
+	return s.deliver(ctx, recipientIDs, notification.Message{
+		EventID:   event.ID,
+		Type:      string(event.Type),
+		Title:     title,
+		Body:      body,
+		RideID:    &rideID,
+		Link:      s.rideLinkBase + rideID.String(),
+		CreatedAt: event.OccurredAt,
+	})
+}
+
+// handleDocumentEvent tells the owner of a document about its review or
+// upcoming expiry
+func (s *NotificationService) handleDocumentEvent(ctx context.Context, event events.Event) error {
+	var doc events.DocumentV1
+	if err := event.Decode(&doc); err != nil {
+		return err
+	}
+
+	title, body, err := notification.Render(event.Type, notification.TemplateData{
+		Document:          document.Label(doc.Type),
+		DocumentExpiresOn: doc.ExpiresOn,
+		Reason:            doc.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.deliver(ctx, []uuid.UUID{doc.UserID}, notification.Message{
+		EventID:   event.ID,
+		Type:      string(event.Type),
+		Title:     title,
+		Body:      body,
+		CreatedAt: event.OccurredAt,
+	})
+}
+
+// deliver puts msg in each user's inbox and queues it for their other
+// enabled channels. Email and webhook endpoints are outside our control, so
+// they are sent by RunDeliveries rather than while the event is handled: a
+// dead one must not hold back the ride's later events, and a redelivered
+// event must not send them again.
+func (s *NotificationService) deliver(ctx context.Context, userIDs []uuid.UUID, msg notification.Message) error {
+	recipients, err := s.loadRecipients(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, r := range recipients {
+		var inbox, external []string
+		for _, name := range r.channels {
+			if name == notification.ChannelInbox {
+				inbox = append(inbox, name)
+			} else {
+				external = append(external, name)
+			}
+		}
+
+		if err := s.dispatcher.Deliver(ctx, r.recipient, inbox, msg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.queueDeliveries(ctx, r.recipient.UserID, external, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// queueDeliveries adds a delivery for each configured channel. Each one is
+// keyed by user, event and channel, so a redelivered event queues nothing new.
+func (s *NotificationService) queueDeliveries(ctx context.Context, userID uuid.UUID, channels []string, msg notification.Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("error encoding notification: %w", err)
+	}
+
+	for _, name := range channels {
+		if !s.dispatcher.Has(name) {
+			continue
+		}
+		_, err := s.dbManager.GetPrimary().ExecContext(ctx, `
+			INSERT INTO notification_deliveries (user_id, event_id, channel, message)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, event_id, channel) DO NOTHING`,
+			userID, msg.EventID, name, payload)
+		if err != nil {
+			return fmt.Errorf("error queueing %s notification: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// queuedDelivery is a claimed row of notification_deliveries
+type queuedDelivery struct {
+	id       uuid.UUID
+	userID   uuid.UUID
+	channel  string
+	msg      notification.Message
+	attempts int
+}
+
+// RunDeliveries sends queued email and webhook notifications every interval
+// until ctx is done
+func (s *NotificationService) RunDeliveries(ctx context.Context, batchSize int, interval time.Duration) {
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			// Keep draining while full batches are coming back
+			n, err := s.SendQueued(ctx, batchSize)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error sending queued notifications", "error", err)
+			}
+			if err != nil || n < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendQueued makes one attempt at up to limit due deliveries and returns how
+// many it claimed. Claiming commits before anything is sent, so no
+// transaction is held open on a slow endpoint. A delivery whose worker died
+// mid-send is picked up again when its lease runs out.
+func (s *NotificationService) SendQueued(ctx context.Context, limit int) (int, error) {
+	query := `
+		UPDATE notification_deliveries d
+		SET attempts = d.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM (
+			SELECT delivery_id FROM notification_deliveries
+			WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE d.delivery_id = due.delivery_id
+		RETURNING d.delivery_id, d.user_id, d.channel, d.message, d.attempts
+	`
+
+	rows, err := s.dbManager.GetPrimary().QueryContext(ctx, query, limit, deliveryLease.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("error claiming notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []queuedDelivery
+	for rows.Next() {
+		var q queuedDelivery
+		var payload []byte
+		if err := rows.Scan(&q.id, &q.userID, &q.channel, &payload, &q.attempts); err != nil {
+			return 0, fmt.Errorf("error scanning notification delivery: %w", err)
+		}
+		if err := json.Unmarshal(payload, &q.msg); err != nil {
+			return 0, fmt.Errorf("error decoding notification delivery: %w", err)
+		}
+		claimed = append(claimed, q)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating through notification deliveries: %w", err)
+	}
+	rows.Close()
+
+	for _, q := range claimed {
+		if err := s.sendQueued(ctx, q); err != nil {
+			return len(claimed), err
+		}
+	}
+	return len(claimed), nil
+}
+
+// sendQueued makes one attempt at a claimed delivery and records the outcome
+func (s *NotificationService) sendQueued(ctx context.Context, q queuedDelivery) error {
+	recipients, err := s.loadRecipients(ctx, []uuid.UUID{q.userID})
+	if err != nil {
+		return err
+	}
+
+	// Accounts deactivated since the event are not contacted
+	sendErr := notification.Permanent(errors.New("recipient is no longer active"))
+	if len(recipients) == 1 {
+		sendCtx, cancel := context.WithTimeout(ctx, deliveryLease)
+		sendErr = s.dispatcher.Send(sendCtx, recipients[0].recipient, q.channel, q.msg)
+		cancel()
+	}
+
+	db := s.dbManager.GetPrimary()
+	if sendErr == nil {
+		_, err = db.ExecContext(ctx,
+			"UPDATE notification_deliveries SET delivered_at = NOW(), last_error = NULL WHERE delivery_id = $1",
+			q.id)
+	} else if delay, retry := s.dispatcher.RetryAfter(q.attempts, sendErr); retry {
+		_, err = db.ExecContext(ctx,
+			"UPDATE notification_deliveries SET last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 second' WHERE delivery_id = $1",
+			q.id, sendErr.Error(), delay.Seconds())
+	} else {
+		logging.FromContext(ctx).Warn("Notification not delivered", "userID", q.userID, "eventID", q.msg.EventID, "channel", q.channel, "error", sendErr)
+		_, err = db.ExecContext(ctx,
+			"UPDATE notification_deliveries SET last_error = $2, failed_at = NOW() WHERE delivery_id = $1",
+			q.id, sendErr.Error())
+	}
+	if err != nil {
+		return fmt.Errorf("error recording notification delivery: %w", err)
+	}
+	return nil
+}
+
+// rideParticipants returns the confirmed passengers of a ride, and optionally
+// riders whose requests are still pending
+func (s *NotificationService) rideParticipants(ctx context.Context, rideID uuid.UUID, includePending bool) ([]uuid.UUID, error) {
+	query := "SELECT user_id FROM ride_passengers WHERE ride_id = $1"
+	if includePending {
+		query += " UNION SELECT rider_id FROM ride_requests WHERE ride_id = $1 AND status = 'pending'"
+	}
+
+	rows, err := s.dbManager.GetPrimary().QueryContext(ctx, query, rideID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ride participants: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning participant: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through participants: %w", err)
+	}
+
+	return ids, nil
+}
+
+// loadRecipients fetches contact details and enabled channels for each user
+func (s *NotificationService) loadRecipients(ctx context.Context, userIDs []uuid.UUID) ([]recipientPrefs, error) {
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		SELECT u.user_id, u.email, u.first_name,
+			COALESCE(p.inbox_enabled, TRUE), COALESCE(p.email_enabled, TRUE),
+			COALESCE(p.webhook_enabled, FALSE), p.webhook_url
+		FROM users u
+		LEFT JOIN notification_preferences p ON p.user_id = u.user_id
+		WHERE u.user_id = ANY($1::uuid[]) AND u.is_active = TRUE
+	`
+
+	rows, err := s.dbManager.GetPrimary().QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []recipientPrefs
+	for rows.Next() {
+		var r recipientPrefs
+		var inbox, email, webhook bool
+		var webhookURL sql.NullString
+		err := rows.Scan(&r.recipient.UserID, &r.recipient.Email, &r.recipient.FirstName,
+			&inbox, &email, &webhook, &webhookURL)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning recipient: %w", err)
+		}
+		r.recipient.WebhookURL = webhookURL.String
+
+		if inbox {
+			r.channels = append(r.channels, notification.ChannelInbox)
+		}
+		if email {
+			r.channels = append(r.channels, notification.ChannelEmail)
+		}
+		if webhook && webhookURL.Valid {
+			r.channels = append(r.channels, notification.ChannelWebhook)
+		}
+		recipients = append(recipients, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through recipients: %w", err)
+	}
+
+	return recipients, nil
+}
+
+// ListNotifications returns a page of the user's inbox along with their unread count
+func (s *NotificationService) ListNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) (*models.NotificationPage, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `
+		SELECT notification_id, user_id, notification_type, title, message, related_ride_id,
+			is_read, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND ($2 = FALSE OR is_read = FALSE)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching notifications: %w", err)
+	}
+	defer rows.Close()
+
+	page := &models.NotificationPage{Notifications: []*models.Notification{}}
+	for rows.Next() {
+		var n models.Notification
+		err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Message, &n.RideID,
+			&n.IsRead, &n.ReadAt, &n.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning notification: %w", err)
+		}
+		page.Notifications = append(page.Notifications, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through notifications: %w", err)
+	}
+
+	page.UnreadCount, err = s.UnreadCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// UnreadCount returns how many unread notifications the user has
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := s.dbManager.GetReplica().QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND is_read = FALSE",
+		userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the user's notifications as read
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID uuid.UUID) error {
+	result, err := s.dbManager.GetPrimary().ExecContext(ctx,
+		`UPDATE notifications SET is_read = TRUE, read_at = COALESCE(read_at, NOW())
+		WHERE notification_id = $1 AND user_id = $2`,
+		notificationID, userID)
+	if err != nil {
+		return fmt.Errorf("error marking notification as read: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %w", err)
+	}
+	if rows == 0 {
+		return apperror.NotFound("notification_not_found", "notification not found")
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification as read and returns how many changed
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := s.dbManager.GetPrimary().ExecContext(ctx,
+		"UPDATE notifications SET is_read = TRUE, read_at = NOW() WHERE user_id = $1 AND is_read = FALSE",
+		userID)
+	if err != nil {
+		return 0, fmt.Errorf("error marking notifications as read: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetPreferences returns the user's channel preferences, or the defaults if
+// they have never changed them
+func (s *NotificationService) GetPreferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error) {
+	prefs := &models.NotificationPreferences{
+		UserID:       userID,
+		InboxEnabled: true,
+		EmailEnabled: true,
+	}
+
+	err := s.dbManager.GetReplica().QueryRowContext(ctx,
+		`SELECT inbox_enabled, email_enabled, webhook_enabled, webhook_url, updated_at
+		FROM notification_preferences WHERE user_id = $1`,
+		userID).Scan(&prefs.InboxEnabled, &prefs.EmailEnabled, &prefs.WebhookEnabled, &prefs.WebhookURL, &prefs.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error fetching notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// UpdatePreferences changes the user's channel preferences
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uuid.UUID, req *models.UpdateNotificationPreferencesRequest) (*models.NotificationPreferences, error) {
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.InboxEnabled != nil {
+		prefs.InboxEnabled = *req.InboxEnabled
+	}
+	if req.EmailEnabled != nil {
+		prefs.EmailEnabled = *req.EmailEnabled
+	}
+	if req.WebhookEnabled != nil {
+		prefs.WebhookEnabled = *req.WebhookEnabled
+	}
+	if req.WebhookURL != nil {
+		if *req.WebhookURL == "" {
+			prefs.WebhookURL = nil
+		} else {
+			if err := validateWebhookURL(*req.WebhookURL); err != nil {
+				return nil, err
+			}
+			prefs.WebhookURL = req.WebhookURL
+		}
+	}
+
+	if prefs.WebhookEnabled && prefs.WebhookURL == nil {
+		return nil, apperror.Invalid("webhookUrl", "required", "webhook URL is required to enable webhooks")
+	}
+
+	query := `
+		INSERT INTO notification_preferences (user_id, inbox_enabled, email_enabled, webhook_enabled, webhook_url)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET inbox_enabled = EXCLUDED.inbox_enabled,
+			email_enabled = EXCLUDED.email_enabled,
+			webhook_enabled = EXCLUDED.webhook_enabled,
+			webhook_url = EXCLUDED.webhook_url,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	err = s.dbManager.GetPrimary().QueryRowContext(ctx, query,
+		userID, prefs.InboxEnabled, prefs.EmailEnabled, prefs.WebhookEnabled, prefs.WebhookURL,
+	).Scan(&prefs.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error saving notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+func validateWebhookURL(raw string) error {
+	if err := notification.ValidateWebhookURL(raw); err != nil {
+		return apperror.Invalid("webhookUrl", "invalid_url", err.Error())
+	}
+	return nil
+}
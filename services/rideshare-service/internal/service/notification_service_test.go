@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/notification"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// stubChannel records sends and returns err from each of them
+type stubChannel struct {
+	err   error
+	calls int
+}
+
+func (c *stubChannel) Name() string { return notification.ChannelEmail }
+
+func (c *stubChannel) Send(ctx context.Context, to notification.Recipient, msg notification.Message) error {
+	c.calls++
+	return c.err
+}
+
+func TestSendQueued(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		sendErr  error
+		update   string
+	}{
+		{name: "marks a sent delivery delivered", attempts: 1, update: "SET delivered_at"},
+		{name: "schedules a failed delivery for retry", attempts: 1, sendErr: errors.New("unavailable"), update: "SET last_error = \\$2, next_attempt_at"},
+		{name: "gives up after the last attempt", attempts: 3, sendErr: errors.New("unavailable"), update: "SET last_error = \\$2, failed_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, mock := newMockDB(t)
+			deliveryID, userID := uuid.New(), uuid.New()
+			msg, _ := json.Marshal(notification.Message{EventID: uuid.New(), Title: "Ride cancelled"})
+
+			mock.ExpectQuery("UPDATE notification_deliveries").WithArgs(10, deliveryLease.Seconds()).
+				WillReturnRows(sqlmock.NewRows([]string{"delivery_id", "user_id", "channel", "message", "attempts"}).
+					AddRow(deliveryID, userID, notification.ChannelEmail, msg, tt.attempts))
+			mock.ExpectQuery("FROM users u").
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "first_name", "inbox", "email", "webhook", "webhook_url"}).
+					AddRow(userID, "rider@uni.edu", "Sam", true, true, false, nil))
+			mock.ExpectExec(tt.update).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			email := &stubChannel{err: tt.sendErr}
+			dispatcher := notification.NewDispatcher(notification.Backoff{MaxAttempts: 3, InitialDelay: time.Second}, email)
+			n, err := NewNotificationService(manager, dispatcher, "").SendQueued(context.Background(), 10)
+			if err != nil || n != 1 || email.calls != 1 {
+				t.Errorf("SendQueued = %d, %v after %d sends, want 1 delivery sent once", n, err, email.calls)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
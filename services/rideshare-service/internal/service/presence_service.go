@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/presence"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"platform/shared/apperror"
+)
+
+// MaxPresenceLookup caps how many users can be looked up in one call
+const MaxPresenceLookup = 100
+
+type PresenceService struct {
+	store     presence.Store
+	dbManager *db.DBManager
+}
+
+// NewPresenceService creates a new PresenceService
+func NewPresenceService(store presence.Store, dbManager *db.DBManager) *PresenceService {
+	return &PresenceService{store: store, dbManager: dbManager}
+}
+
+// Heartbeat marks the user as online now
+func (s *PresenceService) Heartbeat(ctx context.Context, userID uuid.UUID) error {
+	return s.store.Heartbeat(ctx, userID, time.Now())
+}
+
+// Disconnect marks the user as offline now
+func (s *PresenceService) Disconnect(ctx context.Context, userID uuid.UUID) error {
+	return s.store.Disconnect(ctx, userID)
+}
+
+// Lookup returns the online status and last-seen time of each user. Callers
+// may only look up users they share a ride with, so presence cannot be used
+// to follow the activity of strangers.
+func (s *PresenceService) Lookup(ctx context.Context, callerID uuid.UUID, userIDs []uuid.UUID) ([]models.PresenceStatus, error) {
+	if len(userIDs) == 0 {
+		return nil, apperror.Invalid("userIds", "required", "at least one user ID is required")
+	}
+	if len(userIDs) > MaxPresenceLookup {
+		return nil, apperror.Invalid("userIds", "too_many", fmt.Sprintf("cannot look up more than %d users at once", MaxPresenceLookup))
+	}
+
+	if err := s.checkSharesRide(ctx, callerID, userIDs); err != nil {
+		return nil, err
+	}
+	return s.store.Lookup(ctx, userIDs)
+}
+
+// checkSharesRide fails unless every user is the caller, or shares a ride
+// with the caller as its host or as a rider with a pending or accepted
+// request
+func (s *PresenceService) checkSharesRide(ctx context.Context, callerID uuid.UUID, userIDs []uuid.UUID) error {
+	query := `
+		SELECT COUNT(DISTINCT u) FROM unnest($2::uuid[]) AS u
+		WHERE u = $1
+			OR EXISTS (
+				SELECT 1 FROM ride_requests q JOIN rides r ON r.ride_id = q.ride_id
+				WHERE q.status IN ('pending', 'accepted')
+					AND ((r.host_id = $1 AND q.rider_id = u) OR (r.host_id = u AND q.rider_id = $1))
+			)
+			OR EXISTS (
+				SELECT 1 FROM ride_requests mine JOIN ride_requests theirs ON theirs.ride_id = mine.ride_id
+				WHERE mine.rider_id = $1 AND theirs.rider_id = u
+					AND mine.status = 'accepted' AND theirs.status = 'accepted'
+			)
+	`
+
+	ids := make([]string, len(userIDs))
+	distinct := make(map[uuid.UUID]bool, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+		distinct[id] = true
+	}
+
+	var shared int
+	if err := s.dbManager.GetReplica().QueryRowContext(ctx, query, callerID, pq.Array(ids)).Scan(&shared); err != nil {
+		return fmt.Errorf("error checking shared rides: %w", err)
+	}
+	if shared != len(distinct) {
+		return apperror.Forbidden("presence_not_shared", "presence is only visible to users who share a ride")
+	}
+	return nil
+}
+
+// AttachRiderPresence fills in RiderPresence on each request. Presence is
+// best effort, so lookup failures leave the requests unchanged.
+func (s *PresenceService) AttachRiderPresence(ctx context.Context, requests []*models.RideRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	var riderIDs []uuid.UUID
+	for _, req := range requests {
+		if !seen[req.RiderID] {
+			seen[req.RiderID] = true
+			riderIDs = append(riderIDs, req.RiderID)
+		}
+	}
+
+	byRider := make(map[uuid.UUID]models.PresenceStatus, len(riderIDs))
+	for start := 0; start < len(riderIDs); start += MaxPresenceLookup {
+		end := start + MaxPresenceLookup
+		if end > len(riderIDs) {
+			end = len(riderIDs)
+		}
+
+		statuses, err := s.store.Lookup(ctx, riderIDs[start:end])
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			byRider[status.UserID] = status
+		}
+	}
+
+	for _, req := range requests {
+		if status, ok := byRider[req.RiderID]; ok {
+			status := status
+			req.RiderPresence = &status
+		}
+	}
+
+	return nil
+}
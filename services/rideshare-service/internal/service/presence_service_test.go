@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/presence"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestPresenceLookup(t *testing.T) {
+	caller, rider, stranger := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		userIDs  []uuid.UUID
+		shared   int
+		wantCode string
+	}{
+		{name: "shows riders who share a ride", userIDs: []uuid.UUID{rider, caller}, shared: 2},
+		{name: "counts repeated IDs once", userIDs: []uuid.UUID{rider, rider}, shared: 1},
+		{name: "hides users who share no ride", userIDs: []uuid.UUID{rider, stranger}, shared: 1, wantCode: "presence_not_shared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, mock := newMockDB(t)
+			mock.ExpectQuery("unnest").WithArgs(caller, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.shared))
+
+			store := presence.NewMemoryStore(time.Minute)
+			store.Heartbeat(context.Background(), rider, time.Now())
+
+			statuses, err := NewPresenceService(store, manager).Lookup(context.Background(), caller, tt.userIDs)
+			wantCode(t, err, tt.wantCode)
+			if tt.wantCode == "" && (len(statuses) != len(tt.userIDs) || !statuses[0].Online) {
+				t.Errorf("statuses = %+v, want the rider online", statuses)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+)
+
+// maxReportDescriptionLength caps the free-text part of an abuse report
+const maxReportDescriptionLength = 2000
+
+type ReportService struct {
+	dbManager *db.DBManager
+}
+
+// NewReportService creates a new ReportService
+func NewReportService(dbManager *db.DBManager) *ReportService {
+	return &ReportService{dbManager: dbManager}
+}
+
+// CreateReport files an abuse report against a user for a specific ride.
+// Both the reporter and the reported user must have taken part in the ride,
+// either as its host or as a rider whose request was accepted. A user can
+// report someone only once per ride.
+func (s *ReportService) CreateReport(ctx context.Context, reporterID uuid.UUID, req *models.CreateReportRequest) (*models.AbuseReport, error) {
+	if req.ReportedUserID == uuid.Nil || req.RideID == uuid.Nil {
+		return nil, apperror.Validation("required", "ride and reported user are required",
+			apperror.FieldError{Field: "rideId", Code: "required", Message: "ride is required"},
+			apperror.FieldError{Field: "reportedUserId", Code: "required", Message: "reported user is required"})
+	}
+
+	if req.ReportedUserID == reporterID {
+		return nil, apperror.Invalid("reportedUserId", "self_report", "users cannot report themselves")
+	}
+
+	if !isValidReportCategory(req.Category) {
+		return nil, apperror.Invalid("category", "invalid_category", fmt.Sprintf("invalid report category: %s", req.Category))
+	}
+
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return nil, apperror.Invalid("description", "required", "report description is required")
+	}
+	if len(description) > maxReportDescriptionLength {
+		return nil, apperror.Invalid("description", "too_long",
+			fmt.Sprintf("report description cannot exceed %d characters", maxReportDescriptionLength))
+	}
+
+	// Check that both users were involved in the ride. Riders whose request
+	// was rejected or cancelled never rode, so they do not count.
+	participationQuery := `
+		SELECT
+			EXISTS(SELECT 1 FROM rides WHERE ride_id = $1),
+			EXISTS(SELECT 1 FROM rides WHERE ride_id = $1 AND host_id = $2)
+				OR EXISTS(SELECT 1 FROM ride_requests WHERE ride_id = $1 AND rider_id = $2 AND status = 'accepted'),
+			EXISTS(SELECT 1 FROM rides WHERE ride_id = $1 AND host_id = $3)
+				OR EXISTS(SELECT 1 FROM ride_requests WHERE ride_id = $1 AND rider_id = $3 AND status = 'accepted')
+	`
+
+	var rideExists, reporterInvolved, reportedInvolved bool
+	err := s.dbManager.GetReplica().QueryRowContext(ctx, participationQuery, req.RideID, reporterID, req.ReportedUserID).Scan(
+		&rideExists,
+		&reporterInvolved,
+		&reportedInvolved,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error checking ride participation: %w", err)
+	}
+
+	if !rideExists {
+		return nil, models.ErrRideNotFound
+	}
+	if !reporterInvolved || !reportedInvolved {
+		return nil, apperror.Forbidden("not_ride_participant", "both users must have taken part in the ride")
+	}
+
+	query := `
+		INSERT INTO abuse_reports (reporter_id, reported_user_id, ride_id, category, description)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (reporter_id, reported_user_id, ride_id) DO NOTHING
+		RETURNING report_id, reporter_id, reported_user_id, ride_id, category, description,
+			status, reviewed_by, resolution_notes, reviewed_at, created_at, updated_at
+	`
+
+	report, err := scanReport(s.dbManager.GetPrimary().QueryRowContext(
+		ctx,
+		query,
+		reporterID,
+		req.ReportedUserID,
+		req.RideID,
+		req.Category,
+		description,
+	))
+	if err == sql.ErrNoRows {
+		return nil, apperror.Conflict("duplicate_report", "you have already reported this user for this ride")
+	} else if err != nil {
+		return nil, fmt.Errorf("error creating report: %w", err)
+	}
+
+	return report, nil
+}
+
+// ListReports returns the admin review queue, oldest reports first.
+// An empty status returns reports that still need attention.
+func (s *ReportService) ListReports(ctx context.Context, status string, limit, offset int) ([]*models.AbuseReport, error) {
+	query := `
+		SELECT report_id, reporter_id, reported_user_id, ride_id, category, description,
+			status, reviewed_by, resolution_notes, reviewed_at, created_at, updated_at
+		FROM abuse_reports
+		WHERE ($1 = '' AND status IN ('pending', 'reviewing')) OR status::text = $1
+		ORDER BY created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []*models.AbuseReport{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// ReviewReport records an admin's decision on a report
+func (s *ReportService) ReviewReport(ctx context.Context, adminID, reportID uuid.UUID, req *models.ReviewReportRequest) (*models.AbuseReport, error) {
+	switch models.ReportStatus(req.Status) {
+	case models.ReportReviewing, models.ReportResolved, models.ReportDismissed:
+	default:
+		return nil, apperror.Invalid("status", "invalid_status", fmt.Sprintf("invalid report status: %s", req.Status))
+	}
+
+	var notes sql.NullString
+	if req.ResolutionNotes != "" {
+		notes.String = req.ResolutionNotes
+		notes.Valid = true
+	}
+
+	query := `
+		UPDATE abuse_reports
+		SET status = $1, reviewed_by = $2, resolution_notes = COALESCE($3, resolution_notes), reviewed_at = NOW()
+		WHERE report_id = $4
+		RETURNING report_id, reporter_id, reported_user_id, ride_id, category, description,
+			status, reviewed_by, resolution_notes, reviewed_at, created_at, updated_at
+	`
+
+	report, err := scanReport(s.dbManager.GetPrimary().QueryRowContext(ctx, query, req.Status, adminID, notes, reportID))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("report_not_found", "report not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("error reviewing report: %w", err)
+	}
+
+	return report, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReport(row rowScanner) (*models.AbuseReport, error) {
+	var report models.AbuseReport
+	var reviewedBy uuid.NullUUID
+
+	err := row.Scan(
+		&report.ID,
+		&report.ReporterID,
+		&report.ReportedUserID,
+		&report.RideID,
+		&report.Category,
+		&report.Description,
+		&report.Status,
+		&reviewedBy,
+		&report.ResolutionNotes,
+		&report.ReviewedAt,
+		&report.CreatedAt,
+		&report.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if reviewedBy.Valid {
+		report.ReviewedBy = &reviewedBy.UUID
+	}
+
+	return &report, nil
+}
+
+func isValidReportCategory(category string) bool {
+	for _, c := range models.ReportCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
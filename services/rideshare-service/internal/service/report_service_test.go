@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestCreateReport(t *testing.T) {
+	reporter, reported, ride := uuid.New(), uuid.New(), uuid.New()
+	reportColumns := []string{
+		"report_id", "reporter_id", "reported_user_id", "ride_id", "category", "description",
+		"status", "reviewed_by", "resolution_notes", "reviewed_at", "created_at", "updated_at",
+	}
+	participation := func(rideExists, reporterInvolved, reportedInvolved bool) func(sqlmock.Sqlmock) {
+		return func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery(`status = 'accepted'`).WithArgs(ride, reporter, reported).
+				WillReturnRows(sqlmock.NewRows([]string{"ride", "reporter", "reported"}).
+					AddRow(rideExists, reporterInvolved, reportedInvolved))
+		}
+	}
+
+	tests := []struct {
+		name        string
+		reported    uuid.UUID
+		description string
+		expect      func(mock sqlmock.Sqlmock)
+		wantCode    string
+	}{
+		{
+			name:     "files a report between participants",
+			reported: reported,
+			expect: func(mock sqlmock.Sqlmock) {
+				participation(true, true, true)(mock)
+				now := time.Now()
+				mock.ExpectQuery("INSERT INTO abuse_reports").
+					WithArgs(reporter, reported, ride, "harassment", "Rude all the way").
+					WillReturnRows(sqlmock.NewRows(reportColumns).AddRow(
+						uuid.New(), reporter, reported, ride, "harassment", "Rude all the way",
+						"pending", nil, nil, nil, now, now))
+			},
+		},
+		{
+			name:     "rejects reporting yourself",
+			reported: reporter,
+			expect:   func(sqlmock.Sqlmock) {},
+			wantCode: "self_report",
+		},
+		{
+			name:        "rejects a description that is too long",
+			reported:    reported,
+			description: strings.Repeat("x", maxReportDescriptionLength+1),
+			expect:      func(sqlmock.Sqlmock) {},
+			wantCode:    "too_long",
+		},
+		{
+			name:     "rejects an unknown ride",
+			reported: reported,
+			expect:   participation(false, false, false),
+			wantCode: "ride_not_found",
+		},
+		{
+			// A rider whose request was rejected or cancelled is not involved
+			name:     "rejects a user who did not ride",
+			reported: reported,
+			expect:   participation(true, true, false),
+			wantCode: "not_ride_participant",
+		},
+		{
+			name:     "rejects a reporter who did not ride",
+			reported: reported,
+			expect:   participation(true, false, true),
+			wantCode: "not_ride_participant",
+		},
+		{
+			name:     "rejects a duplicate report",
+			reported: reported,
+			expect: func(mock sqlmock.Sqlmock) {
+				participation(true, true, true)(mock)
+				mock.ExpectQuery("ON CONFLICT").WillReturnError(sql.ErrNoRows)
+			},
+			wantCode: "duplicate_report",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, mock := newMockDB(t)
+			tt.expect(mock)
+
+			description := tt.description
+			if description == "" {
+				description = "  Rude all the way "
+			}
+			report, err := NewReportService(manager).CreateReport(context.Background(), reporter, &models.CreateReportRequest{
+				ReportedUserID: tt.reported,
+				RideID:         ride,
+				Category:       "harassment",
+				Description:    description,
+			})
+			wantCode(t, err, tt.wantCode)
+			if tt.wantCode == "" && report.Status != string(models.ReportPending) {
+				t.Errorf("status = %q, want %q", report.Status, models.ReportPending)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -3,54 +3,148 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/audit"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/community"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/fare"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/geocode"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/metrics"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/outbox"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/route"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/timezone"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"platform/shared/apperror"
+	"platform/shared/logging"
 )
 
+// maxPlaceMismatchMeters is how far coordinates sent with a place ID may be
+// from the place
+const maxPlaceMismatchMeters = 1000
+
 type RideService struct {
-	dbManager *db.DBManager
+	dbManager        *db.DBManager
+	blockService     *BlockService
+	communityService *CommunityService
+	impactService    *ImpactService
+	documentService  *DocumentService
+	geocoder         geocode.Geocoder
+	fares            fare.Model
 }
 
-func NewRideService(dbManager *db.DBManager) *RideService {
-	return &RideService{dbManager: dbManager}
+func NewRideService(dbManager *db.DBManager, blockService *BlockService, communityService *CommunityService, impactService *ImpactService, documentService *DocumentService, geocoder geocode.Geocoder, fares fare.Model) *RideService {
+	return &RideService{
+		dbManager:        dbManager,
+		blockService:     blockService,
+		communityService: communityService,
+		impactService:    impactService,
+		documentService:  documentService,
+		geocoder:         geocoder,
+		fares:            fares,
+	}
 }
 
 // CreateRide inserts a new ride into the database
 func (s *RideService) CreateRide(ctx context.Context, hostID uuid.UUID, req *models.CreateRideRequest) (*models.Ride, error) {
+	// Local times are read in the ride's zone, which defaults to the host's
+	zoneName := req.TimeZone
+	if zoneName == "" {
+		var err error
+		if zoneName, err = s.userTimeZone(ctx, hostID); err != nil {
+			return nil, err
+		}
+	}
+	loc, err := timezone.Load(zoneName)
+	if err != nil {
+		return nil, apperror.Invalid("timeZone", "unknown_time_zone", "time zone must be an IANA zone such as America/New_York")
+	}
+
 	// Parse time strings
-	departureTime, err := time.Parse(time.RFC3339, req.DepartureTime)
+	departureTime, err := timezone.ParseLocal(req.DepartureTime, loc)
 	if err != nil {
-		return nil, fmt.Errorf("invalid departure time format: %w", err)
+		return nil, apperror.Invalid("departureTime", "invalid_format", err.Error())
 	}
 
-	estimatedArrivalTime, err := time.Parse(time.RFC3339, req.EstimatedArrivalTime)
+	estimatedArrivalTime, err := timezone.ParseLocal(req.EstimatedArrivalTime, loc)
 	if err != nil {
-		return nil, fmt.Errorf("invalid estimated arrival time format: %w", err)
+		return nil, apperror.Invalid("estimatedArrivalTime", "invalid_format", err.Error())
 	}
 
 	// Validate that departure time is in the future
 	if departureTime.Before(time.Now()) {
-		return nil, errors.New("departure time must be in the future")
+		return nil, apperror.Invalid("departureTime", "in_past", "departure time must be in the future")
 	}
 
 	// Validate that estimated arrival is after departure
 	if estimatedArrivalTime.Before(departureTime) {
-		return nil, errors.New("estimated arrival time must be after departure time")
+		return nil, apperror.Invalid("estimatedArrivalTime", "before_departure", "estimated arrival time must be after departure time")
+	}
+
+	// Capacity and field formats are checked by the request's validate tags
+
+	// Campus policy: only hosts with a checked license, registration and
+	// insurance may offer rides
+	if err := s.documentService.CheckHostingEligibility(ctx, hostID, req.VehicleID); err != nil {
+		return nil, err
+	}
+
+	// Rides default to the host's own matching preferences, and can only be
+	// restricted to attributes the host has verified
+	hostProfile, err := s.communityService.GetProfile(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	audience := hostProfile.Preferences
+	if req.Audience != nil {
+		audience = *req.Audience
+	}
+	if missing := community.Unsupported(audience, hostProfile); len(missing) > 0 {
+		fields := make([]apperror.FieldError, len(missing))
+		for i, name := range missing {
+			fields[i] = apperror.FieldError{Field: "audience." + name, Code: "unverified_attribute", Message: "requires a verified community attribute"}
+		}
+		return nil, apperror.Validation("unverified_audience", "rides can only be restricted to verified community attributes", fields...)
 	}
 
-	// Validate capacity
-	if req.MaxPassengers <= 0 {
-		return nil, errors.New("maximum passengers must be greater than zero")
+	origin, err := s.resolveRideEnd(ctx, "origin", req.OriginPlaceID, req.OriginAddress, req.OriginLatitude, req.OriginLongitude)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := s.resolveRideEnd(ctx, "destination", req.DestinationPlaceID, req.DestinationAddress, req.DestinationLatitude, req.DestinationLongitude)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.AvailableSeats > req.MaxPassengers {
-		return nil, errors.New("available seats cannot exceed maximum passengers")
+	// Waypoints are planned in order between departure and arrival
+	stops := []models.RideStop{{Address: origin.address, Latitude: origin.latitude, Longitude: origin.longitude, PlannedTime: departureTime}}
+	for i, waypoint := range req.Waypoints {
+		field := fmt.Sprintf("waypoints[%d].", i)
+		end, err := s.resolveRideEnd(ctx, field, waypoint.PlaceID, waypoint.Address, waypoint.Latitude, waypoint.Longitude)
+		if err != nil {
+			return nil, err
+		}
+		plannedTime, err := timezone.ParseLocal(waypoint.PlannedTime, loc)
+		if err != nil {
+			return nil, apperror.Invalid(field+"plannedTime", "invalid_format", err.Error())
+		}
+		if !plannedTime.After(stops[i].PlannedTime) || !plannedTime.Before(estimatedArrivalTime) {
+			return nil, apperror.Invalid(field+"plannedTime", "out_of_order", "waypoints must be planned in order between departure and arrival")
+		}
+		stops = append(stops, models.RideStop{Stop: i + 1, Address: end.address, Latitude: end.latitude, Longitude: end.longitude, PlannedTime: plannedTime})
+	}
+	stops = append(stops, models.RideStop{Stop: len(stops), Address: destination.address, Latitude: destination.latitude, Longitude: destination.longitude, PlannedTime: estimatedArrivalTime})
+
+	maxPrice := s.fares.MaxPricePerSeat(stopPoints(stops), req.MaxPassengers)
+	if err := s.checkPrice(req.PricePerSeat, maxPrice); err != nil {
+		return nil, err
 	}
 
 	// Insert into database
@@ -60,15 +154,18 @@ func (s *RideService) CreateRide(ctx context.Context, hostID uuid.UUID, req *mod
 			destination_address, destination_latitude, destination_longitude, 
 			departure_time, estimated_arrival_time, max_passengers, available_seats,
 			price_per_seat, status, description, luggage_capacity, is_pets_allowed, 
-			is_smoking_allowed
+			is_smoking_allowed, time_zone, same_university, same_faculty, same_batch,
+			same_gender
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+			$20, $21, $22, $23
 		)
 		RETURNING ride_id, host_id, vehicle_id, origin_address, origin_latitude, 
 			origin_longitude, destination_address, destination_latitude, destination_longitude,
-			departure_time, estimated_arrival_time, max_passengers, available_seats, 
+			departure_time, estimated_arrival_time, time_zone, max_passengers, available_seats, 
 			price_per_seat, status, description, luggage_capacity, is_pets_allowed, 
-			is_smoking_allowed, created_at, updated_at
+			is_smoking_allowed, same_university, same_faculty, same_batch, same_gender,
+			created_at, updated_at
 	`
 
 	var ride models.Ride
@@ -84,66 +181,191 @@ func (s *RideService) CreateRide(ctx context.Context, hostID uuid.UUID, req *mod
 		luggageCapacity.Valid = true
 	}
 
-	err = s.dbManager.GetPrimary().QueryRowContext(
-		ctx,
-		query,
-		hostID,                      // $1
-		req.VehicleID,               // $2
-		req.OriginAddress,           // $3
-		req.OriginLatitude,          // $4
-		req.OriginLongitude,         // $5
-		req.DestinationAddress,      // $6
-		req.DestinationLatitude,     // $7
-		req.DestinationLongitude,    // $8
-		departureTime,               // $9
-		estimatedArrivalTime,        // $10
-		req.MaxPassengers,           // $11
-		req.AvailableSeats,          // $12
-		req.PricePerSeat,            // $13
-		"scheduled",                 // $14
-		description,                 // $15
-		luggageCapacity,             // $16
-		req.IsPetsAllowed,           // $17
-		req.IsSmokingAllowed,        // $18
-	).Scan(
-		&ride.ID,
-		&ride.HostID,
-		&ride.VehicleID,
-		&ride.OriginAddress,
-		&ride.OriginLatitude,
-		&ride.OriginLongitude,
-		&ride.DestinationAddress,
-		&ride.DestinationLatitude,
-		&ride.DestinationLongitude,
-		&ride.DepartureTime,
-		&ride.EstimatedArrivalTime,
-		&ride.MaxPassengers,
-		&ride.AvailableSeats,
-		&ride.PricePerSeat,
-		&ride.Status,
-		&ride.Description,
-		&ride.LuggageCapacity,
-		&ride.IsPetsAllowed,
-		&ride.IsSmokingAllowed,
-		&ride.CreatedAt,
-		&ride.UpdatedAt,
-	)
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			hostID,                      // $1
+			req.VehicleID,               // $2
+			origin.address,              // $3
+			origin.latitude,             // $4
+			origin.longitude,            // $5
+			destination.address,         // $6
+			destination.latitude,        // $7
+			destination.longitude,       // $8
+			departureTime,               // $9
+			estimatedArrivalTime,        // $10
+			req.MaxPassengers,           // $11
+			req.AvailableSeats,          // $12
+			req.PricePerSeat,            // $13
+			"scheduled",                 // $14
+			description,                 // $15
+			luggageCapacity,             // $16
+			req.IsPetsAllowed,           // $17
+			req.IsSmokingAllowed,        // $18
+			zoneName,                    // $19
+			audience.SameUniversity,     // $20
+			audience.SameFaculty,        // $21
+			audience.SameBatch,          // $22
+			audience.SameGender,         // $23
+		).Scan(
+			&ride.ID,
+			&ride.HostID,
+			&ride.VehicleID,
+			&ride.OriginAddress,
+			&ride.OriginLatitude,
+			&ride.OriginLongitude,
+			&ride.DestinationAddress,
+			&ride.DestinationLatitude,
+			&ride.DestinationLongitude,
+			&ride.DepartureTime,
+			&ride.EstimatedArrivalTime,
+			&ride.TimeZone,
+			&ride.MaxPassengers,
+			&ride.AvailableSeats,
+			&ride.PricePerSeat,
+			&ride.Status,
+			&ride.Description,
+			&ride.LuggageCapacity,
+			&ride.IsPetsAllowed,
+			&ride.IsSmokingAllowed,
+			&ride.Audience.SameUniversity,
+			&ride.Audience.SameFaculty,
+			&ride.Audience.SameBatch,
+			&ride.Audience.SameGender,
+			&ride.CreatedAt,
+			&ride.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := insertStops(ctx, tx, &ride, stops); err != nil {
+			return err
+		}
+		if err := appendEvent(ctx, tx, events.RideCreated, events.AggregateRide, ride.ID, &hostID, rideEventData(&ride, nil)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityRide,
+			EntityID:   ride.ID,
+			OwnerID:    hostID,
+			ActorID:    &hostID,
+			After:      &ride,
+		})
+	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ride: %w", err)
 	}
+	metrics.RideCreated()
 
+	localizeRide(&ride)
 	return &ride, nil
 }
 
+// checkPrice rejects prices above the cost of the trip, as rides are shared
+// at cost rather than for profit
+func (s *RideService) checkPrice(pricePerSeat, maxPrice float64) error {
+	if pricePerSeat > maxPrice {
+		return apperror.Invalid("pricePerSeat", "above_cost",
+			fmt.Sprintf("price per seat cannot exceed %.2f %s, the cost of the trip shared by a full car", maxPrice, s.fares.Currency))
+	}
+	return nil
+}
+
+// rideEnd is the resolved origin or destination of a ride
+type rideEnd struct {
+	address   string
+	latitude  float64
+	longitude float64
+}
+
+// resolveRideEnd fills in whatever the client left out of one end of a ride,
+// or of a waypoint. A place ID supplies the address and coordinates, and
+// coordinates sent with it must be near the place. Otherwise an address is
+// required, and is geocoded when no coordinates are sent. field is "origin",
+// "destination" or a waypoint such as "waypoints[0]."
+func (s *RideService) resolveRideEnd(ctx context.Context, field, placeID, address string, lat, lon *float64) (*rideEnd, error) {
+	name := strings.TrimSuffix(field, ".")
+	if (lat == nil) != (lon == nil) {
+		return nil, apperror.Invalid(endField(field, "Latitude"), "incomplete_coordinates", "latitude and longitude must be sent together")
+	}
+
+	var place *models.Place
+	switch {
+	case placeID != "":
+		if s.geocoder == nil {
+			return nil, apperror.Invalid(endField(field, "PlaceId"), "geocoding_unavailable", "place IDs cannot be resolved")
+		}
+		var err error
+		place, err = s.geocoder.Lookup(ctx, placeID)
+		if errors.Is(err, models.ErrPlaceNotFound) {
+			return nil, apperror.Invalid(endField(field, "PlaceId"), "unknown_place", "no place has this ID")
+		} else if err != nil {
+			return nil, fmt.Errorf("error looking up %s place: %w", name, err)
+		}
+		if lat != nil && geocode.DistanceMeters(*lat, *lon, place.Latitude, place.Longitude) > maxPlaceMismatchMeters {
+			return nil, apperror.Invalid(endField(field, "PlaceId"), "location_mismatch", "coordinates are too far from the place")
+		}
+	case address == "":
+		return nil, apperror.Invalid(endField(field, "Address"), "required", "an address or place ID is required")
+	case lat == nil:
+		if s.geocoder == nil {
+			return nil, apperror.Invalid(endField(field, "Address"), "geocoding_unavailable", "coordinates are required")
+		}
+		places, err := s.geocoder.Forward(ctx, address, 1)
+		if err != nil {
+			return nil, fmt.Errorf("error geocoding %s: %w", name, err)
+		}
+		if len(places) == 0 {
+			return nil, apperror.Invalid(endField(field, "Address"), "address_not_found", "address could not be located; send coordinates or a place ID")
+		}
+		place = &places[0]
+	}
+
+	end := &rideEnd{address: address}
+	if place != nil {
+		end.latitude, end.longitude = place.Latitude, place.Longitude
+		if end.address == "" {
+			end.address = place.Name + ", " + place.Address
+		}
+	}
+	if lat != nil {
+		end.latitude, end.longitude = *lat, *lon
+	}
+	return end, nil
+}
+
+// endField names a field of one end of a ride, e.g. originAddress, or
+// waypoints[0].address for a waypoint
+func endField(field, name string) string {
+	if strings.HasSuffix(field, ".") {
+		return field + strings.ToLower(name[:1]) + name[1:]
+	}
+	return field + name
+}
+
 // GetRide fetches a ride by ID
 func (s *RideService) GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ride, error) {
+	return s.getRide(ctx, s.dbManager.GetReplica(), rideID)
+}
+
+// GetRideFromPrimary fetches a ride from the primary, for event handlers
+// that run right after a write replicas may not have seen yet
+func (s *RideService) GetRideFromPrimary(ctx context.Context, rideID uuid.UUID) (*models.Ride, error) {
+	return s.getRide(ctx, s.dbManager.GetPrimary(), rideID)
+}
+
+func (s *RideService) getRide(ctx context.Context, conn *sql.DB, rideID uuid.UUID) (*models.Ride, error) {
 	query := `
 		SELECT ride_id, host_id, vehicle_id, origin_address, origin_latitude, 
 			origin_longitude, destination_address, destination_latitude, destination_longitude,
-			departure_time, estimated_arrival_time, max_passengers, available_seats, 
+			departure_time, estimated_arrival_time, time_zone, max_passengers, available_seats, 
 			price_per_seat, status, description, luggage_capacity, is_pets_allowed, 
-			is_smoking_allowed, created_at, updated_at
+			is_smoking_allowed, same_university, same_faculty, same_batch, same_gender,
+			created_at, updated_at
 		FROM rides
 		WHERE ride_id = $1
 	`
@@ -151,7 +373,7 @@ func (s *RideService) GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ri
 	var ride models.Ride
 	var description, luggageCapacity sql.NullString
 
-	err := s.dbManager.GetReplica().QueryRowContext(ctx, query, rideID).Scan(
+	err := conn.QueryRowContext(ctx, query, rideID).Scan(
 		&ride.ID,
 		&ride.HostID,
 		&ride.VehicleID,
@@ -163,6 +385,7 @@ func (s *RideService) GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ri
 		&ride.DestinationLongitude,
 		&ride.DepartureTime,
 		&ride.EstimatedArrivalTime,
+		&ride.TimeZone,
 		&ride.MaxPassengers,
 		&ride.AvailableSeats,
 		&ride.PricePerSeat,
@@ -171,12 +394,16 @@ func (s *RideService) GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ri
 		&luggageCapacity,
 		&ride.IsPetsAllowed,
 		&ride.IsSmokingAllowed,
+		&ride.Audience.SameUniversity,
+		&ride.Audience.SameFaculty,
+		&ride.Audience.SameBatch,
+		&ride.Audience.SameGender,
 		&ride.CreatedAt,
 		&ride.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("ride not found")
+		return nil, models.ErrRideNotFound
 	} else if err != nil {
 		return nil, fmt.Errorf("error fetching ride: %w", err)
 	}
@@ -192,14 +419,40 @@ func (s *RideService) GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ri
 		ride.LuggageCapacity = &capacityStr
 	}
 
+	if err := loadStops(ctx, conn, &ride); err != nil {
+		return nil, err
+	}
+	localizeRide(&ride)
 	return &ride, nil
 }
 
-// FindNearbyRides uses the database function to find rides near a location
-func (s *RideService) FindNearbyRides(ctx context.Context, lat, lon, destLat, destLon, radiusMeters float64, departureAfter time.Time) ([]*models.Ride, error) {
+// FindNearbyRides uses the database function to find rides near a location.
+// Any of a ride's stops may match, and each ride found says which stops to
+// board and leave at. When viewerID is set, rides hosted by users who blocked the viewer (or whom
+// the viewer blocked) are left out. A window limits results to rides leaving
+// on a day in the window's zone, or else the viewer's.
+func (s *RideService) FindNearbyRides(ctx context.Context, viewerID uuid.UUID, lat, lon, destLat, destLon, radiusMeters float64, departureAfter time.Time, window *models.DepartureWindow) ([]*models.Ride, error) {
+	var departureBefore sql.NullTime
+	if window != nil {
+		from, to, err := s.departureWindow(ctx, viewerID, window)
+		if err != nil {
+			return nil, err
+		}
+		if from.After(departureAfter) {
+			departureAfter = from
+		}
+		departureBefore = sql.NullTime{Time: to, Valid: true}
+	}
+
 	// Use the PostgreSQL function we defined in the schema
 	query := `
-		SELECT * FROM find_nearby_rides($1, $2, $3, $4, $5, $6)
+		SELECT n.* FROM find_nearby_rides($1, $2, $3, $4, $5, $6) n
+		WHERE ($7::uuid IS NULL OR NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = $7 AND b.blocked_id = n.host_id)
+			   OR (b.blocker_id = n.host_id AND b.blocked_id = $7)
+		))
+			AND ($8::timestamptz IS NULL OR n.departure_time < $8)
 	`
 
 	rows, err := s.dbManager.GetReplica().QueryContext(
@@ -211,6 +464,8 @@ func (s *RideService) FindNearbyRides(ctx context.Context, lat, lon, destLat, de
 		destLon,
 		radiusMeters,
 		departureAfter,
+		uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil},
+		departureBefore,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error finding nearby rides: %w", err)
@@ -227,6 +482,8 @@ func (s *RideService) FindNearbyRides(ctx context.Context, lat, lon, destLat, de
 		AvailableSeats       int
 		DistanceFromOrigin   float64
 		DistanceFromDestination float64
+		PickupStop           int
+		DropoffStop          int
 	}
 
 	var resultIDs []uuid.UUID
@@ -243,6 +500,8 @@ func (s *RideService) FindNearbyRides(ctx context.Context, lat, lon, destLat, de
 			&result.AvailableSeats,
 			&result.DistanceFromOrigin,
 			&result.DistanceFromDestination,
+			&result.PickupStop,
+			&result.DropoffStop,
 		); err != nil {
 			return nil, fmt.Errorf("error scanning nearby ride: %w", err)
 		}
@@ -260,34 +519,103 @@ func (s *RideService) FindNearbyRides(ctx context.Context, lat, lon, destLat, de
 		return []*models.Ride{}, nil
 	}
 
-	// Fetch full ride details for all matched rides
+	// Fetch full ride details for all matched rides, keeping them closest first
 	rides, err := s.GetRidesByIDs(ctx, resultIDs)
 	if err != nil {
 		return nil, err
 	}
+	rides = orderByIDs(rides, resultIDs)
+	for _, ride := range rides {
+		match := nearbyResults[ride.ID]
+		ride.PickupStop, ride.DropoffStop = &match.PickupStop, &match.DropoffStop
+	}
 
-	return rides, nil
+	return s.filterByAudience(ctx, viewerID, rides)
+}
+
+// orderByIDs puts rides in the order of ids. Rides missing from ids are
+// dropped, as are ids without a ride.
+func orderByIDs(rides []*models.Ride, ids []uuid.UUID) []*models.Ride {
+	byID := make(map[uuid.UUID]*models.Ride, len(rides))
+	for _, ride := range rides {
+		byID[ride.ID] = ride
+	}
+
+	ordered := make([]*models.Ride, 0, len(rides))
+	for _, id := range ids {
+		if ride, ok := byID[id]; ok {
+			ordered = append(ordered, ride)
+		}
+	}
+	return ordered
+}
+
+// filterByAudience drops the rides the viewer may not join, because of the
+// ride's audience or the viewer's own matching preferences
+func (s *RideService) filterByAudience(ctx context.Context, viewerID uuid.UUID, rides []*models.Ride) ([]*models.Ride, error) {
+	userIDs := make([]uuid.UUID, 0, len(rides)+1)
+	for _, ride := range rides {
+		userIDs = append(userIDs, ride.HostID)
+	}
+	if viewerID != uuid.Nil {
+		userIDs = append(userIDs, viewerID)
+	}
+	profiles, err := s.communityService.GetProfiles(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Anonymous viewers have no profile and only see open rides
+	viewer := profiles[viewerID]
+	visible := rides[:0]
+	for _, ride := range rides {
+		if community.Eligible(ride.Audience, profiles[ride.HostID], viewer) {
+			visible = append(visible, ride)
+		}
+	}
+	return visible, nil
+}
+
+// departureWindow resolves a window to a time range. Days follow the wall
+// clock of the window's zone, the viewer's zone, or UTC for anonymous viewers.
+func (s *RideService) departureWindow(ctx context.Context, viewerID uuid.UUID, window *models.DepartureWindow) (time.Time, time.Time, error) {
+	zoneName := window.TimeZone
+	if zoneName == "" && viewerID != uuid.Nil {
+		var err error
+		if zoneName, err = s.userTimeZone(ctx, viewerID); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	loc, err := timezone.Load(zoneName)
+	if err != nil {
+		return time.Time{}, time.Time{}, apperror.Invalid("timeZone", "unknown_time_zone", "time zone must be an IANA zone such as America/New_York")
+	}
+
+	day := window.Day
+	if day == "" {
+		day = "today"
+	}
+	from, to, err := timezone.DayRange(time.Now(), loc, day, window.PartOfDay)
+	if err != nil {
+		return time.Time{}, time.Time{}, apperror.Invalid("day", "invalid_window", err.Error())
+	}
+	return from, to, nil
 }
 
-// GetRidesByIDs fetches multiple rides by their IDs
+// GetRidesByIDs fetches multiple rides by their IDs, in no particular order
 func (s *RideService) GetRidesByIDs(ctx context.Context, rideIDs []uuid.UUID) ([]*models.Ride, error) {
 	// Build a query with multiple UUID parameters
 	query := `
 		SELECT ride_id, host_id, vehicle_id, origin_address, origin_latitude, 
 			origin_longitude, destination_address, destination_latitude, destination_longitude,
-			departure_time, estimated_arrival_time, max_passengers, available_seats, 
+			departure_time, estimated_arrival_time, time_zone, max_passengers, available_seats, 
 			price_per_seat, status, description, luggage_capacity, is_pets_allowed, 
-			is_smoking_allowed, created_at, updated_at
+			is_smoking_allowed, same_university, same_faculty, same_batch, same_gender,
+			created_at, updated_at
 		FROM rides
 		WHERE ride_id = ANY($1)
 	`
 
-	// Convert []uuid.UUID to []interface{} for the query
-	idArgs := make([]interface{}, len(rideIDs))
-	for i, id := range rideIDs {
-		idArgs[i] = id
-	}
-
 	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, pq.Array(rideIDs))
 	if err != nil {
 		return nil, fmt.Errorf("error fetching rides by IDs: %w", err)
@@ -311,6 +639,7 @@ func (s *RideService) GetRidesByIDs(ctx context.Context, rideIDs []uuid.UUID) ([
 			&ride.DestinationLongitude,
 			&ride.DepartureTime,
 			&ride.EstimatedArrivalTime,
+			&ride.TimeZone,
 			&ride.MaxPassengers,
 			&ride.AvailableSeats,
 			&ride.PricePerSeat,
@@ -319,6 +648,10 @@ func (s *RideService) GetRidesByIDs(ctx context.Context, rideIDs []uuid.UUID) ([
 			&luggageCapacity,
 			&ride.IsPetsAllowed,
 			&ride.IsSmokingAllowed,
+			&ride.Audience.SameUniversity,
+			&ride.Audience.SameFaculty,
+			&ride.Audience.SameBatch,
+			&ride.Audience.SameGender,
 			&ride.CreatedAt,
 			&ride.UpdatedAt,
 		); err != nil {
@@ -343,9 +676,113 @@ func (s *RideService) GetRidesByIDs(ctx context.Context, rideIDs []uuid.UUID) ([
 		return nil, fmt.Errorf("error iterating through rides: %w", err)
 	}
 
+	if err := loadStops(ctx, s.dbManager.GetReplica(), rides...); err != nil {
+		return nil, err
+	}
+	for _, ride := range rides {
+		localizeRide(ride)
+	}
 	return rides, nil
 }
 
+// insertStops writes every stop of a new ride, each leg offering the ride's
+// available seats, and fills in the ride's waypoints and leg seats
+func insertStops(ctx context.Context, tx *sql.Tx, ride *models.Ride, stops []models.RideStop) error {
+	ride.LegSeats = make([]int, 0, len(stops)-1)
+	for i, stop := range stops {
+		// The destination starts no leg
+		var seats sql.NullInt64
+		if i < len(stops)-1 {
+			seats = sql.NullInt64{Int64: int64(ride.AvailableSeats), Valid: true}
+			ride.LegSeats = append(ride.LegSeats, ride.AvailableSeats)
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO ride_stops (ride_id, stop_index, address, latitude, longitude, planned_time, available_seats)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			ride.ID, i, stop.Address, stop.Latitude, stop.Longitude, stop.PlannedTime, seats)
+		if err != nil {
+			return fmt.Errorf("error adding stop %d: %w", i, err)
+		}
+	}
+	ride.Waypoints = stops[1 : len(stops)-1]
+	return nil
+}
+
+// loadStops fills in the waypoints and leg seats of rides
+func loadStops(ctx context.Context, conn *sql.DB, rides ...*models.Ride) error {
+	if len(rides) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(rides))
+	for i, ride := range rides {
+		ids[i] = ride.ID
+	}
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT ride_id, stop_index, address, latitude, longitude, planned_time, available_seats
+		FROM ride_stops
+		WHERE ride_id = ANY($1)
+		ORDER BY ride_id, stop_index`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error fetching ride stops: %w", err)
+	}
+	defer rows.Close()
+
+	stops := make(map[uuid.UUID][]models.RideStop)
+	seats := make(map[uuid.UUID][]int)
+	for rows.Next() {
+		var rideID uuid.UUID
+		var stop models.RideStop
+		var legSeats sql.NullInt64
+		if err := rows.Scan(&rideID, &stop.Stop, &stop.Address, &stop.Latitude, &stop.Longitude, &stop.PlannedTime, &legSeats); err != nil {
+			return fmt.Errorf("error scanning ride stop: %w", err)
+		}
+		stops[rideID] = append(stops[rideID], stop)
+		if legSeats.Valid {
+			seats[rideID] = append(seats[rideID], int(legSeats.Int64))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating through ride stops: %w", err)
+	}
+
+	for _, ride := range rides {
+		if all := stops[ride.ID]; len(all) > 2 {
+			ride.Waypoints = all[1 : len(all)-1]
+		}
+		ride.LegSeats = seats[ride.ID]
+	}
+	return nil
+}
+
+// rideStops lists every stop of a ride, origin and destination included
+func rideStops(ride *models.Ride) []models.RideStop {
+	stops := make([]models.RideStop, 0, len(ride.Waypoints)+2)
+	stops = append(stops, models.RideStop{
+		Address:     ride.OriginAddress,
+		Latitude:    ride.OriginLatitude,
+		Longitude:   ride.OriginLongitude,
+		PlannedTime: ride.DepartureTime,
+	})
+	stops = append(stops, ride.Waypoints...)
+	return append(stops, models.RideStop{
+		Stop:        len(ride.Waypoints) + 1,
+		Address:     ride.DestinationAddress,
+		Latitude:    ride.DestinationLatitude,
+		Longitude:   ride.DestinationLongitude,
+		PlannedTime: ride.EstimatedArrivalTime,
+	})
+}
+
+// stopPoints returns where each stop is
+func stopPoints(stops []models.RideStop) []route.Point {
+	points := make([]route.Point, len(stops))
+	for i, stop := range stops {
+		points[i] = route.Point{Lat: stop.Latitude, Lon: stop.Longitude}
+	}
+	return points
+}
+
 // CancelRide cancels a ride (changes status to cancelled)
 func (s *RideService) CancelRide(ctx context.Context, userID, rideID uuid.UUID) error {
 	// First check if the ride exists and belongs to the user
@@ -355,11 +792,11 @@ func (s *RideService) CancelRide(ctx context.Context, userID, rideID uuid.UUID)
 	}
 
 	if ride.HostID != userID {
-		return errors.New("unauthorized: only the host can cancel this ride")
+		return apperror.Forbidden("not_ride_host", "only the host can cancel this ride")
 	}
 
 	if ride.Status != "scheduled" {
-		return fmt.Errorf("ride cannot be cancelled from status: %s", ride.Status)
+		return apperror.Conflict("invalid_ride_status", fmt.Sprintf("ride cannot be cancelled from status: %s", ride.Status))
 	}
 
 	// Update the ride status
@@ -369,12 +806,771 @@ func (s *RideService) CancelRide(ctx context.Context, userID, rideID uuid.UUID)
 		WHERE ride_id = $1
 	`
 
-	_, err = s.dbManager.GetPrimary().ExecContext(ctx, query, rideID)
+	// Passengers and pending requesters are notified from the event.
+	// Note: In a real system, we would also handle refunds, etc.
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, rideID); err != nil {
+			return err
+		}
+
+		before := *ride
+		ride.Status = string(models.StatusCancelled)
+		if err := appendEvent(ctx, tx, events.RideCancelled, events.AggregateRide, rideID, &userID, rideEventData(ride, nil)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCancel,
+			EntityType: audit.EntityRide,
+			EntityID:   rideID,
+			OwnerID:    ride.HostID,
+			ActorID:    &userID,
+			Before:     &before,
+			After:      ride,
+		})
+	})
 	if err != nil {
 		return fmt.Errorf("error cancelling ride: %w", err)
 	}
 
-	// Note: In a real system, we would also notify passengers, handle refunds, etc.
-
 	return nil
-}
\ No newline at end of file
+}
+
+// CompleteRide marks a ride that has departed as completed and records the
+// impact of sharing it
+func (s *RideService) CompleteRide(ctx context.Context, userID, rideID uuid.UUID) (*models.Ride, error) {
+	ride, err := s.GetRide(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ride.HostID != userID {
+		return nil, apperror.Forbidden("not_ride_host", "only the host can complete this ride")
+	}
+
+	if ride.Status != string(models.StatusScheduled) && ride.Status != string(models.StatusInProgress) {
+		return nil, apperror.Conflict("invalid_ride_status", fmt.Sprintf("ride cannot be completed from status: %s", ride.Status))
+	}
+	now := time.Now()
+	if ride.DepartureTime.After(now) {
+		return nil, apperror.Conflict("ride_not_departed", "ride cannot be completed before it departs")
+	}
+
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		before := *ride
+		err := tx.QueryRowContext(ctx,
+			"UPDATE rides SET status = 'completed', updated_at = NOW() WHERE ride_id = $1 RETURNING status, updated_at",
+			rideID).Scan(&ride.Status, &ride.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		if err := s.impactService.RecordRide(ctx, tx, ride, now); err != nil {
+			return err
+		}
+		if err := appendEvent(ctx, tx, events.RideCompleted, events.AggregateRide, rideID, &userID, rideEventData(ride, nil)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionComplete,
+			EntityType: audit.EntityRide,
+			EntityID:   rideID,
+			OwnerID:    ride.HostID,
+			ActorID:    &userID,
+			Before:     &before,
+			After:      ride,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error completing ride: %w", err)
+	}
+
+	return ride, nil
+}
+
+// UpdateRide applies a host's changes to a scheduled ride and lets confirmed
+// passengers know what changed
+func (s *RideService) UpdateRide(ctx context.Context, userID, rideID uuid.UUID, req *models.UpdateRideRequest) (*models.Ride, error) {
+	ride, err := s.GetRide(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ride.HostID != userID {
+		return nil, apperror.Forbidden("not_ride_host", "only the host can update this ride")
+	}
+
+	if ride.Status != string(models.StatusScheduled) {
+		return nil, apperror.Conflict("invalid_ride_status", fmt.Sprintf("ride cannot be updated from status: %s", ride.Status))
+	}
+
+	// Local times keep meaning the ride's wall clock
+	loc, err := timezone.Load(ride.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	departureTime := ride.DepartureTime
+	if req.DepartureTime != nil {
+		departureTime, err = timezone.ParseLocal(*req.DepartureTime, loc)
+		if err != nil {
+			return nil, apperror.Invalid("departureTime", "invalid_format", err.Error())
+		}
+		if departureTime.Before(time.Now()) {
+			return nil, apperror.Invalid("departureTime", "in_past", "departure time must be in the future")
+		}
+	}
+
+	estimatedArrivalTime := ride.EstimatedArrivalTime
+	if req.EstimatedArrivalTime != nil {
+		estimatedArrivalTime, err = timezone.ParseLocal(*req.EstimatedArrivalTime, loc)
+		if err != nil {
+			return nil, apperror.Invalid("estimatedArrivalTime", "invalid_format", err.Error())
+		}
+	}
+	if estimatedArrivalTime.Before(departureTime) {
+		return nil, apperror.Invalid("estimatedArrivalTime", "before_departure", "estimated arrival time must be after departure time")
+	}
+
+	// Waypoints move with the departure and must still come before arrival
+	shift := departureTime.Sub(ride.DepartureTime)
+	waypoints := make([]models.RideStop, len(ride.Waypoints))
+	for i, stop := range ride.Waypoints {
+		stop.PlannedTime = stop.PlannedTime.Add(shift)
+		waypoints[i] = stop
+	}
+	if n := len(waypoints); n > 0 && !estimatedArrivalTime.After(waypoints[n-1].PlannedTime) {
+		return nil, apperror.Invalid("estimatedArrivalTime", "before_waypoint", "estimated arrival time must be after the last waypoint")
+	}
+
+	pricePerSeat := ride.PricePerSeat
+	if req.PricePerSeat != nil {
+		if *req.PricePerSeat < 0 {
+			return nil, apperror.Invalid("pricePerSeat", "negative", "price per seat cannot be negative")
+		}
+		maxPrice := s.fares.MaxPricePerSeat(stopPoints(rideStops(ride)), ride.MaxPassengers)
+		if err := s.checkPrice(*req.PricePerSeat, maxPrice); err != nil {
+			return nil, err
+		}
+		pricePerSeat = *req.PricePerSeat
+	}
+
+	description := ride.Description
+	if req.Description != nil {
+		description = req.Description
+		if *req.Description == "" {
+			description = nil
+		}
+	}
+
+	// Moving the departure time re-arms the starting-soon reminder
+	query := `
+		UPDATE rides
+		SET departure_time = $2, estimated_arrival_time = $3, price_per_seat = $4, description = $5,
+			reminder_sent_at = CASE WHEN departure_time = $2 THEN reminder_sent_at ELSE NULL END
+		WHERE ride_id = $1
+		RETURNING updated_at
+	`
+
+	changes := rideChanges(ride, departureTime, estimatedArrivalTime, pricePerSeat, description, loc)
+
+	before := *ride
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			rideID, departureTime, estimatedArrivalTime, pricePerSeat, description,
+		).Scan(&ride.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE ride_stops
+			SET planned_time = CASE
+				WHEN stop_index = 0 THEN $2
+				WHEN stop_index = $4 THEN $3
+				ELSE planned_time + $5 * INTERVAL '1 second'
+			END
+			WHERE ride_id = $1`,
+			rideID, departureTime, estimatedArrivalTime, len(waypoints)+1, shift.Seconds())
+		if err != nil {
+			return err
+		}
+
+		ride.DepartureTime = departureTime
+		ride.EstimatedArrivalTime = estimatedArrivalTime
+		ride.Waypoints = waypoints
+		ride.PricePerSeat = pricePerSeat
+		ride.Description = description
+		localizeRide(ride)
+
+		err = audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityRide,
+			EntityID:   rideID,
+			OwnerID:    ride.HostID,
+			ActorID:    &userID,
+			Before:     &before,
+			After:      ride,
+		})
+		if err != nil || len(changes) == 0 {
+			return err
+		}
+		return appendEvent(ctx, tx, events.RideUpdated, events.AggregateRide, rideID, &userID, rideEventData(ride, changes))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating ride: %w", err)
+	}
+
+	return ride, nil
+}
+
+// rideChanges describes, for the message sent to passengers, how an update
+// changes a ride. Times are given on the ride's local clock.
+func rideChanges(ride *models.Ride, departure, arrival time.Time, pricePerSeat float64, description *string, loc *time.Location) []string {
+	const layout = "Mon 2 Jan 15:04 MST"
+
+	var changes []string
+	if !departure.Equal(ride.DepartureTime) {
+		changes = append(changes, "departure time is now "+departure.In(loc).Format(layout))
+	}
+	if !arrival.Equal(ride.EstimatedArrivalTime) {
+		changes = append(changes, "estimated arrival is now "+arrival.In(loc).Format(layout))
+	}
+	if pricePerSeat != ride.PricePerSeat {
+		changes = append(changes, fmt.Sprintf("price per seat is now %.2f", pricePerSeat))
+	}
+	current, updated := "", ""
+	if ride.Description != nil {
+		current = *ride.Description
+	}
+	if description != nil {
+		updated = *description
+	}
+	if updated != current {
+		changes = append(changes, "description changed")
+	}
+	return changes
+}
+
+// PublishStartingSoon emits a starting-soon event for every scheduled ride
+// departing within lead. Each ride is claimed with reminder_sent_at in the
+// same transaction as its event, so a ride is only announced once even with
+// several instances running.
+func (s *RideService) PublishStartingSoon(ctx context.Context, lead time.Duration) (int, error) {
+	query := `
+		UPDATE rides
+		SET reminder_sent_at = NOW()
+		WHERE status = 'scheduled'
+			AND reminder_sent_at IS NULL
+			AND departure_time > NOW()
+			AND departure_time <= NOW() + $1 * INTERVAL '1 second'
+		RETURNING ride_id, host_id, vehicle_id, status, origin_address, destination_address,
+			departure_time, available_seats, price_per_seat, time_zone
+	`
+
+	var claimed []*models.Ride
+	err := s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, lead.Seconds())
+		if err != nil {
+			return fmt.Errorf("error claiming rides for reminders: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var ride models.Ride
+			err := rows.Scan(&ride.ID, &ride.HostID, &ride.VehicleID, &ride.Status,
+				&ride.OriginAddress, &ride.DestinationAddress, &ride.DepartureTime,
+				&ride.AvailableSeats, &ride.PricePerSeat, &ride.TimeZone)
+			if err != nil {
+				return fmt.Errorf("error scanning ride: %w", err)
+			}
+			claimed = append(claimed, &ride)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating through rides: %w", err)
+		}
+
+		for _, ride := range claimed {
+			if err := appendEvent(ctx, tx, events.RideStartingSoon, events.AggregateRide, ride.ID, nil, rideEventData(ride, nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(claimed), nil
+}
+
+// RunStartingSoonReminders calls PublishStartingSoon every interval until ctx is done
+func (s *RideService) RunStartingSoonReminders(ctx context.Context, lead, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PublishStartingSoon(ctx, lead); err != nil {
+			logging.FromContext(ctx).Error("Error sending starting-soon reminders", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RequestToJoin creates a pending request from a rider to join a ride
+func (s *RideService) RequestToJoin(ctx context.Context, riderID, rideID uuid.UUID, req *models.JoinRideRequest) (*models.RideRequest, error) {
+	ride, err := s.GetRide(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ride.HostID == riderID {
+		return nil, apperror.Validation("own_ride", "hosts cannot request to join their own ride")
+	}
+
+	if ride.Status != string(models.StatusScheduled) {
+		return nil, apperror.Conflict("invalid_ride_status", fmt.Sprintf("ride cannot be joined from status: %s", ride.Status))
+	}
+
+	blocked, err := s.blockService.IsBlockedBetween(ctx, riderID, ride.HostID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		// Blocked users see the same response as for a missing ride
+		return nil, fmt.Errorf("ride hidden by block: %w", models.ErrRideNotFound)
+	}
+
+	profiles, err := s.communityService.GetProfiles(ctx, []uuid.UUID{ride.HostID, riderID})
+	if err != nil {
+		return nil, err
+	}
+	if !community.Eligible(ride.Audience, profiles[ride.HostID], profiles[riderID]) {
+		return nil, apperror.Forbidden("audience_restricted", "ride is limited to riders who share verified community attributes with the host")
+	}
+
+	// Riders board and leave at stops, by default the ones nearest their
+	// pickup and dropoff, and only need seats on the legs between them
+	stops := rideStops(ride)
+	destination := len(stops) - 1
+	var pickupStop int
+	if req.PickupStop != nil {
+		if *req.PickupStop >= destination {
+			return nil, apperror.Invalid("pickupStop", "unknown_stop", fmt.Sprintf("pickup stop must be between 0 and %d", destination-1))
+		}
+		pickupStop = *req.PickupStop
+	} else {
+		pickupStop = route.Nearest(stopPoints(stops), route.Point{Lat: req.PickupLatitude, Lon: req.PickupLongitude}, 0, destination-1)
+	}
+
+	dropoffStop := destination
+	if req.DropoffStop != nil {
+		if *req.DropoffStop <= pickupStop || *req.DropoffStop > destination {
+			return nil, apperror.Invalid("dropoffStop", "unknown_stop", fmt.Sprintf("dropoff stop must be after the pickup stop and at most %d", destination))
+		}
+		dropoffStop = *req.DropoffStop
+	} else if req.DropoffLatitude != nil && req.DropoffLongitude != nil {
+		dropoffStop = route.Nearest(stopPoints(stops), route.Point{Lat: *req.DropoffLatitude, Lon: *req.DropoffLongitude}, pickupStop+1, destination)
+	}
+
+	// A chosen stop stands in for a pickup or dropoff left out
+	if req.PickupAddress == "" {
+		stop := stops[pickupStop]
+		req.PickupAddress, req.PickupLatitude, req.PickupLongitude = stop.Address, stop.Latitude, stop.Longitude
+	}
+	if req.DropoffStop != nil && req.DropoffAddress == "" && req.DropoffLatitude == nil {
+		stop := stops[dropoffStop]
+		req.DropoffAddress, req.DropoffLatitude, req.DropoffLongitude = stop.Address, &stop.Latitude, &stop.Longitude
+	}
+
+	if req.SeatsRequested <= 0 {
+		req.SeatsRequested = 1
+	}
+	if req.SeatsRequested > route.FreeSeats(ride.LegSeats, pickupStop, dropoffStop) {
+		return nil, models.ErrNotEnoughSeats
+	}
+
+	// Only one open request per rider and ride
+	var alreadyRequested bool
+	err = s.dbManager.GetPrimary().QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM ride_requests WHERE ride_id = $1 AND rider_id = $2 AND status IN ('pending', 'accepted'))",
+		rideID, riderID).Scan(&alreadyRequested)
+	if err != nil {
+		return nil, fmt.Errorf("error checking existing requests: %w", err)
+	}
+	if alreadyRequested {
+		return nil, apperror.Conflict("already_requested", "ride already requested")
+	}
+
+	query := `
+		INSERT INTO ride_requests (
+			ride_id, rider_id, pickup_address, pickup_latitude, pickup_longitude,
+			dropoff_address, dropoff_latitude, dropoff_longitude, seats_requested, message,
+			pickup_stop, dropoff_stop
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING request_id, ride_id, rider_id, pickup_address, pickup_latitude, pickup_longitude,
+			dropoff_address, dropoff_latitude, dropoff_longitude, pickup_stop, dropoff_stop, status,
+			seats_requested, distance_added_meters, message, created_at, updated_at, fare_breakdown
+	`
+
+	var dropoffAddress, message sql.NullString
+	if req.DropoffAddress != "" {
+		dropoffAddress.String = req.DropoffAddress
+		dropoffAddress.Valid = true
+	}
+	if req.Message != "" {
+		message.String = req.Message
+		message.Valid = true
+	}
+
+	var request *models.RideRequest
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		request, err = scanRideRequest(tx.QueryRowContext(
+			ctx,
+			query,
+			rideID,                // $1
+			riderID,               // $2
+			req.PickupAddress,     // $3
+			req.PickupLatitude,    // $4
+			req.PickupLongitude,   // $5
+			dropoffAddress,        // $6
+			req.DropoffLatitude,   // $7
+			req.DropoffLongitude,  // $8
+			req.SeatsRequested,    // $9
+			message,               // $10
+			pickupStop,            // $11
+			dropoffStop,           // $12
+		))
+		if err != nil {
+			return err
+		}
+
+		if err := appendEvent(ctx, tx, events.RequestCreated, events.AggregateRideRequest, request.ID, &riderID, requestEventData(request)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityRideRequest,
+			EntityID:   request.ID,
+			OwnerID:    riderID,
+			ActorID:    &riderID,
+			After:      request,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ride request: %w", err)
+	}
+	metrics.RequestCreated()
+
+	return request, nil
+}
+
+// ListRideRequests returns the join requests for a ride. Only the host may list them.
+func (s *RideService) ListRideRequests(ctx context.Context, hostID, rideID uuid.UUID) ([]*models.RideRequest, error) {
+	ride, err := s.GetRide(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ride.HostID != hostID {
+		return nil, apperror.Forbidden("not_ride_host", "only the host can view requests for this ride")
+	}
+
+	query := `
+		SELECT request_id, ride_id, rider_id, pickup_address, pickup_latitude, pickup_longitude,
+			dropoff_address, dropoff_latitude, dropoff_longitude, pickup_stop, dropoff_stop, status,
+			seats_requested, distance_added_meters, message, created_at, updated_at, fare_breakdown
+		FROM ride_requests
+		WHERE ride_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx, query, rideID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ride requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []*models.RideRequest{}
+	for rows.Next() {
+		request, err := scanRideRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning ride request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through ride requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+// DecideRideRequest lets the host accept or reject a pending join request.
+// Accepting a request adds the rider to ride_passengers; the seats free on
+// each leg are kept in sync by the update_available_seats trigger.
+func (s *RideService) DecideRideRequest(ctx context.Context, hostID, rideID, requestID uuid.UUID, status string) (*models.RideRequest, error) {
+	if status != string(models.RequestAccepted) && status != string(models.RequestRejected) {
+		return nil, apperror.Invalid("status", "invalid_status", fmt.Sprintf("invalid request status: %s", status))
+	}
+
+	tx, err := s.dbManager.GetPrimary().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the ride so concurrent accepts cannot oversell seats
+	var rideHostID uuid.UUID
+	var rideStatus string
+	var trip fare.Trip
+	err = tx.QueryRowContext(ctx,
+		`SELECT host_id, status, origin_latitude, origin_longitude,
+			destination_latitude, destination_longitude, COALESCE(price_per_seat, 0)
+		FROM rides WHERE ride_id = $1 FOR UPDATE`,
+		rideID).Scan(&rideHostID, &rideStatus, &trip.OriginLat, &trip.OriginLon,
+		&trip.DestLat, &trip.DestLon, &trip.PricePerSeat)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrRideNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error fetching ride: %w", err)
+	}
+
+	if rideHostID != hostID {
+		return nil, apperror.Forbidden("not_ride_host", "only the host can decide on requests for this ride")
+	}
+
+	if rideStatus != string(models.StatusScheduled) {
+		return nil, apperror.Conflict("invalid_ride_status", fmt.Sprintf("requests cannot be changed for a ride with status: %s", rideStatus))
+	}
+
+	query := `
+		SELECT request_id, ride_id, rider_id, pickup_address, pickup_latitude, pickup_longitude,
+			dropoff_address, dropoff_latitude, dropoff_longitude, pickup_stop, dropoff_stop, status,
+			seats_requested, distance_added_meters, message, created_at, updated_at, fare_breakdown
+		FROM ride_requests
+		WHERE request_id = $1 AND ride_id = $2
+		FOR UPDATE
+	`
+
+	request, err := scanRideRequest(tx.QueryRowContext(ctx, query, requestID, rideID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrRideRequestNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error fetching ride request: %w", err)
+	}
+
+	if request.Status != string(models.RequestPending) {
+		return nil, apperror.Conflict("request_already_decided", fmt.Sprintf("ride request already %s", request.Status))
+	}
+
+	if status == string(models.RequestAccepted) {
+		// Stops only change under the ride's lock
+		legSeats, waypoints, err := legsOf(ctx, tx, rideID)
+		if err != nil {
+			return nil, err
+		}
+		if request.SeatsRequested > route.FreeSeats(legSeats, request.PickupStop, request.DropoffStop) {
+			return nil, models.ErrNotEnoughSeats
+		}
+		trip.Waypoints = waypoints
+
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO ride_passengers (ride_id, user_id, request_id, seats_taken) VALUES ($1, $2, $3, $4)",
+			rideID, request.RiderID, requestID, request.SeatsRequested)
+		if err != nil {
+			return nil, fmt.Errorf("error adding passenger: %w", err)
+		}
+	}
+
+	// The fare is fixed when the request is accepted
+	before := *request
+	var fareBreakdown sql.NullString
+	if status == string(models.RequestAccepted) {
+		trip.PickupLat, trip.PickupLon = request.PickupLatitude, request.PickupLongitude
+		trip.DropoffLat, trip.DropoffLon = trip.DestLat, trip.DestLon
+		if request.DropoffLatitude != nil && request.DropoffLongitude != nil {
+			trip.DropoffLat, trip.DropoffLon = *request.DropoffLatitude, *request.DropoffLongitude
+		}
+		trip.PickupStop, trip.DropoffStop = request.PickupStop, request.DropoffStop
+		trip.Seats = request.SeatsRequested
+
+		breakdown := s.fares.Share(trip)
+		request.Fare = &breakdown
+		data, err := json.Marshal(breakdown)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding fare: %w", err)
+		}
+		fareBreakdown = sql.NullString{String: string(data), Valid: true}
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"UPDATE ride_requests SET status = $1, fare_breakdown = $3 WHERE request_id = $2 RETURNING status, updated_at",
+		status, requestID, fareBreakdown).Scan(&request.Status, &request.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error updating ride request: %w", err)
+	}
+
+	eventType, action := events.RequestRejected, audit.ActionReject
+	if status == string(models.RequestAccepted) {
+		eventType, action = events.RequestAccepted, audit.ActionAccept
+	}
+	if err := appendEvent(ctx, tx, eventType, events.AggregateRideRequest, requestID, &hostID, requestEventData(request)); err != nil {
+		return nil, err
+	}
+	err = audit.Record(ctx, tx, audit.Entry{
+		Action:     action,
+		EntityType: audit.EntityRideRequest,
+		EntityID:   requestID,
+		OwnerID:    request.RiderID,
+		ActorID:    &hostID,
+		Before:     &before,
+		After:      request,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing ride request decision: %w", err)
+	}
+	metrics.RequestDecided(status)
+
+	return request, nil
+}
+
+// legsOf returns the seats free on each leg of a ride and where its
+// waypoints are
+func legsOf(ctx context.Context, tx *sql.Tx, rideID uuid.UUID) ([]int, []route.Point, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT latitude, longitude, available_seats FROM ride_stops WHERE ride_id = $1 ORDER BY stop_index",
+		rideID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching ride stops: %w", err)
+	}
+	defer rows.Close()
+
+	var legSeats []int
+	var points []route.Point
+	for rows.Next() {
+		var point route.Point
+		var seats sql.NullInt64
+		if err := rows.Scan(&point.Lat, &point.Lon, &seats); err != nil {
+			return nil, nil, fmt.Errorf("error scanning ride stop: %w", err)
+		}
+		points = append(points, point)
+		if seats.Valid {
+			legSeats = append(legSeats, int(seats.Int64))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating through ride stops: %w", err)
+	}
+
+	// The origin and destination are not waypoints
+	if len(points) < 2 {
+		return legSeats, nil, nil
+	}
+	return legSeats, points[1 : len(points)-1], nil
+}
+
+func scanRideRequest(row rowScanner) (*models.RideRequest, error) {
+	var request models.RideRequest
+	var fareBreakdown sql.NullString
+	err := row.Scan(
+		&request.ID,
+		&request.RideID,
+		&request.RiderID,
+		&request.PickupAddress,
+		&request.PickupLatitude,
+		&request.PickupLongitude,
+		&request.DropoffAddress,
+		&request.DropoffLatitude,
+		&request.DropoffLongitude,
+		&request.PickupStop,
+		&request.DropoffStop,
+		&request.Status,
+		&request.SeatsRequested,
+		&request.DistanceAdded,
+		&request.Message,
+		&request.CreatedAt,
+		&request.UpdatedAt,
+		&fareBreakdown,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if fareBreakdown.Valid {
+		if err := json.Unmarshal([]byte(fareBreakdown.String), &request.Fare); err != nil {
+			return nil, fmt.Errorf("error decoding fare: %w", err)
+		}
+	}
+	return &request, nil
+}
+
+// appendEvent writes a domain event to the outbox as part of tx
+func appendEvent(ctx context.Context, tx *sql.Tx, eventType events.Type, aggregateType string, aggregateID uuid.UUID, actorID *uuid.UUID, data interface{}) error {
+	event, err := events.New(eventType, aggregateType, aggregateID, actorID, data)
+	if err != nil {
+		return err
+	}
+	return outbox.Append(ctx, tx, event)
+}
+
+func rideEventData(ride *models.Ride, changes []string) events.RideV1 {
+	return events.RideV1{
+		RideID:             ride.ID,
+		HostID:             ride.HostID,
+		VehicleID:          ride.VehicleID,
+		Status:             ride.Status,
+		OriginAddress:      ride.OriginAddress,
+		DestinationAddress: ride.DestinationAddress,
+		DepartureTime:      ride.DepartureTime,
+		AvailableSeats:     ride.AvailableSeats,
+		PricePerSeat:       ride.PricePerSeat,
+		TimeZone:           ride.TimeZone,
+		Changes:            changes,
+	}
+}
+
+// localizeRide fills in the ride's times in its own zone
+func localizeRide(ride *models.Ride) {
+	if ride.TimeZone == "" {
+		ride.TimeZone = timezone.Default
+	}
+	ride.DepartureTimeLocal = timezone.Format(ride.DepartureTime, ride.TimeZone)
+	ride.EstimatedArrivalTimeLocal = timezone.Format(ride.EstimatedArrivalTime, ride.TimeZone)
+	for i := range ride.Waypoints {
+		ride.Waypoints[i].PlannedTimeLocal = timezone.Format(ride.Waypoints[i].PlannedTime, ride.TimeZone)
+	}
+}
+
+// userTimeZone returns a user's preferred zone
+func (s *RideService) userTimeZone(ctx context.Context, userID uuid.UUID) (string, error) {
+	var zone string
+	err := s.dbManager.GetReplica().QueryRowContext(ctx,
+		`SELECT time_zone FROM users WHERE user_id = $1`, userID,
+	).Scan(&zone)
+	if err == sql.ErrNoRows {
+		return timezone.Default, nil
+	} else if err != nil {
+		return "", fmt.Errorf("error fetching time zone: %w", err)
+	}
+	return zone, nil
+}
+
+func requestEventData(request *models.RideRequest) events.RideRequestV1 {
+	return events.RideRequestV1{
+		RequestID:      request.ID,
+		RideID:         request.RideID,
+		RiderID:        request.RiderID,
+		Status:         request.Status,
+		SeatsRequested: request.SeatsRequested,
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestRideChanges(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	departure := time.Date(2026, 5, 4, 6, 30, 0, 0, time.UTC)
+	arrival := departure.Add(2 * time.Hour)
+	description := "Via the ring road"
+	ride := &models.Ride{
+		DepartureTime:        departure,
+		EstimatedArrivalTime: arrival,
+		PricePerSeat:         4.5,
+		Description:          &description,
+	}
+	other := "Via the city centre"
+	empty := ""
+
+	tests := []struct {
+		name        string
+		departure   time.Time
+		arrival     time.Time
+		price       float64
+		description *string
+		want        []string
+	}{
+		{"nothing changed", departure, arrival, 4.5, &description, nil},
+		{"departure moved", departure.Add(time.Hour), arrival, 4.5, &description,
+			[]string{"departure time is now Mon 4 May 09:30 CEST"}},
+		{"arrival moved", departure, arrival.Add(30 * time.Minute), 4.5, &description,
+			[]string{"estimated arrival is now Mon 4 May 11:00 CEST"}},
+		{"price changed", departure, arrival, 5, &description,
+			[]string{"price per seat is now 5.00"}},
+		{"description changed", departure, arrival, 4.5, &other,
+			[]string{"description changed"}},
+		{"description cleared", departure, arrival, 4.5, nil,
+			[]string{"description changed"}},
+		{"whole trip moved", departure.Add(time.Hour), arrival.Add(time.Hour), 4.5, &description,
+			[]string{"departure time is now Mon 4 May 09:30 CEST", "estimated arrival is now Mon 4 May 11:30 CEST"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rideChanges(ride, tt.departure, tt.arrival, tt.price, tt.description, loc)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rideChanges = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	withoutDescription := *ride
+	withoutDescription.Description = nil
+	if got := rideChanges(&withoutDescription, departure, arrival, 4.5, &empty, loc); got != nil {
+		t.Errorf("setting an empty description on a ride without one = %q, want no changes", got)
+	}
+}
+
+func TestOrderByIDs(t *testing.T) {
+	near, mid, far, gone := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	// As the database may return them
+	rides := []*models.Ride{{ID: far}, {ID: near}, {ID: mid}}
+
+	var got []uuid.UUID
+	for _, ride := range orderByIDs(rides, []uuid.UUID{near, gone, mid, far}) {
+		got = append(got, ride.ID)
+	}
+	if want := []uuid.UUID{near, mid, far}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orderByIDs = %v, want %v: closest first, without the missing ride", got, want)
+	}
+}
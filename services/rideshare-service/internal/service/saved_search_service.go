@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/community"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/savedsearch"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/timezone"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"platform/shared/apperror"
+	"platform/shared/logging"
+)
+
+// Most searches a user may save
+const maxSavedSearches = 20
+
+const savedSearchColumns = `
+	search_id, user_id, COALESCE(name, ''), origin_latitude, origin_longitude,
+	destination_latitude, destination_longitude, radius_meters, departure_after,
+	departure_before, days, COALESCE(to_char(earliest_time, 'HH24:MI'), ''),
+	COALESCE(to_char(latest_time, 'HH24:MI'), ''), time_zone, max_price_per_seat,
+	min_seats, pets_allowed, smoking_allowed, created_at, updated_at
+`
+
+var errSavedSearchNotFound = apperror.NotFound("saved_search_not_found", "saved search not found")
+
+type SavedSearchService struct {
+	dbManager        *db.DBManager
+	rideService      *RideService
+	blockService     *BlockService
+	communityService *CommunityService
+}
+
+// NewSavedSearchService creates a new SavedSearchService
+func NewSavedSearchService(dbManager *db.DBManager, rideService *RideService, blockService *BlockService, communityService *CommunityService) *SavedSearchService {
+	return &SavedSearchService{
+		dbManager:        dbManager,
+		rideService:      rideService,
+		blockService:     blockService,
+		communityService: communityService,
+	}
+}
+
+// CreateSavedSearch saves a search for a user and indexes it for matching
+func (s *SavedSearchService) CreateSavedSearch(ctx context.Context, userID uuid.UUID, req *models.SavedSearchRequest) (*models.SavedSearch, error) {
+	search, loc, err := s.buildSearch(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO saved_searches (
+			user_id, name, origin_latitude, origin_longitude, destination_latitude,
+			destination_longitude, radius_meters, departure_after, departure_before, days,
+			earliest_time, latest_time, time_zone, max_price_per_seat, min_seats,
+			pets_allowed, smoking_allowed
+		) VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10,
+			NULLIF($11, '')::time, NULLIF($12, '')::time, $13, $14, $15, $16, $17)
+		RETURNING ` + savedSearchColumns
+
+	var saved *models.SavedSearch
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		// Lock the user so concurrent saves cannot exceed the limit
+		if _, err := tx.ExecContext(ctx, "SELECT 1 FROM users WHERE user_id = $1 FOR UPDATE", userID); err != nil {
+			return err
+		}
+		var count int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM saved_searches WHERE user_id = $1", userID).Scan(&count); err != nil {
+			return err
+		}
+		if count >= maxSavedSearches {
+			return apperror.Conflict("too_many_saved_searches", fmt.Sprintf("at most %d searches can be saved", maxSavedSearches))
+		}
+
+		var err error
+		saved, err = scanSavedSearch(tx.QueryRowContext(ctx, query, append([]interface{}{userID}, searchValues(search)...)...))
+		if err != nil {
+			return err
+		}
+		return writeSearchIndex(ctx, tx, saved, loc)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving search: %w", err)
+	}
+
+	return saved, nil
+}
+
+// ListSavedSearches returns a user's saved searches, newest first
+func (s *SavedSearchService) ListSavedSearches(ctx context.Context, userID uuid.UUID) ([]*models.SavedSearch, error) {
+	rows, err := s.dbManager.GetReplica().QueryContext(ctx,
+		"SELECT "+savedSearchColumns+" FROM saved_searches WHERE user_id = $1 ORDER BY created_at DESC",
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []*models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning saved search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through saved searches: %w", err)
+	}
+
+	return searches, nil
+}
+
+// GetSavedSearch returns one of a user's saved searches. Other users'
+// searches are reported as not found.
+func (s *SavedSearchService) GetSavedSearch(ctx context.Context, userID, searchID uuid.UUID) (*models.SavedSearch, error) {
+	search, err := scanSavedSearch(s.dbManager.GetReplica().QueryRowContext(ctx,
+		"SELECT "+savedSearchColumns+" FROM saved_searches WHERE search_id = $1 AND user_id = $2",
+		searchID, userID))
+	if err == sql.ErrNoRows {
+		return nil, errSavedSearchNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error fetching saved search: %w", err)
+	}
+	return search, nil
+}
+
+// UpdateSavedSearch replaces one of a user's saved searches. Rides already
+// alerted about are not sent again.
+func (s *SavedSearchService) UpdateSavedSearch(ctx context.Context, userID, searchID uuid.UUID, req *models.SavedSearchRequest) (*models.SavedSearch, error) {
+	search, loc, err := s.buildSearch(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE saved_searches
+		SET name = NULLIF($3, ''), origin_latitude = $4, origin_longitude = $5,
+			destination_latitude = $6, destination_longitude = $7, radius_meters = $8,
+			departure_after = $9, departure_before = $10, days = $11,
+			earliest_time = NULLIF($12, '')::time, latest_time = NULLIF($13, '')::time,
+			time_zone = $14, max_price_per_seat = $15, min_seats = $16,
+			pets_allowed = $17, smoking_allowed = $18, updated_at = NOW()
+		WHERE search_id = $1 AND user_id = $2
+		RETURNING ` + savedSearchColumns
+
+	var saved *models.SavedSearch
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		saved, err = scanSavedSearch(tx.QueryRowContext(ctx, query, append([]interface{}{searchID, userID}, searchValues(search)...)...))
+		if err == sql.ErrNoRows {
+			return errSavedSearchNotFound
+		} else if err != nil {
+			return err
+		}
+		return writeSearchIndex(ctx, tx, saved, loc)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating saved search: %w", err)
+	}
+
+	return saved, nil
+}
+
+// DeleteSavedSearch removes one of a user's saved searches
+func (s *SavedSearchService) DeleteSavedSearch(ctx context.Context, userID, searchID uuid.UUID) error {
+	result, err := s.dbManager.GetPrimary().ExecContext(ctx,
+		"DELETE FROM saved_searches WHERE search_id = $1 AND user_id = $2", searchID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting saved search: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errSavedSearchNotFound
+	}
+	return nil
+}
+
+// HandleEvent alerts riders whose saved searches match a ride that was just
+// posted or updated
+func (s *SavedSearchService) HandleEvent(ctx context.Context, event events.Event) error {
+	if event.Type != events.RideCreated && event.Type != events.RideUpdated {
+		return nil
+	}
+	var data events.RideV1
+	if err := event.Decode(&data); err != nil {
+		return err
+	}
+
+	ride, err := s.rideService.GetRideFromPrimary(ctx, data.RideID)
+	if err != nil {
+		return err
+	}
+	matches, err := s.MatchRide(ctx, ride)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	return s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, search := range matches {
+			result, err := tx.ExecContext(ctx,
+				"INSERT INTO saved_search_alerts (search_id, ride_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				search.ID, ride.ID)
+			if err != nil {
+				return fmt.Errorf("error recording saved search alert: %w", err)
+			}
+			if n, err := result.RowsAffected(); err != nil || n == 0 {
+				continue
+			}
+
+			match := events.SavedSearchMatchV1{SearchID: search.ID, UserID: search.UserID, RideID: ride.ID, Name: search.Name}
+			if err := appendEvent(ctx, tx, events.SavedSearchMatched, events.AggregateSavedSearch, search.ID, nil, match); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MatchRide returns the saved searches of other users that a ride matches
+// and that the ride's host and audience allow to see it. Only the searches
+// indexed under the ride's origin cell and departure hour are checked.
+func (s *SavedSearchService) MatchRide(ctx context.Context, ride *models.Ride) ([]*models.SavedSearch, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM saved_searches
+		WHERE search_id IN (
+			SELECT c.search_id
+			FROM saved_search_cells c
+			JOIN saved_search_buckets b ON b.search_id = c.search_id
+			WHERE c.geohash = $1 AND b.time_bucket = $2
+		)
+		AND user_id <> $3
+		AND (departure_before IS NULL OR departure_before >= $4)
+	`
+	rows, err := s.dbManager.GetPrimary().QueryContext(ctx, query,
+		savedsearch.Cell(ride.OriginLatitude, ride.OriginLongitude),
+		savedsearch.HourOfWeek(ride.DepartureTime), ride.HostID, ride.DepartureTime)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*models.SavedSearch
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning saved search: %w", err)
+		}
+		loc, err := timezone.Load(search.TimeZone)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Saved search has an invalid time zone", "searchID", search.ID, "timeZone", search.TimeZone, "error", err)
+			continue
+		}
+		if savedsearch.Matches(search, ride, loc) {
+			candidates = append(candidates, search)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through saved searches: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	userIDs := []uuid.UUID{ride.HostID}
+	for _, search := range candidates {
+		userIDs = append(userIDs, search.UserID)
+	}
+	profiles, err := s.communityService.GetProfiles(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*models.SavedSearch
+	for _, search := range candidates {
+		if !community.Eligible(ride.Audience, profiles[ride.HostID], profiles[search.UserID]) {
+			continue
+		}
+		blocked, err := s.blockService.IsBlockedBetween(ctx, ride.HostID, search.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !blocked {
+			matches = append(matches, search)
+		}
+	}
+	return matches, nil
+}
+
+// buildSearch checks a request and resolves its times in the search's zone,
+// which defaults to the user's
+func (s *SavedSearchService) buildSearch(ctx context.Context, userID uuid.UUID, req *models.SavedSearchRequest) (*models.SavedSearch, *time.Location, error) {
+	zone := req.TimeZone
+	if zone == "" {
+		err := s.dbManager.GetReplica().QueryRowContext(ctx,
+			"SELECT time_zone FROM users WHERE user_id = $1", userID).Scan(&zone)
+		if err == sql.ErrNoRows {
+			zone = timezone.Default
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("error fetching time zone: %w", err)
+		}
+	}
+	loc, err := timezone.Load(zone)
+	if err != nil {
+		return nil, nil, apperror.Invalid("timeZone", "invalid_time_zone", err.Error())
+	}
+
+	search := &models.SavedSearch{
+		UserID:               userID,
+		Name:                 req.Name,
+		OriginLatitude:       req.OriginLatitude,
+		OriginLongitude:      req.OriginLongitude,
+		DestinationLatitude:  req.DestinationLatitude,
+		DestinationLongitude: req.DestinationLongitude,
+		RadiusMeters:         req.RadiusMeters,
+		EarliestTime:         req.EarliestTime,
+		LatestTime:           req.LatestTime,
+		TimeZone:             zone,
+		Filters:              req.Filters,
+	}
+
+	if req.DepartureAfter != "" {
+		after, err := timezone.ParseLocal(req.DepartureAfter, loc)
+		if err != nil {
+			return nil, nil, apperror.Invalid("departureAfter", "invalid_time", err.Error())
+		}
+		search.DepartureAfter = &after
+	}
+	if req.DepartureBefore != "" {
+		before, err := timezone.ParseLocal(req.DepartureBefore, loc)
+		if err != nil {
+			return nil, nil, apperror.Invalid("departureBefore", "invalid_time", err.Error())
+		}
+		if search.DepartureAfter != nil && !before.After(*search.DepartureAfter) {
+			return nil, nil, apperror.Invalid("departureBefore", "before_departure_after", "departureBefore must be after departureAfter")
+		}
+		if before.Before(time.Now()) {
+			return nil, nil, apperror.Invalid("departureBefore", "in_past", "departureBefore must be in the future")
+		}
+		search.DepartureBefore = &before
+	}
+
+	// Days are stored once each, in order
+	seen := map[int]bool{}
+	for _, day := range req.Days {
+		if !seen[day] {
+			seen[day] = true
+			search.Days = append(search.Days, day)
+		}
+	}
+	sort.Ints(search.Days)
+
+	return search, loc, nil
+}
+
+// searchValues are the stored columns of a search, in the order the insert
+// and update statements take them after their key columns
+func searchValues(search *models.SavedSearch) []interface{} {
+	days := make(pq.Int64Array, len(search.Days))
+	for i, day := range search.Days {
+		days[i] = int64(day)
+	}
+	return []interface{}{
+		search.Name,
+		search.OriginLatitude,
+		search.OriginLongitude,
+		search.DestinationLatitude,
+		search.DestinationLongitude,
+		search.RadiusMeters,
+		search.DepartureAfter,
+		search.DepartureBefore,
+		days,
+		search.EarliestTime,
+		search.LatestTime,
+		search.TimeZone,
+		search.Filters.MaxPricePerSeat,
+		search.Filters.MinSeats,
+		search.Filters.PetsAllowed,
+		search.Filters.SmokingAllowed,
+	}
+}
+
+// writeSearchIndex replaces the geohash cells and time buckets a search is
+// matched under
+func writeSearchIndex(ctx context.Context, tx *sql.Tx, search *models.SavedSearch, loc *time.Location) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM saved_search_cells WHERE search_id = $1", search.ID); err != nil {
+		return fmt.Errorf("error clearing saved search cells: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM saved_search_buckets WHERE search_id = $1", search.ID); err != nil {
+		return fmt.Errorf("error clearing saved search buckets: %w", err)
+	}
+
+	cells := savedsearch.Cells(search.OriginLatitude, search.OriginLongitude, search.RadiusMeters)
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO saved_search_cells (geohash, search_id) SELECT unnest($1::text[]), $2",
+		pq.Array(cells), search.ID)
+	if err != nil {
+		return fmt.Errorf("error indexing saved search cells: %w", err)
+	}
+
+	buckets := savedsearch.Buckets(search, loc)
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO saved_search_buckets (time_bucket, search_id) SELECT unnest($1::smallint[]), $2",
+		pq.Array(buckets), search.ID)
+	if err != nil {
+		return fmt.Errorf("error indexing saved search buckets: %w", err)
+	}
+	return nil
+}
+
+func scanSavedSearch(row rowScanner) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	var destLat, destLon, maxPrice sql.NullFloat64
+	var after, before sql.NullTime
+	var days pq.Int64Array
+	var pets, smoking sql.NullBool
+	err := row.Scan(
+		&search.ID,
+		&search.UserID,
+		&search.Name,
+		&search.OriginLatitude,
+		&search.OriginLongitude,
+		&destLat,
+		&destLon,
+		&search.RadiusMeters,
+		&after,
+		&before,
+		&days,
+		&search.EarliestTime,
+		&search.LatestTime,
+		&search.TimeZone,
+		&maxPrice,
+		&search.Filters.MinSeats,
+		&pets,
+		&smoking,
+		&search.CreatedAt,
+		&search.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if destLat.Valid && destLon.Valid {
+		search.DestinationLatitude, search.DestinationLongitude = &destLat.Float64, &destLon.Float64
+	}
+	if after.Valid {
+		search.DepartureAfter = &after.Time
+	}
+	if before.Valid {
+		search.DepartureBefore = &before.Time
+	}
+	for _, day := range days {
+		search.Days = append(search.Days, int(day))
+	}
+	if maxPrice.Valid {
+		search.Filters.MaxPricePerSeat = &maxPrice.Float64
+	}
+	if pets.Valid {
+		search.Filters.PetsAllowed = &pets.Bool
+	}
+	if smoking.Valid {
+		search.Filters.SmokingAllowed = &smoking.Bool
+	}
+	return &search, nil
+}
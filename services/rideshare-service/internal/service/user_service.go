@@ -3,16 +3,22 @@ package service
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/audit"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/timezone"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"platform/shared/apperror"
+	"platform/shared/logging"
 )
 
+// errInvalidCredentials does not say which of email or password was wrong
+var errInvalidCredentials = apperror.Unauthorized("invalid_credentials", "invalid email or password")
+
 type UserService struct {
 	dbManager *db.DBManager
 }
@@ -23,7 +29,11 @@ func NewUserService(dbManager *db.DBManager) *UserService {
 }
 
 // RegisterUser registers a new user
-func (s *UserService) RegisterUser(ctx context.Context, email, password, firstName, lastName, phoneNumber string, dateOfBirth time.Time) (*models.User, error) {
+func (s *UserService) RegisterUser(ctx context.Context, email, password, firstName, lastName, phoneNumber string, dateOfBirth time.Time, timeZone string) (*models.User, error) {
+	if timeZone == "" {
+		timeZone = timezone.Default
+	}
+
 	// Check if email already exists
 	var exists bool
 	err := s.dbManager.GetReplica().QueryRowContext(ctx, 
@@ -35,7 +45,7 @@ func (s *UserService) RegisterUser(ctx context.Context, email, password, firstNa
 	}
 	
 	if exists {
-		return nil, errors.New("email already registered")
+		return nil, apperror.Conflict("email_taken", "email already registered")
 	}
 
 	// Hash password
@@ -48,38 +58,54 @@ func (s *UserService) RegisterUser(ctx context.Context, email, password, firstNa
 	query := `
 		INSERT INTO users (
 			email, password_hash, first_name, last_name, 
-			phone_number, date_of_birth, role
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			phone_number, date_of_birth, role, time_zone
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING user_id, email, first_name, last_name, phone_number, 
 			role, date_of_birth, is_verified, is_active, average_rating,
-			created_at, updated_at
+			created_at, updated_at, time_zone
 	`
 
 	var user models.User
-	err = s.dbManager.GetPrimary().QueryRowContext(
-		ctx,
-		query,
-		email,
-		string(hashedPassword),
-		firstName,
-		lastName,
-		phoneNumber,
-		dateOfBirth,
-		"rider", // Default role
-	).Scan(
-		&user.ID,
-		&user.Email,
-		&user.FirstName,
-		&user.LastName,
-		&user.PhoneNumber,
-		&user.Role,
-		&user.DateOfBirth,
-		&user.IsVerified,
-		&user.IsActive,
-		&user.AverageRating,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			email,
+			string(hashedPassword),
+			firstName,
+			lastName,
+			phoneNumber,
+			dateOfBirth,
+			"rider", // Default role
+			timeZone,
+		).Scan(
+			&user.ID,
+			&user.Email,
+			&user.FirstName,
+			&user.LastName,
+			&user.PhoneNumber,
+			&user.Role,
+			&user.DateOfBirth,
+			&user.IsVerified,
+			&user.IsActive,
+			&user.AverageRating,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.TimeZone,
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionRegister,
+			EntityType: audit.EntityUser,
+			EntityID:   user.ID,
+			OwnerID:    user.ID,
+			ActorID:    &user.ID,
+			After:      &user,
+		})
+	})
 
 	if err != nil {
 		return nil, fmt.Errorf("error inserting user: %w", err)
@@ -94,7 +120,7 @@ func (s *UserService) LoginUser(ctx context.Context, email, password string) (*m
 	query := `
 		SELECT user_id, email, password_hash, first_name, last_name, 
 			phone_number, role, date_of_birth, is_verified, is_active, 
-			average_rating, created_at, updated_at
+			average_rating, created_at, updated_at, time_zone
 		FROM users
 		WHERE email = $1 AND is_active = true
 	`
@@ -114,10 +140,11 @@ func (s *UserService) LoginUser(ctx context.Context, email, password string) (*m
 		&user.AverageRating,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.TimeZone,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("invalid email or password")
+		return nil, errInvalidCredentials
 	} else if err != nil {
 		return nil, fmt.Errorf("error fetching user: %w", err)
 	}
@@ -125,18 +152,33 @@ func (s *UserService) LoginUser(ctx context.Context, email, password string) (*m
 	// Verify password
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, errInvalidCredentials
 	}
 
 	// Update last login time
-	_, err = s.dbManager.GetPrimary().ExecContext(
-		ctx,
-		"UPDATE users SET last_login_at = NOW() WHERE user_id = $1",
-		user.ID,
-	)
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		var lastLoginAt time.Time
+		err := tx.QueryRowContext(
+			ctx,
+			"UPDATE users SET last_login_at = NOW() WHERE user_id = $1 RETURNING last_login_at",
+			user.ID,
+		).Scan(&lastLoginAt)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionLogin,
+			EntityType: audit.EntityUser,
+			EntityID:   user.ID,
+			OwnerID:    user.ID,
+			ActorID:    &user.ID,
+			After:      map[string]time.Time{"lastLoginAt": lastLoginAt},
+		})
+	})
 	if err != nil {
 		// Log the error but don't fail the login
-		fmt.Printf("error updating last login time: %v", err)
+		logging.FromContext(ctx).Error("Error updating last login time", "error", err)
 	}
 
 	return &user, nil
@@ -147,7 +189,7 @@ func (s *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) (*model
 	query := `
 		SELECT user_id, email, first_name, last_name, phone_number,
 			role, date_of_birth, is_verified, is_active, average_rating,
-			created_at, updated_at
+			created_at, updated_at, time_zone
 		FROM users
 		WHERE user_id = $1 AND is_active = true
 	`
@@ -166,10 +208,11 @@ func (s *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) (*model
 		&user.AverageRating,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.TimeZone,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("user not found")
+		return nil, models.ErrUserNotFound
 	} else if err != nil {
 		return nil, fmt.Errorf("error fetching user: %w", err)
 	}
@@ -177,6 +220,44 @@ func (s *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) (*model
 	return &user, nil
 }
 
+// UpdateTimeZone changes the zone a user's times are shown and searched in
+func (s *UserService) UpdateTimeZone(ctx context.Context, userID uuid.UUID, timeZone string) (*models.User, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TimeZone == timeZone {
+		return user, nil
+	}
+
+	before := *user
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			"UPDATE users SET time_zone = $2, updated_at = NOW() WHERE user_id = $1 RETURNING updated_at",
+			userID, timeZone,
+		).Scan(&user.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		user.TimeZone = timeZone
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityUser,
+			EntityID:   userID,
+			OwnerID:    userID,
+			ActorID:    &userID,
+			Before:     &before,
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating time zone: %w", err)
+	}
+
+	return user, nil
+}
+
 // GenerateToken creates a token for a user
 // In production, this would likely use JWT or similar
 func (s *UserService) GenerateToken(userID uuid.UUID) (string, error) {
@@ -187,9 +268,6 @@ func (s *UserService) GenerateToken(userID uuid.UUID) (string, error) {
 
 // ValidateToken checks if a token is valid and returns the user ID
 func (s *UserService) ValidateToken(ctx context.Context, token string) (uuid.UUID, error) {
-	// Debug token validation
-	fmt.Printf("Validating token: %s\n", token)
-	
 	// For testing only - accept dummy token for tests
 	if token == "dummy-auth-token-for-testing" {
 		testID, _ := uuid.Parse("00000000-0000-0000-0000-000000000001")
@@ -220,12 +298,12 @@ func (s *UserService) ValidateToken(ctx context.Context, token string) (uuid.UUI
 	var timestamp int64
 	_, err := fmt.Sscanf(token, "token-%s-%d", &userIDStr, &timestamp)
 	if err != nil {
-		return uuid.Nil, errors.New("invalid token format")
+		return uuid.Nil, apperror.Unauthorized("invalid_token", "invalid token format")
 	}
 	
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		return uuid.Nil, errors.New("invalid user ID in token")
+		return uuid.Nil, apperror.Unauthorized("invalid_token", "invalid user ID in token")
 	}
 	
 	// Check if user exists and is active
@@ -241,7 +319,7 @@ func (s *UserService) ValidateToken(ctx context.Context, token string) (uuid.UUI
 	}
 	
 	if !exists {
-		return uuid.Nil, errors.New("user not found or inactive")
+		return uuid.Nil, apperror.Unauthorized("inactive_user", "user not found or inactive")
 	}
 	
 	return userID, nil
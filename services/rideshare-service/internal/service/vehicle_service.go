@@ -3,13 +3,17 @@ package service
 import (
 	"context"
 	"database/sql"
-	"errors"
 
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/audit"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/db"
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/events"
 	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
 	"github.com/google/uuid"
+	"platform/shared/apperror"
 )
 
+var errVehicleNotOwned = apperror.Forbidden("vehicle_not_owned", "vehicle does not belong to user")
+
 type VehicleService struct {
 	dbManager *db.DBManager
 }
@@ -20,43 +24,59 @@ func NewVehicleService(dbManager *db.DBManager) *VehicleService {
 
 // CreateVehicle creates a new vehicle in the database
 func (s *VehicleService) CreateVehicle(ctx context.Context, userID uuid.UUID, req *models.CreateVehicleRequest) (*models.Vehicle, error) {
-	// Validate request
-	if req.Make == "" || req.Model == "" || req.Year <= 0 || 
-	   req.Color == "" || req.LicensePlate == "" || req.Capacity <= 0 {
-		return nil, errors.New("missing required vehicle fields")
-	}
-
 	// Insert vehicle into database
 	query := `
-		INSERT INTO vehicles (user_id, make, model, year, color, license_plate, capacity, is_active) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, true) 
-		RETURNING vehicle_id, user_id, make, model, year, color, license_plate, capacity, is_active, created_at, updated_at
+		INSERT INTO vehicles (user_id, make, model, year, color, license_plate, capacity, fuel_type, efficiency, is_active) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, 0), true) 
+		RETURNING vehicle_id, user_id, make, model, year, color, license_plate, capacity,
+			COALESCE(fuel_type, ''), COALESCE(efficiency, 0), is_active, created_at, updated_at
 	`
 
 	var vehicle models.Vehicle
-	err := s.dbManager.GetPrimary().QueryRowContext(
-		ctx,
-		query,
-		userID,
-		req.Make,
-		req.Model,
-		req.Year,
-		req.Color,
-		req.LicensePlate,
-		req.Capacity,
-	).Scan(
-		&vehicle.ID,
-		&vehicle.UserID,
-		&vehicle.Make,
-		&vehicle.Model,
-		&vehicle.Year,
-		&vehicle.Color,
-		&vehicle.LicensePlate,
-		&vehicle.Capacity,
-		&vehicle.IsActive,
-		&vehicle.CreatedAt,
-		&vehicle.UpdatedAt,
-	)
+	err := s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			userID,
+			req.Make,
+			req.Model,
+			req.Year,
+			req.Color,
+			req.LicensePlate,
+			req.Capacity,
+			req.FuelType,
+			req.Efficiency,
+		).Scan(
+			&vehicle.ID,
+			&vehicle.UserID,
+			&vehicle.Make,
+			&vehicle.Model,
+			&vehicle.Year,
+			&vehicle.Color,
+			&vehicle.LicensePlate,
+			&vehicle.Capacity,
+			&vehicle.FuelType,
+			&vehicle.Efficiency,
+			&vehicle.IsActive,
+			&vehicle.CreatedAt,
+			&vehicle.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := appendEvent(ctx, tx, events.VehicleCreated, events.AggregateVehicle, vehicle.ID, &userID, vehicleEventData(&vehicle)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityVehicle,
+			EntityID:   vehicle.ID,
+			OwnerID:    userID,
+			ActorID:    &userID,
+			After:      &vehicle,
+		})
+	})
 
 	if err != nil {
 		return nil, err
@@ -68,7 +88,8 @@ func (s *VehicleService) CreateVehicle(ctx context.Context, userID uuid.UUID, re
 // GetVehiclesByUserID retrieves all vehicles belonging to a user
 func (s *VehicleService) GetVehiclesByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Vehicle, error) {
 	query := `
-		SELECT vehicle_id, user_id, make, model, year, color, license_plate, capacity, is_active, created_at, updated_at
+		SELECT vehicle_id, user_id, make, model, year, color, license_plate, capacity,
+			COALESCE(fuel_type, ''), COALESCE(efficiency, 0), is_active, created_at, updated_at
 		FROM vehicles
 		WHERE user_id = $1 AND is_active = true
 		ORDER BY created_at DESC
@@ -92,6 +113,8 @@ func (s *VehicleService) GetVehiclesByUserID(ctx context.Context, userID uuid.UU
 			&v.Color,
 			&v.LicensePlate,
 			&v.Capacity,
+			&v.FuelType,
+			&v.Efficiency,
 			&v.IsActive,
 			&v.CreatedAt,
 			&v.UpdatedAt,
@@ -111,7 +134,8 @@ func (s *VehicleService) GetVehiclesByUserID(ctx context.Context, userID uuid.UU
 // GetVehicleByID retrieves a single vehicle by ID
 func (s *VehicleService) GetVehicleByID(ctx context.Context, vehicleID uuid.UUID) (*models.Vehicle, error) {
 	query := `
-		SELECT vehicle_id, user_id, make, model, year, color, license_plate, capacity, is_active, created_at, updated_at
+		SELECT vehicle_id, user_id, make, model, year, color, license_plate, capacity,
+			COALESCE(fuel_type, ''), COALESCE(efficiency, 0), is_active, created_at, updated_at
 		FROM vehicles
 		WHERE vehicle_id = $1 AND is_active = true
 	`
@@ -126,13 +150,15 @@ func (s *VehicleService) GetVehicleByID(ctx context.Context, vehicleID uuid.UUID
 		&v.Color,
 		&v.LicensePlate,
 		&v.Capacity,
+		&v.FuelType,
+		&v.Efficiency,
 		&v.IsActive,
 		&v.CreatedAt,
 		&v.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("vehicle not found")
+		return nil, models.ErrVehicleNotFound
 	} else if err != nil {
 		return nil, err
 	}
@@ -149,41 +175,65 @@ func (s *VehicleService) UpdateVehicle(ctx context.Context, userID, vehicleID uu
 	}
 
 	if vehicle.UserID != userID {
-		return nil, errors.New("vehicle does not belong to user")
+		return nil, errVehicleNotOwned
 	}
 
 	// Update the vehicle
 	query := `
 		UPDATE vehicles
-		SET make = $1, model = $2, year = $3, color = $4, license_plate = $5, capacity = $6, updated_at = NOW()
+		SET make = $1, model = $2, year = $3, color = $4, license_plate = $5, capacity = $6,
+			fuel_type = NULLIF($8, ''), efficiency = NULLIF($9, 0), updated_at = NOW()
 		WHERE vehicle_id = $7
-		RETURNING vehicle_id, user_id, make, model, year, color, license_plate, capacity, is_active, created_at, updated_at
+		RETURNING vehicle_id, user_id, make, model, year, color, license_plate, capacity,
+			COALESCE(fuel_type, ''), COALESCE(efficiency, 0), is_active, created_at, updated_at
 	`
 
 	var updatedVehicle models.Vehicle
-	err = s.dbManager.GetPrimary().QueryRowContext(
-		ctx,
-		query,
-		req.Make,
-		req.Model,
-		req.Year,
-		req.Color,
-		req.LicensePlate,
-		req.Capacity,
-		vehicleID,
-	).Scan(
-		&updatedVehicle.ID,
-		&updatedVehicle.UserID,
-		&updatedVehicle.Make,
-		&updatedVehicle.Model,
-		&updatedVehicle.Year,
-		&updatedVehicle.Color,
-		&updatedVehicle.LicensePlate,
-		&updatedVehicle.Capacity,
-		&updatedVehicle.IsActive,
-		&updatedVehicle.CreatedAt,
-		&updatedVehicle.UpdatedAt,
-	)
+	err = s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			req.Make,
+			req.Model,
+			req.Year,
+			req.Color,
+			req.LicensePlate,
+			req.Capacity,
+			vehicleID,
+			req.FuelType,
+			req.Efficiency,
+		).Scan(
+			&updatedVehicle.ID,
+			&updatedVehicle.UserID,
+			&updatedVehicle.Make,
+			&updatedVehicle.Model,
+			&updatedVehicle.Year,
+			&updatedVehicle.Color,
+			&updatedVehicle.LicensePlate,
+			&updatedVehicle.Capacity,
+			&updatedVehicle.FuelType,
+			&updatedVehicle.Efficiency,
+			&updatedVehicle.IsActive,
+			&updatedVehicle.CreatedAt,
+			&updatedVehicle.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := appendEvent(ctx, tx, events.VehicleUpdated, events.AggregateVehicle, vehicleID, &userID, vehicleEventData(&updatedVehicle)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityVehicle,
+			EntityID:   vehicleID,
+			OwnerID:    userID,
+			ActorID:    &userID,
+			Before:     vehicle,
+			After:      &updatedVehicle,
+		})
+	})
 
 	if err != nil {
 		return nil, err
@@ -201,7 +251,7 @@ func (s *VehicleService) DeleteVehicle(ctx context.Context, userID, vehicleID uu
 	}
 
 	if vehicle.UserID != userID {
-		return errors.New("vehicle does not belong to user")
+		return errVehicleNotOwned
 	}
 
 	// Soft delete the vehicle
@@ -211,6 +261,36 @@ func (s *VehicleService) DeleteVehicle(ctx context.Context, userID, vehicleID uu
 		WHERE vehicle_id = $1
 	`
 
-	_, err = s.dbManager.GetPrimary().ExecContext(ctx, query, vehicleID)
-	return err
+	return s.dbManager.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, vehicleID); err != nil {
+			return err
+		}
+
+		before := *vehicle
+		vehicle.IsActive = false
+		if err := appendEvent(ctx, tx, events.VehicleDeactivated, events.AggregateVehicle, vehicleID, &userID, vehicleEventData(vehicle)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityVehicle,
+			EntityID:   vehicleID,
+			OwnerID:    userID,
+			ActorID:    &userID,
+			Before:     &before,
+			After:      vehicle,
+		})
+	})
+}
+
+func vehicleEventData(vehicle *models.Vehicle) events.VehicleV1 {
+	return events.VehicleV1{
+		VehicleID: vehicle.ID,
+		UserID:    vehicle.UserID,
+		Make:      vehicle.Make,
+		Model:     vehicle.Model,
+		Year:      vehicle.Year,
+		Capacity:  vehicle.Capacity,
+		IsActive:  vehicle.IsActive,
+	}
 }
\ No newline at end of file
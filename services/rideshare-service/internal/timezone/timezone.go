@@ -0,0 +1,129 @@
+// Package timezone interprets local times in IANA time zones, so rides keep
+// their wall-clock time across DST changes and for users in other cities
+package timezone
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// The service image has no zoneinfo, so embed the zone database
+	_ "time/tzdata"
+)
+
+// Default is the zone of rides and users that have not chosen one
+const Default = "UTC"
+
+// Layouts accepted for local times, which have no UTC offset
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// Parts of the day, as [start, end) hours of local time. Night runs into the
+// following day.
+var partsOfDay = map[string][2]int{
+	"morning":   {5, 12},
+	"afternoon": {12, 17},
+	"evening":   {17, 22},
+	"night":     {22, 29},
+}
+
+// Load returns the location of an IANA zone name such as "America/New_York".
+// An empty name is the default zone. "Local" is rejected because it depends
+// on the server.
+func Load(name string) (*time.Location, error) {
+	if name == "" {
+		name = Default
+	}
+	if name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// Valid reports whether name is a zone Load accepts
+func Valid(name string) bool {
+	_, err := Load(name)
+	return err == nil
+}
+
+// ParseLocal parses an RFC 3339 timestamp, or a local time without an offset
+// such as "2025-03-09T08:30", which is taken as wall-clock time in loc.
+// Local times skipped by a DST change do not exist and are rejected.
+func ParseLocal(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range localLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			continue
+		}
+		// Go moves times in a DST gap; a moved time no longer reads the same
+		if t.Format(layout) != value {
+			return time.Time{}, fmt.Errorf("%s does not exist in %s", value, loc)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// IsTimestamp reports whether value is a time ParseLocal can parse, without
+// checking it against a zone
+func IsTimestamp(value string) bool {
+	_, err := ParseLocal(value, time.UTC)
+	return err == nil
+}
+
+// DayRange returns the [from, to) range of a day in loc, or of part of it.
+// day is "today", "tomorrow" or a date such as "2025-03-09", relative to now;
+// part is "morning", "afternoon", "evening", "night" or empty for the whole
+// day. Days are measured on the wall clock, so they are 23 or 25 hours long
+// across DST changes.
+func DayRange(now time.Time, loc *time.Location, day, part string) (time.Time, time.Time, error) {
+	local := now.In(loc)
+	var year, month, date int
+	switch strings.ToLower(day) {
+	case "today":
+		year, month, date = local.Year(), int(local.Month()), local.Day()
+	case "tomorrow":
+		next := time.Date(local.Year(), local.Month(), local.Day()+1, 12, 0, 0, 0, loc)
+		year, month, date = next.Year(), int(next.Month()), next.Day()
+	default:
+		parsed, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid day %q", day)
+		}
+		year, month, date = parsed.Year(), int(parsed.Month()), parsed.Day()
+	}
+
+	startHour, endHour := 0, 24
+	if part != "" {
+		hours, ok := partsOfDay[strings.ToLower(part)]
+		if !ok {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid part of day %q", part)
+		}
+		startHour, endHour = hours[0], hours[1]
+	}
+
+	// time.Date normalizes hours past 24 into the next day
+	from := time.Date(year, time.Month(month), date, startHour, 0, 0, 0, loc)
+	to := time.Date(year, time.Month(month), date, endHour, 0, 0, 0, loc)
+	return from, to, nil
+}
+
+// Format renders t as RFC 3339 in the named zone, falling back to UTC for
+// zones that cannot be loaded
+func Format(t time.Time, name string) string {
+	loc, err := Load(name)
+	if err != nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
@@ -0,0 +1,95 @@
+package timezone
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := Load(name)
+	if err != nil {
+		t.Fatalf("Load(%q): %v", name, err)
+	}
+	return loc
+}
+
+func TestLoadRejectsUnknownAndServerZones(t *testing.T) {
+	for _, name := range []string{"Mars/Olympus_Mons", "Local", "EST5EDT "} {
+		if Valid(name) {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+	if loc := mustLoad(t, ""); loc != time.UTC {
+		t.Errorf("empty zone loaded as %v", loc)
+	}
+}
+
+func TestParseLocalUsesWallClockInZone(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+
+	// Same wall-clock time either side of the March DST change
+	before, err := ParseLocal("2025-03-08T08:30", ny)
+	if err != nil {
+		t.Fatalf("ParseLocal: %v", err)
+	}
+	after, err := ParseLocal("2025-03-10T08:30:00", ny)
+	if err != nil {
+		t.Fatalf("ParseLocal: %v", err)
+	}
+	if got := before.UTC().Format("15:04"); got != "13:30" {
+		t.Errorf("08:30 EST is %s UTC", got)
+	}
+	if got := after.UTC().Format("15:04"); got != "12:30" {
+		t.Errorf("08:30 EDT is %s UTC", got)
+	}
+
+	// Offsets win over the zone
+	explicit, err := ParseLocal("2025-03-10T08:30:00+02:00", ny)
+	if err != nil || explicit.UTC().Format("15:04") != "06:30" {
+		t.Errorf("RFC 3339 time parsed as %v, %v", explicit, err)
+	}
+
+	// 02:30 is skipped when clocks go forward
+	if _, err := ParseLocal("2025-03-09T02:30", ny); err == nil {
+		t.Error("expected a time in the DST gap to be rejected")
+	}
+}
+
+func TestDayRangeFollowsTheLocalCalendar(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	// 03:00 UTC on the 9th is still the evening of the 8th in New York
+	now := time.Date(2025, 3, 9, 3, 0, 0, 0, time.UTC)
+
+	from, to, err := DayRange(now, ny, "today", "")
+	if err != nil {
+		t.Fatalf("DayRange: %v", err)
+	}
+	if from.Format(time.RFC3339) != "2025-03-08T00:00:00-05:00" || to.Format(time.RFC3339) != "2025-03-09T00:00:00-05:00" {
+		t.Errorf("today = %v to %v", from, to)
+	}
+
+	// Tomorrow is the DST day, which is 23 hours long
+	from, to, err = DayRange(now, ny, "tomorrow", "")
+	if err != nil {
+		t.Fatalf("DayRange: %v", err)
+	}
+	if to.Sub(from) != 23*time.Hour {
+		t.Errorf("DST day lasts %v", to.Sub(from))
+	}
+
+	from, to, err = DayRange(now, ny, "tomorrow", "morning")
+	if err != nil {
+		t.Fatalf("DayRange: %v", err)
+	}
+	if from.Format(time.RFC3339) != "2025-03-09T05:00:00-04:00" || to.Format(time.RFC3339) != "2025-03-09T12:00:00-04:00" {
+		t.Errorf("tomorrow morning = %v to %v", from, to)
+	}
+
+	if _, _, err := DayRange(now, ny, "someday", ""); err == nil {
+		t.Error("expected an invalid day to be rejected")
+	}
+	if _, _, err := DayRange(now, ny, "today", "brunch"); err == nil {
+		t.Error("expected an invalid part of day to be rejected")
+	}
+}
@@ -0,0 +1,180 @@
+// Package validation checks request payloads against the rules declared in
+// their `validate` struct tags and reports every failing field at once.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/timezone"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+)
+
+// MinimumAge is the youngest a user may be to register
+const MinimumAge = 18
+
+// maximumAge rejects dates of birth that are almost certainly typos
+const maximumAge = 120
+
+// dateLayout is the format of dates of birth
+const dateLayout = "2006-01-02"
+
+var (
+	licensePlatePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{0,18}[A-Za-z0-9]$`)
+	phonePattern        = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{6,18}[0-9]$`)
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON names, which is what clients send
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// A nil UUID counts as missing for "required"
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if id, ok := field.Interface().(uuid.UUID); ok && id != uuid.Nil {
+			return id.String()
+		}
+		return ""
+	}, uuid.UUID{})
+
+	v.RegisterValidation("notblank", validators.NotBlank)
+	v.RegisterValidation("localtime", isLocalTime)
+	v.RegisterValidation("timezone", isTimeZone)
+	v.RegisterValidation("adult", isAdult)
+	v.RegisterValidation("vehicleyear", isVehicleYear)
+	v.RegisterValidation("licenseplate", matches(licensePlatePattern))
+	v.RegisterValidation("phone", matches(phonePattern))
+	return v
+}
+
+// Struct validates s and returns an apperror validation error listing every
+// invalid field, or nil
+func Struct(s interface{}) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return fmt.Errorf("error validating request: %w", err)
+	}
+
+	fields := make([]apperror.FieldError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		fields = append(fields, apperror.FieldError{
+			Field:   fieldPath(fe),
+			Code:    fe.Tag(),
+			Message: message(fe),
+		})
+	}
+	return apperror.Validation("invalid_fields", "request has invalid fields", fields...)
+}
+
+// fieldPath drops the struct name from the namespace, e.g.
+// "CreateRideRequest.originLatitude" becomes "originLatitude"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "min", "gte":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "ltefield":
+		return "must not exceed " + fe.Param()
+	case "required_with":
+		return "is required with " + fe.Param()
+	case "required_without":
+		return "is required without " + fe.Param()
+	case "datetime":
+		return "must be a time of day such as 08:30"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "latitude":
+		return "must be between -90 and 90"
+	case "longitude":
+		return "must be between -180 and 180"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "localtime":
+		return "must be an RFC 3339 timestamp or a local time such as 2006-01-02T15:04"
+	case "timezone":
+		return "must be an IANA time zone such as America/New_York"
+	case "adult":
+		return fmt.Sprintf("must be a YYYY-MM-DD date at least %d years ago", MinimumAge)
+	case "vehicleyear":
+		return fmt.Sprintf("must be between 1900 and %d", time.Now().Year()+1)
+	case "licenseplate":
+		return "must be 2 to 20 letters, digits, spaces or dashes"
+	case "phone":
+		return "must be a valid phone number"
+	default:
+		return "is invalid"
+	}
+}
+
+// isLocalTime accepts RFC 3339 timestamps and local times without an offset,
+// which are resolved against the ride's time zone later
+func isLocalTime(fl validator.FieldLevel) bool {
+	return timezone.IsTimestamp(fl.Field().String())
+}
+
+func isTimeZone(fl validator.FieldLevel) bool {
+	return timezone.Valid(fl.Field().String())
+}
+
+func isAdult(fl validator.FieldLevel) bool {
+	dob, err := time.Parse(dateLayout, fl.Field().String())
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	return !dob.After(now.AddDate(-MinimumAge, 0, 0)) && dob.After(now.AddDate(-maximumAge, 0, 0))
+}
+
+// isVehicleYear mirrors the valid_year check on the vehicles table
+func isVehicleYear(fl validator.FieldLevel) bool {
+	year := fl.Field().Int()
+	return year >= 1900 && year <= int64(time.Now().Year()+1)
+}
+
+func matches(pattern *regexp.Regexp) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		return pattern.MatchString(fl.Field().String())
+	}
+}
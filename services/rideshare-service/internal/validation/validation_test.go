@@ -0,0 +1,189 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ahmed-Abbas-2077/rideshare-service/internal/models"
+	"github.com/google/uuid"
+	"platform/shared/apperror"
+)
+
+func fieldCodes(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperror.KindValidation {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	codes := make(map[string]string)
+	for _, f := range appErr.Fields {
+		codes[f.Field] = f.Code
+	}
+	return codes
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func TestStructReportsEveryInvalidField(t *testing.T) {
+	req := &models.CreateRideRequest{
+		OriginAddress:        "  ",
+		OriginLatitude:       float(91),
+		OriginLongitude:      float(31.2),
+		DestinationAddress:   "Campus",
+		DestinationLatitude:  float(30.0),
+		DestinationLongitude: float(-181),
+		DepartureTime:        "tomorrow",
+		EstimatedArrivalTime: time.Now().Add(2 * time.Hour).Format(time.RFC3339),
+		MaxPassengers:        3,
+		AvailableSeats:       4,
+		PricePerSeat:         -5,
+	}
+
+	codes := fieldCodes(t, Struct(req))
+	want := map[string]string{
+		"vehicleId":            "required",
+		"originAddress":        "notblank",
+		"originLatitude":       "latitude",
+		"destinationLongitude": "longitude",
+		"departureTime":        "localtime",
+		"availableSeats":       "ltefield",
+		"pricePerSeat":         "gte",
+	}
+	for field, code := range want {
+		if codes[field] != code {
+			t.Errorf("expected %s to fail %s, got %q", field, code, codes[field])
+		}
+	}
+	if len(codes) != len(want) {
+		t.Errorf("expected %d field errors, got %v", len(want), codes)
+	}
+}
+
+func TestStructAcceptsValidVehicle(t *testing.T) {
+	req := &models.CreateVehicleRequest{
+		Make:         "Toyota",
+		Model:        "Corolla",
+		Year:         2020,
+		Color:        "White",
+		LicensePlate: "ABC-1234",
+		Capacity:     4,
+	}
+	if err := Struct(req); err != nil {
+		t.Errorf("expected vehicle to be valid, got %v", err)
+	}
+
+	req.Year = time.Now().Year() + 2
+	req.LicensePlate = "!!"
+	codes := fieldCodes(t, Struct(req))
+	if codes["year"] != "vehicleyear" || codes["licensePlate"] != "licenseplate" {
+		t.Errorf("expected year and licensePlate to fail, got %v", codes)
+	}
+}
+
+func TestAdultRule(t *testing.T) {
+	type registration struct {
+		DateOfBirth string `json:"dateOfBirth" validate:"adult"`
+	}
+
+	now := time.Now()
+	cases := map[string]bool{
+		now.AddDate(-MinimumAge, 0, 0).Format(dateLayout):   true,
+		now.AddDate(-MinimumAge, 0, 1).Format(dateLayout):   false,
+		now.AddDate(-maximumAge-1, 0, 0).Format(dateLayout): false,
+		"01/02/1990": false,
+	}
+	for dob, valid := range cases {
+		err := Struct(&registration{DateOfBirth: dob})
+		if (err == nil) != valid {
+			t.Errorf("dateOfBirth %s: expected valid=%v, got %v", dob, valid, err)
+		}
+	}
+}
+
+func TestOptionalPointersAreOnlyCheckedWhenSet(t *testing.T) {
+	if err := Struct(&models.UpdateRideRequest{}); err != nil {
+		t.Errorf("expected empty update to be valid, got %v", err)
+	}
+
+	negative := -1.0
+	codes := fieldCodes(t, Struct(&models.UpdateRideRequest{PricePerSeat: &negative}))
+	if codes["pricePerSeat"] != "gte" {
+		t.Errorf("expected pricePerSeat to fail gte, got %v", codes)
+	}
+
+	if err := Struct(&models.MarkChatReadRequest{MessageID: uuid.New()}); err != nil {
+		t.Errorf("expected non-nil UUID to satisfy required, got %v", err)
+	}
+}
+
+func TestSavedSearchRequest(t *testing.T) {
+	req := &models.SavedSearchRequest{
+		OriginLatitude:      30.04,
+		OriginLongitude:     31.23,
+		DestinationLatitude: float(30.1),
+		RadiusMeters:        20000,
+		Days:                []int{1, 7},
+		EarliestTime:        "8am",
+		Filters:             models.SearchFilters{MinSeats: -1},
+	}
+
+	codes := fieldCodes(t, Struct(req))
+	want := map[string]string{
+		"destinationLongitude": "required_with",
+		"radiusMeters":         "lte",
+		"days[1]":              "max",
+		"earliestTime":         "datetime",
+		"filters.minSeats":     "min",
+	}
+	for field, code := range want {
+		if codes[field] != code {
+			t.Errorf("expected %s to fail %s, got %q", field, code, codes[field])
+		}
+	}
+	if len(codes) != len(want) {
+		t.Errorf("expected %d field errors, got %v", len(want), codes)
+	}
+}
+
+func TestWaypointsAndStops(t *testing.T) {
+	req := &models.CreateRideRequest{
+		VehicleID:            uuid.New(),
+		OriginAddress:        "Campus",
+		DestinationAddress:   "Metro",
+		DepartureTime:        "2030-01-02T08:00",
+		EstimatedArrivalTime: "2030-01-02T09:00",
+		MaxPassengers:        3,
+		AvailableSeats:       3,
+		Waypoints: []models.WaypointRequest{
+			{Address: "North gate", PlannedTime: "2030-01-02T08:20"},
+			{Address: "East gate", Latitude: float(95), PlannedTime: "soon"},
+		},
+	}
+
+	codes := fieldCodes(t, Struct(req))
+	want := map[string]string{
+		"waypoints[1].latitude":    "latitude",
+		"waypoints[1].plannedTime": "localtime",
+	}
+	for field, code := range want {
+		if codes[field] != code {
+			t.Errorf("expected %s to fail %s, got %q", field, code, codes[field])
+		}
+	}
+	if len(codes) != len(want) {
+		t.Errorf("expected %d field errors, got %v", len(want), codes)
+	}
+
+	// A pickup stop stands in for the pickup address
+	stop := 1
+	if err := Struct(&models.JoinRideRequest{PickupStop: &stop}); err != nil {
+		t.Errorf("expected a pickup stop to be enough, got %v", err)
+	}
+	codes = fieldCodes(t, Struct(&models.JoinRideRequest{}))
+	if codes["pickupAddress"] != "required_without" {
+		t.Errorf("expected pickupAddress to be required without a stop, got %v", codes)
+	}
+}
@@ -0,0 +1,441 @@
+-- Brings a database created from the original schema up to date with
+-- init-scripts/init_schema.pgsql. Every statement checks for what it adds,
+-- so this also runs cleanly against a database created from the current
+-- schema.
+
+DO $$
+BEGIN
+    CREATE TYPE report_status AS ENUM ('pending', 'reviewing', 'resolved', 'dismissed');
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+DO $$
+BEGIN
+    CREATE TYPE report_category AS ENUM ('harassment', 'unsafe_driving', 'no_show', 'inappropriate_behavior', 'fraud', 'other');
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE vehicles
+    ADD COLUMN IF NOT EXISTS fuel_type VARCHAR(20) CHECK (fuel_type IN ('petrol', 'diesel', 'hybrid', 'electric', 'lpg')),
+    ADD COLUMN IF NOT EXISTS efficiency DECIMAL(5,2);
+
+ALTER TABLE rides
+    ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN IF NOT EXISTS same_university BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS same_faculty BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS same_batch BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS same_gender BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS reminder_sent_at TIMESTAMP WITH TIME ZONE;
+
+-- Existing requests cover the whole route, from the origin to the destination
+ALTER TABLE ride_requests
+    ADD COLUMN IF NOT EXISTS pickup_stop INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS dropoff_stop INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS fare_breakdown JSONB;
+
+ALTER TABLE ride_requests DROP CONSTRAINT IF EXISTS valid_stops;
+ALTER TABLE ride_requests
+    ADD CONSTRAINT valid_stops CHECK (pickup_stop >= 0 AND dropoff_stop > pickup_stop);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    event_id UUID,
+    notification_type VARCHAR(50) NOT NULL DEFAULT 'general',
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    related_ride_id UUID REFERENCES rides(ride_id),
+    is_read BOOLEAN DEFAULT FALSE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS event_id UUID,
+    ADD COLUMN IF NOT EXISTS notification_type VARCHAR(50) NOT NULL DEFAULT 'general',
+    ADD COLUMN IF NOT EXISTS read_at TIMESTAMP WITH TIME ZONE;
+
+-- Append-only history of changes to rides, vehicles and users. user_id is
+-- who made the change and owner_id whose entity it was, so users can read
+-- the history of their own rides and vehicles.
+CREATE TABLE IF NOT EXISTS audit_log (
+    log_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(user_id),
+    owner_id UUID REFERENCES users(user_id),
+    action VARCHAR(100) NOT NULL,
+    table_name VARCHAR(100) NOT NULL,
+    record_id VARCHAR(100) NOT NULL,
+    old_values JSONB,
+    new_values JSONB,
+    request_id VARCHAR(100),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE audit_log
+    ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES users(user_id),
+    ADD COLUMN IF NOT EXISTS request_id VARCHAR(100);
+
+-- Blocks are one-directional records but are enforced both ways
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT different_block_users CHECK (blocker_id != blocked_id)
+);
+
+-- Abuse reports feed the admin review queue
+CREATE TABLE IF NOT EXISTS abuse_reports (
+    report_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    reporter_id UUID NOT NULL REFERENCES users(user_id),
+    reported_user_id UUID NOT NULL REFERENCES users(user_id),
+    ride_id UUID NOT NULL REFERENCES rides(ride_id),
+    category report_category NOT NULL,
+    description TEXT NOT NULL,
+    status report_status NOT NULL DEFAULT 'pending',
+    reviewed_by UUID REFERENCES users(user_id),
+    resolution_notes TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT different_report_users CHECK (reporter_id != reported_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_blocks_blocked_id_idx ON user_blocks(blocked_id);
+CREATE INDEX IF NOT EXISTS abuse_reports_status_created_idx ON abuse_reports(status, created_at);
+CREATE INDEX IF NOT EXISTS abuse_reports_reported_user_idx ON abuse_reports(reported_user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS abuse_reports_once_per_ride_idx ON abuse_reports(reporter_id, reported_user_id, ride_id);
+
+-- One conversation per ride, open to the host and accepted passengers
+CREATE TABLE IF NOT EXISTS chat_messages (
+    message_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message_seq BIGSERIAL NOT NULL UNIQUE,
+    ride_id UUID NOT NULL REFERENCES rides(ride_id),
+    sender_id UUID NOT NULL REFERENCES users(user_id),
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chat_body_not_empty CHECK (LENGTH(body) > 0)
+);
+
+CREATE TABLE IF NOT EXISTS chat_read_receipts (
+    ride_id UUID NOT NULL REFERENCES rides(ride_id),
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    last_read_message_id UUID NOT NULL REFERENCES chat_messages(message_id),
+    last_read_seq BIGINT NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ride_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS chat_messages_ride_seq_idx ON chat_messages(ride_id, message_seq);
+
+-- Which channels each user wants to be notified on; missing rows mean the defaults
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    inbox_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    webhook_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    webhook_url TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT webhook_url_required CHECK (NOT webhook_enabled OR webhook_url IS NOT NULL)
+);
+
+-- Each event lands in a user's inbox at most once, even if it is redelivered
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_user_event_idx ON notifications(user_id, event_id);
+CREATE INDEX IF NOT EXISTS notifications_user_unread_idx ON notifications(user_id, created_at) WHERE is_read = FALSE;
+
+-- Email and webhook notifications waiting to be sent. One row per user,
+-- event and channel, so a redelivered event never sends a message twice.
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    delivery_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    message JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, event_id, channel)
+);
+
+CREATE INDEX IF NOT EXISTS notification_deliveries_due_idx ON notification_deliveries(next_attempt_at) WHERE delivered_at IS NULL AND failed_at IS NULL;
+
+-- Transactional outbox: domain events are written in the same transaction as
+-- the change they describe and relayed to RabbitMQ in event_seq order
+CREATE TABLE IF NOT EXISTS outbox_events (
+    event_seq BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    event_version INTEGER NOT NULL,
+    actor_id UUID,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx ON outbox_events(event_seq) WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS audit_log_owner_idx ON audit_log(owner_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_record_idx ON audit_log(table_name, record_id, created_at DESC);
+
+-- Campus community attributes and matching preferences. Attributes only
+-- count for matching once verified by an admin.
+CREATE TABLE IF NOT EXISTS community_profiles (
+    user_id UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    university VARCHAR(100),
+    faculty VARCHAR(100),
+    batch VARCHAR(20),
+    gender VARCHAR(20),
+    verified_at TIMESTAMP WITH TIME ZONE,
+    verified_by UUID REFERENCES users(user_id),
+    prefer_same_university BOOLEAN NOT NULL DEFAULT FALSE,
+    prefer_same_faculty BOOLEAN NOT NULL DEFAULT FALSE,
+    prefer_same_batch BOOLEAN NOT NULL DEFAULT FALSE,
+    prefer_same_gender BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Emissions and money saved by each participant of a completed ride
+CREATE TABLE IF NOT EXISTS ride_impacts (
+    ride_id UUID NOT NULL REFERENCES rides(ride_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('host', 'passenger')),
+    distance_km DECIMAL(10,2) NOT NULL,
+    seats INTEGER NOT NULL DEFAULT 0,
+    co2_avoided_kg DECIMAL(10,2) NOT NULL,
+    money_saved DECIMAL(10,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (ride_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS ride_impacts_user_idx ON ride_impacts(user_id, completed_at DESC);
+CREATE INDEX IF NOT EXISTS ride_impacts_completed_idx ON ride_impacts(completed_at);
+
+-- Searches riders are alerted about when a matching ride is posted
+CREATE TABLE IF NOT EXISTS saved_searches (
+    search_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    name VARCHAR(100),
+    origin_latitude DECIMAL(9,6) NOT NULL,
+    origin_longitude DECIMAL(9,6) NOT NULL,
+    destination_latitude DECIMAL(9,6),
+    destination_longitude DECIMAL(9,6),
+    radius_meters FLOAT NOT NULL,
+    departure_after TIMESTAMP WITH TIME ZONE,
+    departure_before TIMESTAMP WITH TIME ZONE,
+    days SMALLINT[] NOT NULL DEFAULT '{}', -- Weekdays, 0 is Sunday; empty means every day
+    earliest_time TIME,
+    latest_time TIME,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    max_price_per_seat DECIMAL(10,2),
+    min_seats INTEGER NOT NULL DEFAULT 0,
+    pets_allowed BOOLEAN,
+    smoking_allowed BOOLEAN,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS saved_searches_user_idx ON saved_searches(user_id);
+
+-- Geohash cells each saved search's origin radius overlaps
+CREATE TABLE IF NOT EXISTS saved_search_cells (
+    geohash VARCHAR(12) NOT NULL,
+    search_id UUID NOT NULL REFERENCES saved_searches(search_id) ON DELETE CASCADE,
+    PRIMARY KEY (geohash, search_id)
+);
+
+-- UTC hours of the week (0 is Sunday midnight) each saved search covers
+CREATE TABLE IF NOT EXISTS saved_search_buckets (
+    time_bucket SMALLINT NOT NULL CHECK (time_bucket BETWEEN 0 AND 167),
+    search_id UUID NOT NULL REFERENCES saved_searches(search_id) ON DELETE CASCADE,
+    PRIMARY KEY (time_bucket, search_id)
+);
+
+-- Rides each saved search has alerted about, so each is only sent once
+CREATE TABLE IF NOT EXISTS saved_search_alerts (
+    search_id UUID NOT NULL REFERENCES saved_searches(search_id) ON DELETE CASCADE,
+    ride_id UUID NOT NULL REFERENCES rides(ride_id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (search_id, ride_id)
+);
+
+-- Secret tokens of users' iCalendar feeds
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    rotated_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Verification documents hosts upload for themselves and their vehicles.
+-- Files live in the blob store under blob_key.
+CREATE TABLE IF NOT EXISTS documents (
+    document_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    vehicle_id UUID REFERENCES vehicles(vehicle_id) ON DELETE CASCADE,
+    document_type VARCHAR(30) NOT NULL CHECK (document_type IN ('driver_license', 'vehicle_registration', 'vehicle_insurance')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    rejection_reason TEXT,
+    blob_key TEXT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    expires_on DATE NOT NULL,
+    reviewed_by UUID REFERENCES users(user_id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    expiry_reminder_sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    -- Licenses belong to a driver, everything else to a vehicle
+    CONSTRAINT document_subject CHECK ((document_type = 'driver_license') = (vehicle_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS documents_user_idx ON documents(user_id, document_type);
+CREATE INDEX IF NOT EXISTS documents_vehicle_idx ON documents(vehicle_id, document_type);
+CREATE INDEX IF NOT EXISTS documents_pending_idx ON documents(created_at) WHERE status = 'pending';
+
+-- Every stop a ride makes, from the origin (stop 0) to the destination.
+-- available_seats counts the seats free on the leg to the next stop and is
+-- NULL at the destination. Rides created before stops existed are given
+-- theirs by migrations/002_backfill_ride_stops.sql.
+CREATE TABLE IF NOT EXISTS ride_stops (
+    ride_id UUID NOT NULL REFERENCES rides(ride_id) ON DELETE CASCADE,
+    stop_index INTEGER NOT NULL,
+    address TEXT NOT NULL,
+    latitude DECIMAL(9,6) NOT NULL,
+    longitude DECIMAL(9,6) NOT NULL,
+    planned_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    available_seats INTEGER,
+    PRIMARY KEY (ride_id, stop_index),
+    CONSTRAINT valid_stop_index CHECK (stop_index >= 0),
+    CONSTRAINT valid_leg_seats CHECK (available_seats >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS ride_stops_lat_long_idx ON ride_stops(latitude, longitude);
+
+-- The result columns changed, which CREATE OR REPLACE cannot do
+DROP FUNCTION IF EXISTS find_nearby_rides(FLOAT, FLOAT, FLOAT, FLOAT, FLOAT, TIMESTAMP WITH TIME ZONE);
+
+-- Function to find nearby rides. A ride matches when one of its stops is
+-- near the origin and a later stop near the destination, with a seat free on
+-- every leg between them. Each ride is listed once, for its closest pair of
+-- stops, with the addresses and departure time of that pair. Closest rides
+-- come first.
+CREATE OR REPLACE FUNCTION find_nearby_rides(
+    origin_lat FLOAT,
+    origin_lon FLOAT,
+    destination_lat FLOAT,
+    destination_lon FLOAT,
+    radius_meters FLOAT DEFAULT 5000,
+    departure_after TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+) RETURNS TABLE (
+    ride_id UUID,
+    host_id UUID,
+    origin_address TEXT,
+    destination_address TEXT,
+    departure_time TIMESTAMP WITH TIME ZONE,
+    available_seats INTEGER,
+    distance_from_origin FLOAT,
+    distance_from_destination FLOAT,
+    pickup_stop INTEGER,
+    dropoff_stop INTEGER
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT m.* FROM (
+        SELECT DISTINCT ON (r.ride_id)
+            r.ride_id,
+            r.host_id,
+            p.address AS origin_address,
+            d.address AS destination_address,
+            p.planned_time AS departure_time,
+            legs.seats AS available_seats,
+            calculate_distance(origin_lat, origin_lon, p.latitude, p.longitude) AS distance_from_origin,
+            calculate_distance(destination_lat, destination_lon, d.latitude, d.longitude) AS distance_from_destination,
+            p.stop_index AS pickup_stop,
+            d.stop_index AS dropoff_stop
+        FROM rides r
+        JOIN ride_stops p ON p.ride_id = r.ride_id
+        JOIN ride_stops d ON d.ride_id = r.ride_id AND d.stop_index > p.stop_index
+        CROSS JOIN LATERAL (
+            SELECT MIN(l.available_seats) AS seats
+            FROM ride_stops l
+            WHERE l.ride_id = r.ride_id
+              AND l.stop_index >= p.stop_index
+              AND l.stop_index < d.stop_index
+        ) legs
+        WHERE r.status = 'scheduled'
+          AND p.planned_time > departure_after
+          AND legs.seats > 0
+          AND calculate_distance(origin_lat, origin_lon, p.latitude, p.longitude) <= radius_meters
+          AND calculate_distance(destination_lat, destination_lon, d.latitude, d.longitude) <= radius_meters
+        ORDER BY r.ride_id,
+            calculate_distance(origin_lat, origin_lon, p.latitude, p.longitude) +
+            calculate_distance(destination_lat, destination_lon, d.latitude, d.longitude)
+    ) m
+    ORDER BY m.distance_from_origin + m.distance_from_destination, m.departure_time;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Update available seats when a request is accepted, on the legs between
+-- the request's stops. The ride's own count is what is free along the whole
+-- route.
+CREATE OR REPLACE FUNCTION update_available_seats()
+RETURNS TRIGGER AS $$
+DECLARE
+    seat_change INTEGER := 0;
+BEGIN
+    IF NEW.status = 'accepted' AND OLD.status = 'pending' THEN
+        seat_change := -NEW.seats_requested;
+    ELSIF NEW.status IN ('rejected', 'cancelled') AND OLD.status = 'accepted' THEN
+        seat_change := NEW.seats_requested;
+    END IF;
+
+    IF seat_change <> 0 THEN
+        UPDATE ride_stops
+        SET available_seats = available_seats + seat_change
+        WHERE ride_id = NEW.ride_id
+          AND stop_index >= NEW.pickup_stop
+          AND stop_index < NEW.dropoff_stop;
+
+        UPDATE rides
+        SET available_seats = (
+            SELECT MIN(s.available_seats) FROM ride_stops s WHERE s.ride_id = NEW.ride_id
+        )
+        WHERE ride_id = NEW.ride_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The audit log is never rewritten
+CREATE OR REPLACE FUNCTION prevent_audit_log_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_abuse_reports_updated_at ON abuse_reports;
+CREATE TRIGGER update_abuse_reports_updated_at
+BEFORE UPDATE ON abuse_reports
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION prevent_audit_log_changes();
@@ -0,0 +1,15 @@
+-- Rides created before waypoints were added have no stops. Nearby search
+-- matches stops and seat accounting updates per-leg seats, so until they
+-- have some these rides are never found and accepting a request for one
+-- leaves its seats untouched. Each gets its origin and destination as stops,
+-- the origin leg offering the seats the ride has left.
+--
+-- Rides that already have stops are skipped, so this can be run again.
+INSERT INTO ride_stops (ride_id, stop_index, address, latitude, longitude, planned_time, available_seats)
+SELECT r.ride_id, 0, r.origin_address, r.origin_latitude, r.origin_longitude, r.departure_time, r.available_seats
+FROM rides r
+WHERE NOT EXISTS (SELECT 1 FROM ride_stops s WHERE s.ride_id = r.ride_id)
+UNION ALL
+SELECT r.ride_id, 1, r.destination_address, r.destination_latitude, r.destination_longitude, r.estimated_arrival_time, NULL
+FROM rides r
+WHERE NOT EXISTS (SELECT 1 FROM ride_stops s WHERE s.ride_id = r.ride_id);
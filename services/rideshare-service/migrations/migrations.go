@@ -0,0 +1,10 @@
+// Package migrations holds the SQL that brings an existing database up to
+// date with the schema in init-scripts. The server applies it on startup.
+package migrations
+
+import "embed"
+
+// Files are applied in name order
+//
+//go:embed *.sql
+var Files embed.FS
@@ -1,24 +1,47 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
+	"platform/shared/health"
+	"platform/shared/logging"
+	"platform/shared/tracing"
 	"search-service/internal/cache"
 	"search-service/internal/config"
 	"search-service/internal/db"
 	"search-service/internal/grpc"
+	"search-service/internal/metrics"
 	"search-service/internal/service"
 	"search-service/internal/transport"
 )
 
+// readinessTimeout bounds each dependency check of /readyz
+const readinessTimeout = 2 * time.Second
+
 func main() {
+	if _, err := logging.Setup(os.Getenv("LOG_LEVEL")); err != nil {
+		logging.Fatal("Logging error", "error", err)
+	}
+
 	// Load environment-based config
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Config error: %v", err)
+		logging.Fatal("Config error", "error", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), "search-service")
+	if err != nil {
+		logging.Fatal("Tracing error", "error", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
 
 	// Connect to Redis
 	redisClient := cache.NewRedisClient(cfg.RedisAddress)
@@ -27,7 +50,7 @@ func main() {
 	geoClient := grpc.NewGeoClient(cfg)
 
 	// Inject the repository logic
-	rideRepo := &db.MockRideRepo{}
+	rideRepo := db.NewTracedRideRepo(&db.MockRideRepo{})
 	// Inject into search logic
 	searchLogic := service.NewSearchService(geoClient, redisClient)
 
@@ -35,14 +58,29 @@ func main() {
 	handler := transport.NewHandler(searchLogic, rideRepo)
 	router := transport.SetupRouter(handler)
 
+	// Without Redis and geo-distance-service no search can be answered
+	checker := health.NewChecker(readinessTimeout)
+	checker.Add("redis", func(ctx context.Context) error {
+		return redisClient.Client.Ping(ctx).Err()
+	})
+	checker.Add("geo-distance-service", geoClient.Ping)
+
+	// /metrics and the probes are served beside the API rather than by its
+	// router, which only carries the routes in the OpenAPI spec
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("GET /livez", checker.Live)
+	mux.HandleFunc("GET /readyz", checker.Ready)
+	mux.Handle("/", logging.Middleware(metrics.Middleware(router)))
+
 	// Port from .env or default to 8080
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Search service listening on port %s", port)
-	if err := http.ListenAndServe(":"+port, router); err != nil {
-		log.Fatalf("HTTP server failed: %v", err)
+	slog.Info("Search service listening", "port", port)
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		logging.Fatal("HTTP server failed", "error", err)
 	}
 }
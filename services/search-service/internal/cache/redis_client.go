@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
 	pb "search-service/proto"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -19,6 +21,9 @@ func NewRedisClient(addr string) *RedisClient {
 	rdb := redis.NewClient(&redis.Options{
 		Addr: addr,
 	})
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		slog.Warn("Redis calls will not be traced", "error", err)
+	}
 	return &RedisClient{Client: rdb}
 }
 
@@ -0,0 +1,65 @@
+// Package community decides which rides a rider may see from the verified
+// campus attributes of the host and the rider, following the same rules as
+// the rideshare service
+package community
+
+import "strings"
+
+// Audience restricts a ride to riders who share verified attributes with the
+// host. As a rider's matching preferences it restricts the rides they see.
+type Audience struct {
+	SameUniversity bool `json:"sameUniversity"`
+	SameFaculty    bool `json:"sameFaculty"`
+	SameBatch      bool `json:"sameBatch"`
+	SameGender     bool `json:"sameGender"`
+}
+
+// Profile is a user's community attributes and matching preferences
+type Profile struct {
+	University  string
+	Faculty     string
+	Batch       string
+	Gender      string
+	Verified    bool
+	Preferences Audience
+}
+
+// Restriction is the audience of a ride together with its host's profile
+type Restriction struct {
+	Audience Audience
+	Host     *Profile
+}
+
+// Eligible reports whether rider may see a ride hosted by host with the given
+// audience. Every restriction set by the ride or by the rider's preferences
+// requires both users to have the same verified attribute. Anonymous riders
+// have no profile and only see open rides.
+func Eligible(audience Audience, host, rider *Profile) bool {
+	required := audience
+	if rider != nil {
+		required = Audience{
+			SameUniversity: audience.SameUniversity || rider.Preferences.SameUniversity,
+			SameFaculty:    audience.SameFaculty || rider.Preferences.SameFaculty,
+			SameBatch:      audience.SameBatch || rider.Preferences.SameBatch,
+			SameGender:     audience.SameGender || rider.Preferences.SameGender,
+		}
+	}
+	if required == (Audience{}) {
+		return true
+	}
+	if host == nil || rider == nil || !host.Verified || !rider.Verified {
+		return false
+	}
+
+	return (!required.SameUniversity || same(host.University, rider.University)) &&
+		(!required.SameFaculty || same(host.Faculty, rider.Faculty)) &&
+		(!required.SameBatch || same(host.Batch, rider.Batch)) &&
+		(!required.SameGender || same(host.Gender, rider.Gender))
+}
+
+// same compares attributes ignoring case and surrounding space. Empty
+// attributes match nothing.
+func same(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	return a != "" && strings.EqualFold(a, b)
+}
@@ -0,0 +1,30 @@
+package community
+
+import "testing"
+
+func TestEligible(t *testing.T) {
+	host := &Profile{University: "Cairo University", Batch: "2026", Gender: "female", Verified: true}
+	sameGender := Audience{SameGender: true}
+
+	tests := []struct {
+		name     string
+		audience Audience
+		host     *Profile
+		rider    *Profile
+		want     bool
+	}{
+		{"open ride, anonymous rider", Audience{}, host, nil, true},
+		{"restricted ride, anonymous rider", sameGender, host, nil, false},
+		{"same gender", sameGender, host, &Profile{Gender: "Female", Verified: true}, true},
+		{"different gender", sameGender, host, &Profile{Gender: "male", Verified: true}, false},
+		{"unverified rider", sameGender, host, &Profile{Gender: "female"}, false},
+		{"same university and batch", Audience{SameUniversity: true, SameBatch: true}, host, &Profile{University: "cairo university ", Batch: "2026", Verified: true}, true},
+		{"rider prefers same batch", Audience{}, host, &Profile{Batch: "2025", Verified: true, Preferences: Audience{SameBatch: true}}, false},
+		{"rider prefers same gender, unverified host", Audience{}, &Profile{Gender: "female"}, &Profile{Gender: "female", Verified: true, Preferences: sameGender}, false},
+	}
+	for _, tt := range tests {
+		if got := Eligible(tt.audience, tt.host, tt.rider); got != tt.want {
+			t.Errorf("%s: Eligible = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,17 @@
 package db
 
-import "search-service/proto"
+import (
+	"context"
+
+	"search-service/internal/community"
+	"search-service/proto"
+)
 
 // RideRepo defines an interface for ride data access
 type RideRepo interface {
-	GetAllAvailableRides() ([]*proto.Ride, error)
+	GetAllAvailableRides(ctx context.Context) ([]*proto.Ride, error)
+	GetRideRestrictions(ctx context.Context) (map[string]community.Restriction, error)
+	GetProfile(ctx context.Context, userID string) (*community.Profile, error)
 }
 
 type MockRideRepo struct{}
@@ -15,7 +22,7 @@ func NewMockRideRepo() RideRepo {
 }
 
 // GetAllAvailableRides returns a mocked list of rides
-func (r *MockRideRepo) GetAllAvailableRides() ([]*proto.Ride, error) {
+func (r *MockRideRepo) GetAllAvailableRides(ctx context.Context) ([]*proto.Ride, error) {
 	rides := []*proto.Ride{
 		{
 			RideId:     "ride_1",
@@ -37,6 +44,31 @@ func (r *MockRideRepo) GetAllAvailableRides() ([]*proto.Ride, error) {
 	return rides, nil
 }
 
+// GetRideRestrictions returns a mocked audience for every ride that has one
+func (r *MockRideRepo) GetRideRestrictions(ctx context.Context) (map[string]community.Restriction, error) {
+	return map[string]community.Restriction{
+		"ride_2": {
+			Audience: community.Audience{SameGender: true},
+			Host:     mockProfiles["user_1"],
+		},
+		"ride_3": {
+			Audience: community.Audience{SameUniversity: true, SameBatch: true},
+			Host:     mockProfiles["user_2"],
+		},
+	}, nil
+}
+
+// GetProfile returns a mocked community profile, or nil for unknown users
+func (r *MockRideRepo) GetProfile(ctx context.Context, userID string) (*community.Profile, error) {
+	return mockProfiles[userID], nil
+}
+
+var mockProfiles = map[string]*community.Profile{
+	"user_1": {University: "Cairo University", Faculty: "Engineering", Batch: "2026", Gender: "female", Verified: true},
+	"user_2": {University: "Alexandria University", Faculty: "Medicine", Batch: "2025", Gender: "male", Verified: true},
+	"user_3": {University: "Cairo University", Faculty: "Engineering", Batch: "2026", Gender: "female"},
+}
+
 /**
 package db
 
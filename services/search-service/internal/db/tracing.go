@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+
+	"search-service/internal/community"
+	"search-service/proto"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("search-service/internal/db")
+
+// tracedRideRepo records a span for every repository call. The repository is
+// still a mock, so the spans only mark where data access will happen; they
+// carry no database attributes until a real store replaces it.
+type tracedRideRepo struct {
+	repo RideRepo
+}
+
+// NewTracedRideRepo wraps repo so its calls show up in request traces
+func NewTracedRideRepo(repo RideRepo) RideRepo {
+	return &tracedRideRepo{repo: repo}
+}
+
+func (t *tracedRideRepo) GetAllAvailableRides(ctx context.Context) ([]*proto.Ride, error) {
+	ctx, span := tracer.Start(ctx, "RideRepo.GetAllAvailableRides", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	rides, err := t.repo.GetAllAvailableRides(ctx)
+	return rides, recordError(span, err)
+}
+
+func (t *tracedRideRepo) GetRideRestrictions(ctx context.Context) (map[string]community.Restriction, error) {
+	ctx, span := tracer.Start(ctx, "RideRepo.GetRideRestrictions", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	restrictions, err := t.repo.GetRideRestrictions(ctx)
+	return restrictions, recordError(span, err)
+}
+
+func (t *tracedRideRepo) GetProfile(ctx context.Context, userID string) (*community.Profile, error) {
+	ctx, span := tracer.Start(ctx, "RideRepo.GetProfile", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	profile, err := t.repo.GetProfile(ctx, userID)
+	return profile, recordError(span, err)
+}
+
+// recordError marks span as failed when err is set, and returns err
+func recordError(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
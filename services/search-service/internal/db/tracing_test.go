@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"search-service/internal/community"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type failingRepo struct{ MockRideRepo }
+
+func (*failingRepo) GetProfile(context.Context, string) (*community.Profile, error) {
+	return nil, errors.New("profile store unreachable")
+}
+
+func TestTracedRideRepoRecordsSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	repo := NewTracedRideRepo(&failingRepo{})
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "search")
+	if _, err := repo.GetAllAvailableRides(ctx); err != nil {
+		t.Fatalf("GetAllAvailableRides: %v", err)
+	}
+	if _, err := repo.GetProfile(ctx, "user_1"); err == nil {
+		t.Fatal("GetProfile: expected the wrapped error")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	rides, profile := spans[0], spans[1]
+	if rides.Name() != "RideRepo.GetAllAvailableRides" || rides.Status().Code == codes.Error {
+		t.Errorf("rides span = %s %v", rides.Name(), rides.Status())
+	}
+	if profile.Name() != "RideRepo.GetProfile" || profile.Status().Code != codes.Error {
+		t.Errorf("profile span = %s %v, want a failed GetProfile span", profile.Name(), profile.Status())
+	}
+	if rides.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("repository span is not a child of the request span")
+	}
+}
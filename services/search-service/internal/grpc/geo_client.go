@@ -2,27 +2,58 @@ package grpc
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"time"
 
+	"platform/shared/apperror"
+	"platform/shared/grpclogging"
+	"platform/shared/logging"
 	"search-service/internal/config"
+	"search-service/internal/metrics"
 	pb "search-service/proto"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 type GeoClient struct {
 	client pb.GeoDistanceServiceClient
+	health healthpb.HealthClient
 }
 
 func NewGeoClient(cfg *config.Config) *GeoClient {
-	conn, err := grpc.Dial(cfg.GeoGRPCAddress, grpc.WithInsecure())
+	// Each call gets a client span, and the trace context and request ID
+	// travel in its metadata
+	conn, err := grpc.Dial(cfg.GeoGRPCAddress,
+		grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(metrics.UnaryClientInterceptor, grpclogging.UnaryClientInterceptor),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
 	if err != nil {
-		log.Fatalf("failed to connect to geo-distance-service: %v", err)
+		logging.Fatal("Failed to connect to geo-distance-service", "error", err)
 	}
 
 	client := pb.NewGeoDistanceServiceClient(conn)
-	return &GeoClient{client: client}
+	return &GeoClient{client: client, health: healthpb.NewHealthClient(conn)}
+}
+
+// Ping asks geo-distance-service over the gRPC health protocol whether it is
+// serving
+func (g *GeoClient) Ping(ctx context.Context) error {
+	resp, err := g.health.Check(ctx, &healthpb.HealthCheckRequest{
+		Service: pb.GeoDistanceService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		return fmt.Errorf("error checking geo-distance-service: %w", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("geo-distance-service is %s", resp.Status)
+	}
+	return nil
 }
 
 func (g *GeoClient) FilterRides(ctx context.Context, point *pb.Point, rides []*pb.Ride, matchType pb.MatchType) ([]*pb.Ride, error) {
@@ -37,8 +68,38 @@ func (g *GeoClient) FilterRides(ctx context.Context, point *pb.Point, rides []*p
 
 	resp, err := g.client.FilterRidesByProximity(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, mapGeoError(err)
 	}
 
 	return resp.Rides, nil
 }
+
+// mapGeoError turns gRPC status codes from geo-distance-service into domain
+// errors, so a bad point is reported as a client error rather than a 500
+func mapGeoError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("error filtering rides: %w", err)
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code := "invalid_point"
+		var fields []apperror.FieldError
+		for _, detail := range st.Details() {
+			switch d := detail.(type) {
+			case *errdetails.ErrorInfo:
+				code = d.Reason
+			case *errdetails.BadRequest:
+				for _, v := range d.FieldViolations {
+					fields = append(fields, apperror.FieldError{Field: v.Field, Code: code, Message: v.Description})
+				}
+			}
+		}
+		return apperror.Validation(code, st.Message(), fields...)
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return apperror.Unavailable("geo_unavailable", "geo-distance-service is unavailable")
+	default:
+		return fmt.Errorf("error filtering rides: %w", err)
+	}
+}
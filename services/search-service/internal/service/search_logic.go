@@ -3,10 +3,12 @@ package service
 import (
 	"context"
 	//"encoding/json"
-	"errors"
 	"time"
 
+	"platform/shared/apperror"
 	"search-service/internal/cache"
+	"search-service/internal/community"
+	"search-service/internal/metrics"
 	pb "search-service/proto"
 )
 
@@ -22,6 +24,10 @@ type GeoDistanceClient interface {
 type SearchInput struct {
 	Start *pb.Point
 	End   *pb.Point
+	// Viewer is the searching user's community profile, nil when anonymous
+	Viewer *community.Profile
+	// Restrictions holds the audience of each restricted ride by ride ID
+	Restrictions map[string]community.Restriction
 }
 
 func NewSearchService(geoClient GeoDistanceClient, redisClient *cache.RedisClient) *SearchService {
@@ -32,8 +38,8 @@ func NewSearchService(geoClient GeoDistanceClient, redisClient *cache.RedisClien
 }
 
 func (s *SearchService) SearchRides(ctx context.Context, input SearchInput, allRides []*pb.Ride) ([]*pb.Ride, error) {
-	if input.Start == nil || input.End == nil {
-		return nil, errors.New("start and end points are required")
+	if err := validateSearchInput(input); err != nil {
+		return nil, err
 	}
 
 	// Generate cache key
@@ -42,9 +48,12 @@ func (s *SearchService) SearchRides(ctx context.Context, input SearchInput, allR
 	cacheKey := cache.GenerateCacheKey(startHash, endHash)
 
 	// Check cache first
+	// Cached results are shared by all users, so audiences are applied after
 	if cached, err := s.RedisClient.GetCachedSearch(ctx, cacheKey); err == nil && cached != nil {
-		return cached, nil
+		metrics.CacheHit()
+		return filterByAudience(cached, input), nil
 	}
+	metrics.CacheMiss()
 
 	// Start filtering using geo-distance-service
 	startFiltered, err := s.GeoClient.FilterRides(ctx, input.Start, allRides, pb.MatchType_START)
@@ -63,5 +72,41 @@ func (s *SearchService) SearchRides(ctx context.Context, input SearchInput, allR
 		return nil, err
 	}
 
-	return endFiltered, nil
+	return filterByAudience(endFiltered, input), nil
+}
+
+// filterByAudience drops the rides the viewer may not join, because of the
+// ride's audience or the viewer's own matching preferences
+func filterByAudience(rides []*pb.Ride, input SearchInput) []*pb.Ride {
+	visible := make([]*pb.Ride, 0, len(rides))
+	for _, ride := range rides {
+		restriction := input.Restrictions[ride.RideId]
+		if community.Eligible(restriction.Audience, restriction.Host, input.Viewer) {
+			visible = append(visible, ride)
+		}
+	}
+	return visible
+}
+
+func validateSearchInput(input SearchInput) error {
+	if input.Start == nil || input.End == nil {
+		return apperror.Validation("missing_points", "start and end points are required")
+	}
+
+	var fields []apperror.FieldError
+	checkPoint := func(prefix string, p *pb.Point) {
+		if p.Lat < -90 || p.Lat > 90 {
+			fields = append(fields, apperror.FieldError{Field: prefix + "_lat", Code: "out_of_range", Message: "latitude must be between -90 and 90"})
+		}
+		if p.Lng < -180 || p.Lng > 180 {
+			fields = append(fields, apperror.FieldError{Field: prefix + "_lng", Code: "out_of_range", Message: "longitude must be between -180 and 180"})
+		}
+	}
+	checkPoint("start", input.Start)
+	checkPoint("end", input.End)
+
+	if len(fields) > 0 {
+		return apperror.Validation("invalid_coordinates", "coordinates are out of range", fields...)
+	}
+	return nil
 }
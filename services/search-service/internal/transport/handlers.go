@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"platform/shared/logging"
+	"platform/shared/problem"
 	"search-service/internal/db"
 
 	"search-service/internal/service"
@@ -12,13 +14,19 @@ import (
 
 type Handler struct {
 	SearchService *service.SearchService
-	RideRepo      *db.MockRideRepo
+	RideRepo      db.RideRepo
 }
 
-func NewHandler(searchService *service.SearchService, rideRepo *db.MockRideRepo) *Handler {
+func NewHandler(searchService *service.SearchService, rideRepo db.RideRepo) *Handler {
 	return &Handler{SearchService: searchService, RideRepo: rideRepo}
 }
 
+// userIDHeader carries the ID of the searching user. The gateway sets it
+// from the verified token and drops any value sent by clients, so the
+// service must only be reachable through the gateway. Searches without it
+// are anonymous.
+const userIDHeader = "X-User-ID"
+
 type searchRequest struct {
 	StartLat float64 `json:"start_lat"`
 	StartLng float64 `json:"start_lng"`
@@ -29,7 +37,7 @@ type searchRequest struct {
 func (h *Handler) SearchRidesHandler(w http.ResponseWriter, r *http.Request) {
 	var req searchRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		problem.InvalidBody(w, r)
 		return
 	}
 
@@ -39,16 +47,34 @@ func (h *Handler) SearchRidesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch all available rides from the repository
-	allRides, err := h.RideRepo.GetAllAvailableRides()
+	allRides, err := h.RideRepo.GetAllAvailableRides(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error fetching rides", "error", err)
+		problem.Error(w, r, err)
+		return
+	}
+
+	// Audiences are enforced with verified profiles only
+	input.Restrictions, err = h.RideRepo.GetRideRestrictions(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error fetching ride audiences", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
+	if userID := r.Header.Get(userIDHeader); userID != "" {
+		input.Viewer, err = h.RideRepo.GetProfile(r.Context(), userID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error fetching profile", "userID", userID, "error", err)
+			problem.Error(w, r, err)
+			return
+		}
+	}
 
 	// Call SearchService to get the filtered rides
 	matches, err := h.SearchService.SearchRides(r.Context(), input, allRides)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error searching rides", "error", err)
+		problem.Error(w, r, err)
 		return
 	}
 
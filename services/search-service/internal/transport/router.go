@@ -1,13 +1,25 @@
 package transport
 
 import (
-	//"net/http"
+	"net/http"
+
+	"platform/shared/problem"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
 func SetupRouter(handler *Handler) *mux.Router {
 	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.New(http.StatusNotFound, "route_not_found", "No route matches "+r.URL.Path).Write(w, r)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem.New(http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+r.URL.Path).Write(w, r)
+	})
+
+	// Continue the caller's trace, or start one, for every request
+	router.Use(otelmux.Middleware("search-service"))
 
 	// Register your search endpoint
 	router.HandleFunc("/search", handler.SearchRidesHandler).Methods("POST")
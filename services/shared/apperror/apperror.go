@@ -0,0 +1,95 @@
+// Package apperror defines the typed errors the service layer returns, so
+// transports can map them to a response without matching on message text.
+package apperror
+
+import "errors"
+
+// Kind classifies an error by how a client should react to it
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindUnavailable
+)
+
+// FieldError describes a problem with a single input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with a stable, machine-readable code. Messages may
+// change wording; codes may not.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether target has the same code, so callers can compare with
+// the sentinels below even when the message was formatted differently.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// New creates an error of the given kind
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Forbidden creates an error for an authenticated caller who lacks access
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// Unauthorized creates an error for a missing or invalid identity
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+// Conflict creates an error for a request that clashes with current state
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// Validation creates an error for invalid input, optionally listing the
+// offending fields
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+// Invalid creates a validation error for a single field
+func Invalid(field, code, message string) *Error {
+	return Validation(code, message, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Unavailable creates an error for a dependency that could not be reached
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+// KindOf returns the kind of the first *Error in err's chain, or KindInternal
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
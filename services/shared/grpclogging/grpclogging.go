@@ -0,0 +1,56 @@
+// Package grpclogging carries the request ID across gRPC calls, so the lines
+// a server logs while handling a call can be found alongside those of the
+// request that caused it.
+package grpclogging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"platform/shared/logging"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDMetadata carries the request ID in gRPC metadata
+const RequestIDMetadata = "x-request-id"
+
+// UnaryClientInterceptor passes the request ID on in the metadata of
+// outgoing gRPC calls
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := logging.RequestID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// UnaryServerInterceptor gives every call an ID, reusing the one the caller
+// sent in its metadata when there is one, and logs the call once it is handled
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var caller string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDMetadata); len(values) > 0 {
+			caller = values[0]
+		}
+	}
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID(caller))
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	// Health probes arrive every few seconds and would drown out real calls
+	level := slog.LevelInfo
+	if strings.HasPrefix(info.FullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		level = slog.LevelDebug
+	}
+	logging.FromContext(ctx).Log(ctx, level, "Call handled",
+		"method", info.FullMethod,
+		"code", status.Code(err).String(),
+		"duration_ms", time.Since(start).Milliseconds())
+	return resp, err
+}
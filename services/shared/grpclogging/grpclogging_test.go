@@ -0,0 +1,40 @@
+package grpclogging
+
+import (
+	"context"
+	"testing"
+
+	"platform/shared/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestIDCrossesCalls(t *testing.T) {
+	// The client puts the caller's request ID in the outgoing metadata
+	var outgoing metadata.MD
+	ctx := logging.WithRequestID(context.Background(), "search-request")
+	err := UnaryClientInterceptor(ctx, "/geo.Geo/Distance", nil, nil, nil,
+		func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			outgoing, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("UnaryClientInterceptor: %v", err)
+	}
+
+	// The server picks it up from the incoming metadata
+	var got string
+	incoming := metadata.NewIncomingContext(context.Background(), outgoing)
+	_, err = UnaryServerInterceptor(incoming, nil, &grpc.UnaryServerInfo{FullMethod: "/geo.Geo/Distance"},
+		func(ctx context.Context, _ any) (any, error) {
+			got = logging.RequestID(ctx)
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatalf("UnaryServerInterceptor: %v", err)
+	}
+	if got != "search-request" {
+		t.Errorf("server saw request ID %q, want search-request", got)
+	}
+}
@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyReportsEveryCheck(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Add("postgres", func(ctx context.Context) error { return nil })
+	checker.Add("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	rec := httptest.NewRecorder()
+	checker.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decoding report: %v", err)
+	}
+	if report.Status != StatusUnavailable {
+		t.Errorf("report status = %q, want %q", report.Status, StatusUnavailable)
+	}
+	if got := report.Checks["postgres"]; got.Status != StatusOK || got.Error != "" {
+		t.Errorf("postgres = %+v, want ok", got)
+	}
+	if got := report.Checks["redis"]; got.Status != StatusUnavailable || got.Error != "connection refused" {
+		t.Errorf("redis = %+v, want unavailable with error", got)
+	}
+}
+
+func TestReadyWhenAllChecksPass(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Add("postgres", func(ctx context.Context) error { return nil })
+
+	rec := httptest.NewRecorder()
+	checker.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRunAbandonsSlowChecks(t *testing.T) {
+	checker := NewChecker(20 * time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	// Ignores its context, as a driver without deadline support would
+	checker.Add("stuck", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Run took %v, want it bounded by the timeout", elapsed)
+	}
+	if got := report.Checks["stuck"]; got.Status != StatusUnavailable || got.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("stuck = %+v, want unavailable after deadline", got)
+	}
+}
+
+func TestLiveIgnoresChecks(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Add("postgres", func(ctx context.Context) error { return errors.New("down") })
+
+	rec := httptest.NewRecorder()
+	checker.Live(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,167 @@
+// Package logging sets up structured JSON logging. Each request carries its
+// own logger, tagged with the request ID the gateway assigned, so the lines
+// every service logs while handling it can be found from one ID. Secrets are
+// redacted before a line is written.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID between services over HTTP
+const RequestIDHeader = "X-Request-ID"
+
+// Redacted replaces secret values in log lines
+const Redacted = "[REDACTED]"
+
+// Longest request ID accepted from a caller
+const maxRequestIDLength = 100
+
+type (
+	loggerKey    struct{}
+	requestIDKey struct{}
+)
+
+// sensitiveKeys are attribute names whose values are never logged
+var sensitiveKeys = []string{"token", "password", "secret", "authorization", "cookie", "apikey", "api_key"}
+
+// sensitiveValues match secrets inside messages and free-form values
+var sensitiveValues = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	// Bearer credentials in headers
+	{regexp.MustCompile(`(?i)(bearer\s+)[^\s",]+`), "${1}" + Redacted},
+	// JSON fields and key=value pairs named like a secret
+	{regexp.MustCompile(`(?i)("?[a-z_]*(?:token|password|secret)"?\s*[:=]\s*"?)[^\s",}]+`), "${1}" + Redacted},
+	// Passwords in connection URLs
+	{regexp.MustCompile(`(://[^:/@\s]+:)[^@/\s]+@`), "${1}" + Redacted + "@"},
+	// JWTs
+	{regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]*`), Redacted},
+}
+
+// Setup makes a JSON logger writing to stderr at level the default logger.
+// Lines written through the log package go through it as well.
+func Setup(level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		Level:       lvl,
+		ReplaceAttr: redact,
+	}))
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// Fatal logs msg at error level and exits
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// FromContext returns the logger of the request ctx belongs to, or the
+// default logger outside a request
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestID returns the ID of the request ctx belongs to, or an empty string
+// outside a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithContext returns a copy of ctx carrying logger
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// WithRequestID returns a copy of ctx carrying id and a logger tagged with it
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return WithContext(ctx, slog.Default().With("request_id", id))
+}
+
+// NewRequestID returns the ID a caller sent when it can be reused, or a new
+// one when it is missing or too long
+func NewRequestID(caller string) string {
+	if caller == "" || len(caller) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	return caller
+}
+
+// Middleware gives every request an ID, reusing the caller's when there is
+// one, echoes it in the response and logs the request once it is handled
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := NewRequestID(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, id)
+		r = r.WithContext(WithRequestID(r.Context(), id))
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		FromContext(r.Context()).Info("Request handled",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds())
+	})
+}
+
+// redact hides the values of secret attributes and any secrets found in
+// string values, including the message
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+
+	if a.Value.Kind() == slog.KindString {
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	}
+	if err, ok := a.Value.Any().(error); ok {
+		return slog.String(a.Key, RedactString(err.Error()))
+	}
+	return a
+}
+
+// RedactString replaces the secrets found in s
+func RedactString(s string) string {
+	for _, v := range sensitiveValues {
+		s = v.re.ReplaceAllString(s, v.replacement)
+	}
+	return s
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,107 @@
+// Package problem writes errors as RFC 7807 application/problem+json
+// responses.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"platform/shared/apperror"
+)
+
+// ContentType is the media type of every error response
+const ContentType = "application/problem+json"
+
+// typeBase prefixes the code to form the problem type URI
+const typeBase = "/problems/"
+
+// Problem is an RFC 7807 problem detail. Code and Errors are extensions:
+// Code is stable across releases and Errors lists field-level failures.
+type Problem struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty"`
+	Code     string                `json:"code"`
+	Errors   []apperror.FieldError `json:"errors,omitempty"`
+}
+
+// New creates a problem for status with a stable code
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   typeBase + strings.ReplaceAll(code, "_", "-"),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// FromError maps err to a problem. Errors that are not *apperror.Error are
+// reported as a generic internal error so driver and SQL details never reach
+// the client.
+func FromError(err error) *Problem {
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Kind == apperror.KindInternal {
+		return New(http.StatusInternalServerError, "internal_error", "An unexpected error occurred")
+	}
+
+	p := New(StatusOf(appErr.Kind), appErr.Code, appErr.Message)
+	p.Errors = appErr.Fields
+	return p
+}
+
+// StatusOf returns the HTTP status for an error kind
+func StatusOf(kind apperror.Kind) int {
+	switch kind {
+	case apperror.KindValidation:
+		return http.StatusBadRequest
+	case apperror.KindUnauthorized:
+		return http.StatusUnauthorized
+	case apperror.KindForbidden:
+		return http.StatusForbidden
+	case apperror.KindNotFound:
+		return http.StatusNotFound
+	case apperror.KindConflict:
+		return http.StatusConflict
+	case apperror.KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Write sends p as the response to r
+func (p *Problem) Write(w http.ResponseWriter, r *http.Request) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error writes err as a problem response. Callers log the error first, as
+// internal errors reach the client only as a generic message.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	FromError(err).Write(w, r)
+}
+
+// InvalidParam writes a 400 for a malformed path or query parameter
+func InvalidParam(w http.ResponseWriter, r *http.Request, field, detail string) {
+	Error(w, r, apperror.Invalid(field, "invalid_parameter", detail))
+}
+
+// InvalidBody writes a 400 for a request body that could not be decoded
+func InvalidBody(w http.ResponseWriter, r *http.Request) {
+	New(http.StatusBadRequest, "invalid_body", "Request body must be valid JSON").Write(w, r)
+}
+
+// Unauthorized writes a 401 for a request without a usable identity
+func Unauthorized(w http.ResponseWriter, r *http.Request) {
+	New(http.StatusUnauthorized, "unauthenticated", "Authentication is required").Write(w, r)
+}
@@ -0,0 +1,60 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"platform/shared/apperror"
+)
+
+func TestFromErrorMapsWrappedDomainErrors(t *testing.T) {
+	err := fmt.Errorf("ride hidden by block: %w", apperror.NotFound("ride_not_found", "ride not found"))
+
+	p := FromError(err)
+	if p.Status != http.StatusNotFound || p.Code != "ride_not_found" {
+		t.Errorf("expected 404 ride_not_found, got %d %s", p.Status, p.Code)
+	}
+	if p.Detail != "ride not found" {
+		t.Errorf("expected the domain message as detail, got %q", p.Detail)
+	}
+	if p.Type != "/problems/ride-not-found" {
+		t.Errorf("unexpected type %q", p.Type)
+	}
+}
+
+func TestFromErrorHidesInternalErrors(t *testing.T) {
+	p := FromError(errors.New(`pq: relation "rides" does not exist`))
+	if p.Status != http.StatusInternalServerError || p.Code != "internal_error" {
+		t.Errorf("expected 500 internal_error, got %d %s", p.Status, p.Code)
+	}
+	if p.Detail != "An unexpected error occurred" {
+		t.Errorf("expected a generic detail, got %q", p.Detail)
+	}
+}
+
+func TestErrorWritesProblemJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/vehicles", nil)
+
+	Error(rec, req, apperror.Validation("missing_fields", "missing required vehicle fields",
+		apperror.FieldError{Field: "make", Code: "required", Message: "make is required"}))
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("expected %s, got %s", ContentType, ct)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+
+	var body Problem
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("error decoding body: %v", err)
+	}
+	if body.Instance != "/api/vehicles" || len(body.Errors) != 1 || body.Errors[0].Field != "make" {
+		t.Errorf("unexpected body %+v", body)
+	}
+}